@@ -1,7 +1,10 @@
 package main
 
 import (
+	"context"
 	"os"
+	"strconv"
+	"time"
 
 	"rakamin-final-task/config"
 	repo "rakamin-final-task/controllers/repository"
@@ -10,8 +13,11 @@ import (
 	"rakamin-final-task/helpers/configbuilder"
 	"rakamin-final-task/helpers/configreader"
 	"rakamin-final-task/helpers/files"
+	"rakamin-final-task/helpers/imaging"
 	"rakamin-final-task/helpers/jwt"
 	"rakamin-final-task/helpers/log"
+	"rakamin-final-task/helpers/moderation"
+	"rakamin-final-task/helpers/scheduler"
 	"rakamin-final-task/helpers/storage"
 	"rakamin-final-task/helpers/validator"
 	"rakamin-final-task/router"
@@ -19,6 +25,16 @@ import (
 
 const (
 	configFile = "./config/config.json"
+
+	storageDriverLocal = "local"
+
+	// defaultProcessInterval is used when the config has no process interval, since the uploaded photo files are
+	// never processed without the job
+	defaultProcessInterval = 2 * time.Second
+
+	// defaultWatermarkInterval is used when the config has no watermark interval, since the photos of the users with
+	// a watermark are never served to other users without their copy
+	defaultWatermarkInterval = time.Minute
 )
 
 // @title Rakamin Backend
@@ -61,12 +77,53 @@ func main() {
 	validatorLib := validator.Init()
 
 	// Init Storage
-	gcpConfig := storage.GCPConfig{
-		ProjectID:   os.Getenv("GCP_PROJECT_ID"),
-		PrivateKey:  os.Getenv("GCP_PRIVATE_KEY"),
-		ClientEmail: os.Getenv("GCP_CLIENT_EMAIL"),
+	var storageLib storage.Interface
+	signedURLTTL := time.Duration(config.Storage.SignedURLTTLSec) * time.Second
+	switch config.Storage.Driver {
+	case storageDriverLocal:
+		localConfig := storage.LocalConfig{
+			Dir:     config.Storage.Local.Dir,
+			BaseURL: config.Storage.Local.BaseURL,
+			Secret:  config.Storage.Local.Secret,
+		}
+		storageLib = storage.InitLocal(localConfig, signedURLTTL)
+	default:
+		gcpConfig := storage.GCPConfig{
+			ProjectID:   os.Getenv("GCP_PROJECT_ID"),
+			PrivateKey:  os.Getenv("GCP_PRIVATE_KEY"),
+			ClientEmail: os.Getenv("GCP_CLIENT_EMAIL"),
+		}
+		storageLib = storage.Init(gcpConfig, config.Storage.BucketName, signedURLTTL)
 	}
-	storageLib := storage.Init(gcpConfig, config.Storage.BucketName)
+
+	// Init Image Encoders
+	encoderLibs := map[string]imaging.Encoder{
+		imaging.FormatWebP: imaging.InitWebP(config.Storage.Renditions.WebP.Path),
+		imaging.FormatAVIF: imaging.InitAVIF(config.Storage.Renditions.AVIF.Path),
+	}
+	for _, format := range config.Storage.Renditions.Formats {
+		if _, ok := encoderLibs[format]; !ok {
+			logger.Fatal(context.Background(), "Unknown rendition format "+format)
+		}
+	}
+
+	// Init Moderator
+	blockedHashes := make([]uint64, 0, len(config.Moderation.BlockedHashes))
+	for _, blockedHash := range config.Moderation.BlockedHashes {
+		hash, err := strconv.ParseUint(blockedHash, 16, 64)
+		if err != nil {
+			logger.Fatal(context.Background(), "Invalid blocked hash "+blockedHash)
+		}
+		blockedHashes = append(blockedHashes, hash)
+	}
+	moderatorLib := moderation.InitRules(moderation.Rules{
+		RejectWords:     config.Moderation.RejectWords,
+		FlagWords:       config.Moderation.FlagWords,
+		MinWidth:        config.Moderation.MinWidth,
+		MinHeight:       config.Moderation.MinHeight,
+		BlockedHashes:   blockedHashes,
+		MaxHashDistance: config.Moderation.MaxHashDistance,
+	})
 
 	// Init DB Connection
 	db := database.Init(logger, config.SQL)
@@ -77,14 +134,45 @@ func main() {
 
 	// Init Usecase
 	ucParam := uc.InitParam{
-		Repo:         repository,
-		ServerConf:   config.Server,
-		JwtLib:       jwtLib,
-		ValidatorLib: validatorLib,
-		StorageLib:   storageLib,
+		Repo:           repository,
+		ServerConf:     config.Server,
+		StorageConf:    config.Storage,
+		ModerationConf: config.Moderation,
+		JwtLib:         jwtLib,
+		ValidatorLib:   validatorLib,
+		StorageLib:     storageLib,
+		EncoderLibs:    encoderLibs,
+		ModeratorLib:   moderatorLib,
 	}
 	usecase := uc.Init(ucParam)
 
+	// Run a one-off command instead of the server when one is given, e.g. ./app backfill-photo-images
+	if len(os.Args) > 1 {
+		runCommand(logger, usecase, os.Args[1])
+		return
+	}
+
+	// Init Scheduler
+	processInterval := time.Duration(config.Storage.ProcessIntervalSec) * time.Second
+	if processInterval <= 0 {
+		processInterval = defaultProcessInterval
+	}
+
+	watermarkInterval := time.Duration(config.Storage.WatermarkIntervalSec) * time.Second
+	if watermarkInterval <= 0 {
+		watermarkInterval = defaultWatermarkInterval
+	}
+
+	schedulerLib := scheduler.Init(logger)
+	schedulerLib.Register("purge-photo-versions", time.Duration(config.Storage.PurgeIntervalSec)*time.Second, usecase.Photos.PurgeExpiredVersions)
+	schedulerLib.Register("purge-photo-trash", time.Duration(config.Storage.PurgeIntervalSec)*time.Second, usecase.Photos.PurgeTrash)
+	schedulerLib.Register("purge-photo-uploads", time.Duration(config.Storage.PurgeIntervalSec)*time.Second, usecase.Photos.PurgeExpiredUploads)
+	schedulerLib.Register("purge-tus-uploads", time.Duration(config.Storage.PurgeIntervalSec)*time.Second, usecase.Photos.PurgeExpiredTusUploads)
+	schedulerLib.Register("purge-photo-objects", time.Duration(config.Storage.PurgeIntervalSec)*time.Second, usecase.Photos.PurgeUnreferencedObjects)
+	schedulerLib.Register("render-watermarks", watermarkInterval, usecase.Photos.RenderWatermarks)
+	schedulerLib.Register("process-photos", processInterval, usecase.Photos.ProcessPhotos)
+	schedulerLib.Start()
+
 	// Init Router
 	routerParam := router.InitParam{
 		Config:  config,
@@ -94,4 +182,29 @@ func main() {
 	router := router.Init(routerParam)
 
 	router.Run()
+	schedulerLib.Stop()
+}
+
+// runCommand runs a maintenance command of the usecases to completion, the process exits with an error when it fails
+func runCommand(logger log.LogInterface, usecase uc.Usecase, name string) {
+	commands := map[string]scheduler.Job{
+		"backfill-photo-images": usecase.Photos.BackfillImages,
+		"render-watermarks":     usecase.Photos.RenderWatermarks,
+		"process-photos":        usecase.Photos.ProcessPhotos,
+		// The former name of backfill-photo-images, from when only the perceptual hash was backfilled
+		"backfill-perceptual-hash": usecase.Photos.BackfillImages,
+	}
+
+	ctx := context.Background()
+
+	command, ok := commands[name]
+	if !ok {
+		logger.Fatal(ctx, "Unknown command "+name)
+	}
+
+	logger.Info(ctx, "Command "+name+" started")
+	if err := command(ctx); err != nil {
+		logger.Fatal(ctx, "Command "+name+" failed: "+err.Error())
+	}
+	logger.Info(ctx, "Command "+name+" done")
 }
@@ -1,9 +1,10 @@
 package config
 
 type Application struct {
-	Server  Server  `json:"server"`
-	SQL     SQL     `json:"sql"`
-	Storage Storage `json:"storage"`
+	Server     Server     `json:"server"`
+	SQL        SQL        `json:"sql"`
+	Storage    Storage    `json:"storage"`
+	Moderation Moderation `json:"moderation"`
 }
 
 type Server struct {
@@ -24,12 +25,13 @@ type Password struct {
 }
 
 type SQL struct {
-	Host       string     `json:"host"`
-	Port       string     `json:"port"`
-	Username   string     `json:"username"`
-	Password   string     `json:"password"`
-	Database   string     `json:"database"`
-	PoolConfig PoolConfig `json:"poolConfig"`
+	Host           string         `json:"host"`
+	Port           string         `json:"port"`
+	Username       string         `json:"username"`
+	Password       string         `json:"password"`
+	Database       string         `json:"database"`
+	PoolConfig     PoolConfig     `json:"poolConfig"`
+	FullTextSearch FullTextSearch `json:"fullTextSearch"`
 }
 
 type PoolConfig struct {
@@ -39,6 +41,114 @@ type PoolConfig struct {
 	ConnMaxLifetimeSec int64 `json:"connMaxLifetimeSec"`
 }
 
+type FullTextSearch struct {
+	// Languages are PostgreSQL text search configurations, the first one is used for highlighting
+	Languages []string `json:"languages"`
+}
+
 type Storage struct {
-	BucketName string `json:"bucketName"`
+	// Driver is where the photos are stored, gcs for the bucket or local for the filesystem
+	Driver     string       `json:"driver"`
+	BucketName string       `json:"bucketName"`
+	Local      LocalStorage `json:"local"`
+
+	// SignedURLTTLSec is how long a photo URL returned by the API can be used
+	SignedURLTTLSec int64 `json:"signedURLTTLSec"`
+
+	// VersionRetentionSec is how long a replaced photo file is kept for a rollback, it is deleted at once when zero
+	VersionRetentionSec int64 `json:"versionRetentionSec"`
+
+	// TrashRetentionSec is how long a deleted photo stays in the trash, it is never purged when zero
+	TrashRetentionSec int64 `json:"trashRetentionSec"`
+	PurgeIntervalSec  int64 `json:"purgeIntervalSec"`
+
+	// BatchUploadLimit is the maximum number of files of a batch upload, UploadWorkers of them are uploaded at once
+	BatchUploadLimit int `json:"batchUploadLimit"`
+	UploadWorkers    int `json:"uploadWorkers"`
+
+	// DirectUploadMaxSize is the maximum size in bytes of a file uploaded straight to the storage
+	DirectUploadMaxSize int64 `json:"directUploadMaxSize"`
+
+	Tus Tus `json:"tus"`
+
+	// DuplicatePolicy is what happens when a user uploads a file they already have a photo of, unless the upload
+	// asks otherwise: allow, warn to return the duplicated photo along with the new one, or reject
+	DuplicatePolicy string `json:"duplicatePolicy"`
+
+	// ContentMaxAgeSec is how long a client may cache a photo served by the API before revalidating it
+	ContentMaxAgeSec int64 `json:"contentMaxAgeSec"`
+
+	Renditions Renditions `json:"renditions"`
+
+	// WatermarkIntervalSec is how often the missing and outdated watermarked copies are rendered, every minute when
+	// it is unset. A photo is served to other users without URL until its copy is rendered.
+	WatermarkIntervalSec int64 `json:"watermarkIntervalSec"`
+
+	// ProcessIntervalSec is how often the pending jobs of the uploaded photo files are looked for, every 2 seconds when
+	// it is not set since the jobs can not be turned off
+	ProcessIntervalSec int64 `json:"processIntervalSec"`
+
+	Render Render `json:"render"`
+}
+
+// Tus configures the resumable uploads, their data is kept in Dir until they are complete
+type Tus struct {
+	Dir       string `json:"dir"`
+	MaxSize   int64  `json:"maxSize"`
+	ExpirySec int64  `json:"expirySec"`
+}
+
+// Renditions are copies of the uploaded images in the formats listed in Formats, a client that accepts one of them
+// is served the rendition instead of the original, preferring the formats in the listed order. The encoders are
+// also used by the renders of these formats.
+type Renditions struct {
+	Formats []string `json:"formats"`
+	WebP    Encoder  `json:"webp"`
+	AVIF    Encoder  `json:"avif"`
+}
+
+// Encoder is the command line tool that encodes a format, Quality from 0 to 100 is the quality of the renditions
+type Encoder struct {
+	Path    string `json:"path"`
+	Quality int    `json:"quality"`
+}
+
+// Render configures the transformed photos of GET /photos/:photo_id/render. Only the parameters of a preset can be
+// requested, through the URLs the API returns along with the photos, signed with Secret and sent to BaseURL.
+type Render struct {
+	BaseURL string                  `json:"baseURL"`
+	Secret  string                  `json:"secret"`
+	Presets map[string]RenderPreset `json:"presets"`
+}
+
+// RenderPreset is an allowed transformation, Fit is cover or contain, Format is jpeg, png, webp or avif and
+// Quality goes from 0 to 100
+type RenderPreset struct {
+	Width   int    `json:"width"`
+	Height  int    `json:"height"`
+	Fit     string `json:"fit"`
+	Format  string `json:"format"`
+	Quality int    `json:"quality"`
+}
+
+type LocalStorage struct {
+	Dir     string `json:"dir"`
+	BaseURL string `json:"baseURL"`
+	Secret  string `json:"secret"`
+}
+
+// Moderation configures the default moderator of the uploaded photos and of their titles and captions. A text with
+// a rejected word is refused and one with a flagged word is hidden until a moderator reviews it. The images smaller
+// than MinWidth or MinHeight are flagged, the ones within MaxHashDistance of a blocked perceptual hash, written in
+// hexadecimal, are rejected. A photo or a user is hidden until a moderator resolves their reports once
+// ReportHideThreshold distinct users have reported them, they are never hidden by the reports when it is zero.
+type Moderation struct {
+	RejectWords     []string `json:"rejectWords"`
+	FlagWords       []string `json:"flagWords"`
+	MinWidth        int      `json:"minWidth"`
+	MinHeight       int      `json:"minHeight"`
+	BlockedHashes   []string `json:"blockedHashes"`
+	MaxHashDistance int      `json:"maxHashDistance"`
+
+	ReportHideThreshold int `json:"reportHideThreshold"`
 }
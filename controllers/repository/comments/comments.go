@@ -0,0 +1,145 @@
+package comments
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"rakamin-final-task/controllers/repository/querybuilder"
+	"rakamin-final-task/database"
+	"rakamin-final-task/helpers/errors"
+	"rakamin-final-task/helpers/response"
+	"rakamin-final-task/models"
+)
+
+type Interface interface {
+	Create(ctx context.Context, comment models.Comments) (models.Comments, error)
+	Get(ctx context.Context, params models.CommentParams) (models.Comments, error)
+	GetList(ctx context.Context, params models.CommentParams) ([]models.Comments, *response.PaginationParam, error)
+	Update(ctx context.Context, comment models.Comments, params models.CommentParams) (models.Comments, error)
+	Delete(ctx context.Context, comment models.Comments) error
+}
+
+var listQuery = querybuilder.Builder{
+	Table: "comments",
+	SortColumns: map[string]string{
+		"created": "created_at",
+		"updated": "updated_at",
+	},
+	DefaultSort: "created:asc",
+}
+
+type comments struct {
+	db *database.DB
+}
+
+func Init(db *database.DB) Interface {
+	return &comments{
+		db: db,
+	}
+}
+
+// Create stores the comment and increases the photo comment count in a single transaction
+func (c *comments) Create(ctx context.Context, comment models.Comments) (models.Comments, error) {
+	err := c.db.ORM.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&comment).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(models.Photos{}).
+			Where("id = ?", comment.PhotoID).
+			UpdateColumn("comment_count", gorm.Expr("comment_count + 1")).Error
+	})
+	if err != nil {
+		return comment, err
+	}
+
+	return comment, nil
+}
+
+func (c *comments) Get(ctx context.Context, params models.CommentParams) (models.Comments, error) {
+	var comment models.Comments
+
+	res := c.db.ORM.WithContext(ctx).Where(params).First(&comment)
+	if res.RowsAffected == 0 {
+		return comment, errors.NotFound("Comment not found")
+	} else if res.Error != nil {
+		return comment, res.Error
+	}
+
+	return comment, nil
+}
+
+func (c *comments) GetList(ctx context.Context, params models.CommentParams) ([]models.Comments, *response.PaginationParam, error) {
+	var comments []models.Comments
+
+	pg := params.PaginationParam
+	pg.SetDefaultPagination()
+
+	query := c.db.ORM.WithContext(ctx).
+		Model(&models.Comments{}).
+		Select("comments.*, (?) AS reply_count", c.replyCount()).
+		Where(params)
+
+	if params.ParentID != 0 {
+		query = query.Where("comments.parent_id = ?", params.ParentID)
+	} else {
+		query = query.Where("comments.parent_id IS NULL")
+	}
+
+	query, err := listQuery.Filter(query, params.ListFilter)
+	if err != nil {
+		return comments, &pg, err
+	}
+
+	if err := listQuery.List(query, &comments, params.Sort, "", &pg); err != nil {
+		return comments, &pg, err
+	}
+
+	return comments, &pg, nil
+}
+
+func (c *comments) Update(ctx context.Context, comment models.Comments, params models.CommentParams) (models.Comments, error) {
+	res := c.db.ORM.WithContext(ctx).Model(models.Comments{}).Where(params).Updates(&comment)
+	if res.RowsAffected == 0 {
+		return comment, errors.NotFound("Comment not found")
+	} else if res.Error != nil {
+		return comment, res.Error
+	}
+
+	return comment, nil
+}
+
+// deleteThread soft deletes the comment along with its replies at any depth, the replies deleted beforehand are
+// left as they are
+const deleteThread = `
+	WITH RECURSIVE thread AS (
+		SELECT id FROM comments WHERE id = ? AND deleted_at IS NULL
+		UNION ALL
+		SELECT replies.id FROM comments AS replies JOIN thread ON replies.parent_id = thread.id
+		WHERE replies.deleted_at IS NULL
+	)
+	UPDATE comments SET deleted_at = NOW(), deleted_by = ? WHERE id IN (SELECT id FROM thread)`
+
+// Delete soft deletes the comment with its replies and decreases the photo comment count by all of them in a single
+// transaction
+func (c *comments) Delete(ctx context.Context, comment models.Comments) error {
+	return c.db.ORM.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Exec(deleteThread, comment.ID, comment.DeletedBy)
+		if res.Error != nil {
+			return res.Error
+		} else if res.RowsAffected == 0 {
+			return errors.NotFound("Comment not found")
+		}
+
+		return tx.Model(models.Photos{}).
+			Where("id = ? AND comment_count > 0", comment.PhotoID).
+			UpdateColumn("comment_count", gorm.Expr("GREATEST(comment_count - ?, 0)", res.RowsAffected)).Error
+	})
+}
+
+func (c *comments) replyCount() *gorm.DB {
+	return c.db.ORM.Model(models.Comments{}).
+		Select("COUNT(*)").
+		Where("replies.parent_id = comments.id").
+		Table("comments AS replies")
+}
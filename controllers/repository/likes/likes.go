@@ -0,0 +1,53 @@
+package likes
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"rakamin-final-task/database"
+	"rakamin-final-task/models"
+)
+
+type Interface interface {
+	Create(ctx context.Context, like models.PhotoLikes) error
+	Delete(ctx context.Context, params models.PhotoLikeParams) error
+}
+
+type likes struct {
+	db *database.DB
+}
+
+func Init(db *database.DB) Interface {
+	return &likes{
+		db: db,
+	}
+}
+
+// Create likes the photo and increases its like count, liking the same photo twice is a no-op
+func (l *likes) Create(ctx context.Context, like models.PhotoLikes) error {
+	return l.db.ORM.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&like)
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+
+		return tx.Model(models.Photos{}).
+			Where("id = ?", like.PhotoID).
+			UpdateColumn("like_count", gorm.Expr("like_count + 1")).Error
+	})
+}
+
+// Delete removes the like and decreases the photo like count, removing a missing like is a no-op
+func (l *likes) Delete(ctx context.Context, params models.PhotoLikeParams) error {
+	return l.db.ORM.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Where(params).Delete(&models.PhotoLikes{})
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+
+		return tx.Model(models.Photos{}).
+			Where("id = ? AND like_count > 0", params.PhotoID).
+			UpdateColumn("like_count", gorm.Expr("like_count - 1")).Error
+	})
+}
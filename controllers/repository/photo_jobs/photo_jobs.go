@@ -0,0 +1,137 @@
+package photo_jobs
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	photoModerationRepo "rakamin-final-task/controllers/repository/photo_moderations"
+	"rakamin-final-task/database"
+	"rakamin-final-task/models"
+)
+
+type Interface interface {
+	Claim(ctx context.Context, limit int, lease time.Duration) ([]models.PhotoJobs, error)
+	Finish(ctx context.Context, job models.PhotoJobs) error
+	GetList(ctx context.Context, photoID int64) ([]models.PhotoJobs, error)
+}
+
+type photoJobs struct {
+	db *database.DB
+}
+
+func Init(db *database.DB) Interface {
+	return &photoJobs{
+		db: db,
+	}
+}
+
+// Claim takes the due pending jobs for an attempt. A claimed job is due again once the lease is over, so the job of
+// a worker that stopped in the middle is retried. Jobs claimed by another worker are skipped.
+func (p *photoJobs) Claim(ctx context.Context, limit int, lease time.Duration) ([]models.PhotoJobs, error) {
+	var jobs []models.PhotoJobs
+
+	now := time.Now()
+	due := p.db.ORM.WithContext(ctx).Model(&models.PhotoJobs{}).Select("id").
+		Where("status = ? AND run_at <= ?", models.PhotoJobStatusPending, now.Unix()).
+		Order("run_at ASC").Limit(limit).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})
+
+	res := p.db.ORM.WithContext(ctx).Model(&jobs).Clauses(clause.Returning{}).
+		Where("id IN (?)", due).
+		Updates(map[string]interface{}{
+			"attempts":   gorm.Expr("attempts + 1"),
+			"revision":   gorm.Expr("revision + 1"),
+			"run_at":     now.Add(lease).Unix(),
+			"updated_at": now.Unix(),
+		})
+	if res.Error != nil {
+		return jobs, res.Error
+	}
+
+	return jobs, nil
+}
+
+// Finish stores the outcome of the attempt unless the job has been claimed or enqueued again since, the processing
+// status of the photo follows its jobs in the same transaction
+func (p *photoJobs) Finish(ctx context.Context, job models.PhotoJobs) error {
+	return p.db.ORM.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.PhotoJobs{}).
+			Where("id = ? AND revision = ?", job.ID, job.Revision).
+			Updates(map[string]interface{}{
+				"status":     job.Status,
+				"run_at":     job.RunAt,
+				"error":      job.Error,
+				"updated_at": time.Now().Unix(),
+			})
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+
+		return updateStatus(tx, job.PhotoID)
+	})
+}
+
+func (p *photoJobs) GetList(ctx context.Context, photoID int64) ([]models.PhotoJobs, error) {
+	var jobs []models.PhotoJobs
+
+	res := p.db.ORM.WithContext(ctx).Where("photo_id = ?", photoID).Order("id ASC").Find(&jobs)
+	if res.Error != nil {
+		return jobs, res.Error
+	}
+
+	return jobs, nil
+}
+
+// Enqueue adds the jobs of the given kinds for a new photo file within the given transaction, so they are enqueued
+// along with the file. The jobs of the previous file of the photo start over and the photo is pending again.
+func Enqueue(tx *gorm.DB, photoID int64, kinds []string) error {
+	now := time.Now().Unix()
+
+	jobs := make([]models.PhotoJobs, 0, len(kinds))
+	for _, kind := range kinds {
+		jobs = append(jobs, models.PhotoJobs{
+			PhotoID: photoID,
+			Kind:    kind,
+			Status:  models.PhotoJobStatusPending,
+			RunAt:   now,
+		})
+	}
+
+	err := tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "photo_id"}, {Name: "kind"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"status":     models.PhotoJobStatusPending,
+			"run_at":     now,
+			"attempts":   0,
+			"error":      "",
+			"revision":   gorm.Expr("photo_jobs.revision + 1"),
+			"updated_at": now,
+		}),
+	}).Create(&jobs).Error
+	if err != nil {
+		return err
+	}
+
+	return updateStatus(tx, photoID)
+}
+
+// updateStatus sets the processing status of the photo from its jobs, a failed job fails the photo. The photo is
+// hidden from the other users until its image is moderated, so the moderation status follows the jobs as well.
+func updateStatus(tx *gorm.DB, photoID int64) error {
+	status := gorm.Expr(`CASE
+		WHEN EXISTS (SELECT 1 FROM photo_jobs WHERE photo_id = photos.id AND status = ?) THEN ?
+		WHEN EXISTS (SELECT 1 FROM photo_jobs WHERE photo_id = photos.id AND status = ?) THEN ?
+		ELSE ? END`,
+		models.PhotoJobStatusFailed, models.PhotoStatusFailed,
+		models.PhotoJobStatusPending, models.PhotoStatusPending,
+		models.PhotoStatusReady)
+
+	err := tx.Unscoped().Model(&models.Photos{}).Where("id = ?", photoID).UpdateColumn("processing_status", status).Error
+	if err != nil {
+		return err
+	}
+
+	return photoModerationRepo.UpdateStatus(tx, photoID)
+}
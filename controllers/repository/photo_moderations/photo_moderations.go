@@ -0,0 +1,125 @@
+package photo_moderations
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"rakamin-final-task/controllers/repository/querybuilder"
+	"rakamin-final-task/database"
+	"rakamin-final-task/helpers/errors"
+	"rakamin-final-task/helpers/response"
+	"rakamin-final-task/models"
+)
+
+type Interface interface {
+	Create(ctx context.Context, moderation models.PhotoModerations) error
+	Get(ctx context.Context, params models.ModerationParams) (models.PhotoModerations, error)
+	GetList(ctx context.Context, params models.ModerationParams) ([]models.PhotoModerations, *response.PaginationParam, error)
+	Review(ctx context.Context, moderation models.PhotoModerations) error
+}
+
+// listQuery lists the oldest flags first, so the queue is reviewed in order
+var listQuery = querybuilder.Builder{
+	Table: "photo_moderations",
+	SortColumns: map[string]string{
+		"created": "created_at",
+		"updated": "updated_at",
+	},
+	DefaultSort: "created:asc",
+}
+
+type photoModerations struct {
+	db *database.DB
+}
+
+func Init(db *database.DB) Interface {
+	return &photoModerations{
+		db: db,
+	}
+}
+
+// Create raises the flag and hides the photo in a single transaction
+func (p *photoModerations) Create(ctx context.Context, moderation models.PhotoModerations) error {
+	return p.db.ORM.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&moderation).Error; err != nil {
+			return err
+		}
+
+		return UpdateStatus(tx, moderation.PhotoID)
+	})
+}
+
+func (p *photoModerations) Get(ctx context.Context, params models.ModerationParams) (models.PhotoModerations, error) {
+	var moderation models.PhotoModerations
+
+	res := p.db.ORM.WithContext(ctx).Where(params).Preload("Photo").First(&moderation)
+	if res.RowsAffected == 0 {
+		return moderation, errors.NotFound("Moderation not found")
+	} else if res.Error != nil {
+		return moderation, res.Error
+	}
+
+	return moderation, nil
+}
+
+func (p *photoModerations) GetList(ctx context.Context, params models.ModerationParams) ([]models.PhotoModerations, *response.PaginationParam, error) {
+	var moderations []models.PhotoModerations
+
+	pg := params.PaginationParam
+	pg.SetDefaultPagination()
+
+	query := p.db.ORM.WithContext(ctx).Model(&models.PhotoModerations{}).Where(params).Preload("Photo")
+
+	query, err := listQuery.Filter(query, params.ListFilter)
+	if err != nil {
+		return moderations, &pg, err
+	}
+
+	if err := listQuery.List(query, &moderations, params.Sort, "", &pg); err != nil {
+		return moderations, &pg, err
+	}
+
+	return moderations, &pg, nil
+}
+
+// Review records the decision of a moderator on a pending flag and updates the photo in a single transaction,
+// a flag can only be reviewed once
+func (p *photoModerations) Review(ctx context.Context, moderation models.PhotoModerations) error {
+	return p.db.ORM.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.PhotoModerations{}).
+			Where("id = ? AND status = ?", moderation.ID, models.ModerationStatusPending).
+			Updates(map[string]interface{}{
+				"status":      moderation.Status,
+				"reviewed_by": moderation.ReviewedBy,
+				"reviewed_at": time.Now().Unix(),
+				"note":        moderation.Note,
+			})
+		if res.Error != nil {
+			return res.Error
+		} else if res.RowsAffected == 0 {
+			return errors.Conflict("Moderation has already been reviewed")
+		}
+
+		return UpdateStatus(tx, moderation.PhotoID)
+	})
+}
+
+// UpdateStatus sets the moderation status of the photo from its flags within the given transaction. A rejected flag
+// rejects the photo for good, a pending one keeps it flagged and the photo is approved once all of its flags are.
+// The photo is pending until its file is stored and its moderate job is done, even when a job has failed for good.
+func UpdateStatus(tx *gorm.DB, photoID int64) error {
+	status := gorm.Expr(`CASE
+		WHEN EXISTS (SELECT 1 FROM photo_moderations WHERE photo_id = photos.id AND status = ?) THEN ?
+		WHEN EXISTS (SELECT 1 FROM photo_moderations WHERE photo_id = photos.id AND status = ?) THEN ?
+		WHEN EXISTS (SELECT 1 FROM photo_jobs WHERE photo_id = photos.id AND kind IN ? AND status <> ?) THEN ?
+		WHEN EXISTS (SELECT 1 FROM photo_moderations WHERE photo_id = photos.id) THEN ?
+		ELSE ? END`,
+		models.ModerationStatusRejected, models.PhotoModerationRejected,
+		models.ModerationStatusPending, models.PhotoModerationFlagged,
+		[]string{models.PhotoJobStore, models.PhotoJobModerate}, models.PhotoJobStatusDone, models.PhotoModerationPending,
+		models.PhotoModerationApproved,
+		models.PhotoModerationAllowed)
+
+	return tx.Unscoped().Model(&models.Photos{}).Where("id = ?", photoID).UpdateColumn("moderation_status", status).Error
+}
@@ -0,0 +1,105 @@
+package photo_objects
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"rakamin-final-task/database"
+	"rakamin-final-task/helpers/files"
+	"rakamin-final-task/models"
+)
+
+type Interface interface {
+	Acquire(ctx context.Context, object models.PhotoObjects) error
+	Release(ctx context.Context, photoURLs ...string) error
+	GetUnreferenced(ctx context.Context, limit int) ([]models.PhotoObjects, error)
+	Purge(ctx context.Context, fileName string, deleteFile func() error) error
+}
+
+type photoObjects struct {
+	db *database.DB
+}
+
+func Init(db *database.DB) Interface {
+	return &photoObjects{
+		db: db,
+	}
+}
+
+// Acquire adds a reference to the file, the object is created with a single reference when it is new
+func (p *photoObjects) Acquire(ctx context.Context, object models.PhotoObjects) error {
+	object.RefCount = 1
+
+	return p.db.ORM.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "file_name"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"ref_count":  gorm.Expr("photo_objects.ref_count + 1"),
+			"updated_at": time.Now().Unix(),
+		}),
+	}).Create(&object).Error
+}
+
+func (p *photoObjects) Release(ctx context.Context, photoURLs ...string) error {
+	return Release(p.db.ORM.WithContext(ctx), photoURLs...)
+}
+
+// GetUnreferenced returns the objects that have been released for the longest time without being referred again
+func (p *photoObjects) GetUnreferenced(ctx context.Context, limit int) ([]models.PhotoObjects, error) {
+	var objects []models.PhotoObjects
+
+	res := p.db.ORM.WithContext(ctx).Where("ref_count = 0").Order("updated_at ASC").Limit(limit).Find(&objects)
+	if res.Error != nil {
+		return objects, res.Error
+	}
+
+	return objects, nil
+}
+
+// Purge deletes the file and its object while it is still unreferenced. The object is locked while the file is
+// deleted, so a concurrent upload of the same content waits and uploads the file again once it is gone.
+func (p *photoObjects) Purge(ctx context.Context, fileName string, deleteFile func() error) error {
+	return p.db.ORM.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var object models.PhotoObjects
+
+		res := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("file_name = ? AND ref_count = 0", fileName).Limit(1).Find(&object)
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+
+		if err := deleteFile(); err != nil {
+			return err
+		}
+
+		return tx.Where("file_name = ?", fileName).Delete(&models.PhotoObjects{}).Error
+	})
+}
+
+// Release removes a reference to the file of every URL within the given transaction, so the references are released
+// along with the rows that held them. A file stored before the objects were counted had a single reference,
+// it is recorded as unreferenced so it gets purged like any other file.
+func Release(tx *gorm.DB, photoURLs ...string) error {
+	counts := map[string]int64{}
+	for _, photoURL := range photoURLs {
+		counts[files.GetFileNameFromURL(photoURL)]++
+	}
+
+	now := time.Now().Unix()
+	for fileName, count := range counts {
+		object := models.PhotoObjects{FileName: fileName}
+
+		err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "file_name"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"ref_count":  gorm.Expr("GREATEST(photo_objects.ref_count - ?, 0)", count),
+				"updated_at": now,
+			}),
+		}).Create(&object).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
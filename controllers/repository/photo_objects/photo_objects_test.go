@@ -0,0 +1,161 @@
+package photo_objects
+
+import (
+	"context"
+	goerr "errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"rakamin-final-task/database"
+)
+
+const (
+	releaseQuery = `INSERT INTO "photo_objects" ("file_name","created_at","updated_at","hash","size","ref_count") VALUES ($1,$2,$3,$4,$5,$6) ON CONFLICT ("file_name") DO UPDATE SET "ref_count"=GREATEST(photo_objects.ref_count - $7, 0),"updated_at"=$8`
+	lockQuery    = `SELECT * FROM "photo_objects" WHERE file_name = $1 AND ref_count = 0 LIMIT $2 FOR UPDATE`
+	deleteQuery  = `DELETE FROM "photo_objects" WHERE file_name = $1`
+)
+
+// mockDB runs the repository against a mocked connection, so the statements of a transaction are checked
+// without a database
+func mockDB(t *testing.T) (*database.DB, sqlmock.Sqlmock) {
+	t.Helper()
+
+	conn, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	orm, err := gorm.Open(postgres.New(postgres.Config{Conn: conn}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &database.DB{ORM: orm}, mock
+}
+
+func TestRelease(t *testing.T) {
+	tests := []struct {
+		name      string
+		photoURLs []string
+		want      map[string]int64
+	}{
+		{
+			name: "no file",
+			want: map[string]int64{},
+		},
+		{
+			name:      "one reference",
+			photoURLs: []string{"https://storage.example.com/photos/a.jpg"},
+			want:      map[string]int64{"a.jpg": 1},
+		},
+		{
+			name: "references of the same file are released at once",
+			photoURLs: []string{
+				"https://storage.example.com/photos/a.jpg",
+				"https://storage.example.com/photos/b.png",
+				"https://storage.example.com/photos/a.jpg",
+			},
+			want: map[string]int64{"a.jpg": 2, "b.png": 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := mockDB(t)
+			mock.MatchExpectationsInOrder(false)
+
+			mock.ExpectBegin()
+			for fileName, count := range tt.want {
+				mock.ExpectExec(releaseQuery).
+					WithArgs(fileName, sqlmock.AnyArg(), sqlmock.AnyArg(), "", 0, 0, count, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+			mock.ExpectCommit()
+
+			err := db.ORM.Transaction(func(tx *gorm.DB) error {
+				return Release(tx, tt.photoURLs...)
+			})
+			if err != nil {
+				t.Fatalf("Release() error = %v", err)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestPurge(t *testing.T) {
+	errDelete := goerr.New("storage unavailable")
+
+	tests := []struct {
+		name         string
+		unreferenced bool
+		deleteErr    error
+		wantDeleted  bool
+		wantErr      error
+	}{
+		{
+			name:         "unreferenced file",
+			unreferenced: true,
+			wantDeleted:  true,
+		},
+		{
+			name:         "file referenced again",
+			unreferenced: false,
+		},
+		{
+			name:         "file that can not be deleted is kept",
+			unreferenced: true,
+			deleteErr:    errDelete,
+			wantDeleted:  true,
+			wantErr:      errDelete,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := mockDB(t)
+
+			rows := sqlmock.NewRows([]string{"file_name", "ref_count"})
+			if tt.unreferenced {
+				rows.AddRow("a.jpg", 0)
+			}
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(lockQuery).WithArgs("a.jpg", 1).WillReturnRows(rows)
+			if tt.unreferenced && tt.deleteErr == nil {
+				mock.ExpectExec(deleteQuery).WithArgs("a.jpg").WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+			if tt.deleteErr != nil {
+				mock.ExpectRollback()
+			} else {
+				mock.ExpectCommit()
+			}
+
+			deleted := false
+			deleteFile := func() error {
+				deleted = true
+				return tt.deleteErr
+			}
+
+			err := Init(db).Purge(context.Background(), "a.jpg", deleteFile)
+			if !goerr.Is(err, tt.wantErr) {
+				t.Errorf("Purge() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if deleted != tt.wantDeleted {
+				t.Errorf("Purge() deleted the file = %v, want %v", deleted, tt.wantDeleted)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
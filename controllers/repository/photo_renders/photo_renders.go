@@ -0,0 +1,64 @@
+package photo_renders
+
+import (
+	"context"
+
+	"gorm.io/gorm/clause"
+	"rakamin-final-task/database"
+	"rakamin-final-task/helpers/errors"
+	"rakamin-final-task/models"
+)
+
+type Interface interface {
+	Create(ctx context.Context, render models.PhotoRenders) error
+	Get(ctx context.Context, fileName string) (models.PhotoRenders, error)
+	GetList(ctx context.Context, sourceName string, sourcePath string) ([]models.PhotoRenders, error)
+	Delete(ctx context.Context, fileNames []string) error
+}
+
+type photoRenders struct {
+	db *database.DB
+}
+
+func Init(db *database.DB) Interface {
+	return &photoRenders{
+		db: db,
+	}
+}
+
+// Create keeps the existing render when the same render has been created by a concurrent request
+func (p *photoRenders) Create(ctx context.Context, render models.PhotoRenders) error {
+	return p.db.ORM.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&render).Error
+}
+
+func (p *photoRenders) Get(ctx context.Context, fileName string) (models.PhotoRenders, error) {
+	var render models.PhotoRenders
+
+	res := p.db.ORM.WithContext(ctx).Where("file_name = ?", fileName).Limit(1).Find(&render)
+	if res.Error != nil {
+		return render, res.Error
+	} else if res.RowsAffected == 0 {
+		return render, errors.NotFound("Render not found")
+	}
+
+	return render, nil
+}
+
+func (p *photoRenders) GetList(ctx context.Context, sourceName string, sourcePath string) ([]models.PhotoRenders, error) {
+	var renders []models.PhotoRenders
+
+	res := p.db.ORM.WithContext(ctx).Where("source_name = ? AND source_path = ?", sourceName, sourcePath).Find(&renders)
+	if res.Error != nil {
+		return renders, res.Error
+	}
+
+	return renders, nil
+}
+
+func (p *photoRenders) Delete(ctx context.Context, fileNames []string) error {
+	if len(fileNames) == 0 {
+		return nil
+	}
+
+	return p.db.ORM.WithContext(ctx).Where("file_name IN ?", fileNames).Delete(&models.PhotoRenders{}).Error
+}
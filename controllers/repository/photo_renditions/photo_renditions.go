@@ -0,0 +1,40 @@
+package photo_renditions
+
+import (
+	"context"
+
+	"gorm.io/gorm/clause"
+	"rakamin-final-task/database"
+	"rakamin-final-task/models"
+)
+
+type Interface interface {
+	Create(ctx context.Context, rendition models.PhotoRenditions) error
+	GetList(ctx context.Context, objectName string) ([]models.PhotoRenditions, error)
+}
+
+type photoRenditions struct {
+	db *database.DB
+}
+
+func Init(db *database.DB) Interface {
+	return &photoRenditions{
+		db: db,
+	}
+}
+
+// Create keeps the existing rendition when the format of the file has already been rendered
+func (p *photoRenditions) Create(ctx context.Context, rendition models.PhotoRenditions) error {
+	return p.db.ORM.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&rendition).Error
+}
+
+func (p *photoRenditions) GetList(ctx context.Context, objectName string) ([]models.PhotoRenditions, error) {
+	var renditions []models.PhotoRenditions
+
+	res := p.db.ORM.WithContext(ctx).Where("object_name = ?", objectName).Find(&renditions)
+	if res.Error != nil {
+		return renditions, res.Error
+	}
+
+	return renditions, nil
+}
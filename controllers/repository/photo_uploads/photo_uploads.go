@@ -0,0 +1,97 @@
+package photo_uploads
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	photoJobRepo "rakamin-final-task/controllers/repository/photo_jobs"
+	photoRepo "rakamin-final-task/controllers/repository/photos"
+	"rakamin-final-task/database"
+	"rakamin-final-task/helpers/errors"
+	"rakamin-final-task/models"
+)
+
+type Interface interface {
+	Create(ctx context.Context, photoUpload models.PhotoUploads) (models.PhotoUploads, error)
+	Get(ctx context.Context, params models.PhotoUploadParams) (models.PhotoUploads, error)
+	Complete(ctx context.Context, params models.PhotoUploadParams, photo *models.Photos) error
+	GetExpired(ctx context.Context, now int64, limit int) ([]models.PhotoUploads, error)
+	Delete(ctx context.Context, ids []int64) error
+}
+
+type photoUploads struct {
+	db *database.DB
+}
+
+func Init(db *database.DB) Interface {
+	return &photoUploads{
+		db: db,
+	}
+}
+
+func (p *photoUploads) Create(ctx context.Context, photoUpload models.PhotoUploads) (models.PhotoUploads, error) {
+	if err := p.db.ORM.WithContext(ctx).Create(&photoUpload).Error; err != nil {
+		return photoUpload, err
+	}
+
+	return photoUpload, nil
+}
+
+func (p *photoUploads) Get(ctx context.Context, params models.PhotoUploadParams) (models.PhotoUploads, error) {
+	var photoUpload models.PhotoUploads
+
+	res := p.db.ORM.WithContext(ctx).Where(params).First(&photoUpload)
+	if res.RowsAffected == 0 {
+		return photoUpload, errors.NotFound("Photo upload not found")
+	} else if res.Error != nil {
+		return photoUpload, res.Error
+	}
+
+	return photoUpload, nil
+}
+
+// Complete creates the photo of a pending upload along with the job storing its file in a single transaction, an
+// upload can only be completed once
+func (p *photoUploads) Complete(ctx context.Context, params models.PhotoUploadParams, photo *models.Photos) error {
+	return p.db.ORM.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := photoRepo.Insert(tx, photo); err != nil {
+			return err
+		}
+
+		res := tx.Model(&models.PhotoUploads{}).
+			Where("id = ? AND user_id = ? AND status = ? AND expires_at > ?", params.ID, params.UserID, models.PhotoUploadStatusPending, time.Now().Unix()).
+			Updates(models.PhotoUploads{Status: models.PhotoUploadStatusCompleted, PhotoID: &photo.ID, UpdatedBy: &params.UserID})
+		if res.Error != nil {
+			return res.Error
+		} else if res.RowsAffected == 0 {
+			return errors.Conflict("Photo upload has already been completed or has expired")
+		}
+
+		return photoJobRepo.Enqueue(tx, photo.ID, []string{models.PhotoJobStore})
+	})
+}
+
+// GetExpired returns the oldest pending uploads that can not be completed anymore
+func (p *photoUploads) GetExpired(ctx context.Context, now int64, limit int) ([]models.PhotoUploads, error) {
+	var photoUploads []models.PhotoUploads
+
+	res := p.db.ORM.WithContext(ctx).
+		Where("status = ? AND expires_at <= ?", models.PhotoUploadStatusPending, now).
+		Order("expires_at ASC").
+		Limit(limit).
+		Find(&photoUploads)
+	if res.Error != nil {
+		return photoUploads, res.Error
+	}
+
+	return photoUploads, nil
+}
+
+func (p *photoUploads) Delete(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	return p.db.ORM.WithContext(ctx).Where("id IN ?", ids).Delete(&models.PhotoUploads{}).Error
+}
@@ -0,0 +1,88 @@
+package photo_versions
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	photoObjectRepo "rakamin-final-task/controllers/repository/photo_objects"
+	"rakamin-final-task/database"
+	"rakamin-final-task/helpers/errors"
+	"rakamin-final-task/models"
+)
+
+type Interface interface {
+	Get(ctx context.Context, params models.PhotoVersionParams) (models.PhotoVersions, error)
+	GetList(ctx context.Context, params models.PhotoVersionParams) ([]models.PhotoVersions, error)
+	GetExpired(ctx context.Context, now int64, limit int) ([]models.PhotoVersions, error)
+	Delete(ctx context.Context, ids []int64) error
+}
+
+type photoVersions struct {
+	db *database.DB
+}
+
+func Init(db *database.DB) Interface {
+	return &photoVersions{
+		db: db,
+	}
+}
+
+func (p *photoVersions) Get(ctx context.Context, params models.PhotoVersionParams) (models.PhotoVersions, error) {
+	var photoVersion models.PhotoVersions
+
+	res := p.db.ORM.WithContext(ctx).Where(params).First(&photoVersion)
+	if res.RowsAffected == 0 {
+		return photoVersion, errors.NotFound("Photo version not found")
+	} else if res.Error != nil {
+		return photoVersion, res.Error
+	}
+
+	return photoVersion, nil
+}
+
+func (p *photoVersions) GetList(ctx context.Context, params models.PhotoVersionParams) ([]models.PhotoVersions, error) {
+	var photoVersions []models.PhotoVersions
+
+	res := p.db.ORM.WithContext(ctx).Where(params).Order("created_at DESC, id DESC").Find(&photoVersions)
+	if res.Error != nil {
+		return photoVersions, res.Error
+	}
+
+	return photoVersions, nil
+}
+
+// GetExpired returns the oldest versions that are past their retention, including the ones of deleted photos
+func (p *photoVersions) GetExpired(ctx context.Context, now int64, limit int) ([]models.PhotoVersions, error) {
+	var photoVersions []models.PhotoVersions
+
+	res := p.db.ORM.WithContext(ctx).Where("expires_at <= ?", now).Order("expires_at ASC").Limit(limit).Find(&photoVersions)
+	if res.Error != nil {
+		return photoVersions, res.Error
+	}
+
+	return photoVersions, nil
+}
+
+// Delete deletes the versions and releases their files in a single transaction
+func (p *photoVersions) Delete(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	return p.db.ORM.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var photoURLs []string
+
+		err := tx.Model(&models.PhotoVersions{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ?", ids).Pluck("photo_url", &photoURLs).Error
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Where("id IN ?", ids).Delete(&models.PhotoVersions{}).Error; err != nil {
+			return err
+		}
+
+		return photoObjectRepo.Release(tx, photoURLs...)
+	})
+}
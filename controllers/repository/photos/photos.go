@@ -2,7 +2,17 @@ package photos
 
 import (
 	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
 
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	photoJobRepo "rakamin-final-task/controllers/repository/photo_jobs"
+	photoObjectRepo "rakamin-final-task/controllers/repository/photo_objects"
+	"rakamin-final-task/controllers/repository/querybuilder"
+	tagRepo "rakamin-final-task/controllers/repository/tags"
 	"rakamin-final-task/database"
 	"rakamin-final-task/helpers/errors"
 	"rakamin-final-task/helpers/response"
@@ -13,8 +23,59 @@ type Interface interface {
 	Create(ctx context.Context, photo models.Photos) (models.Photos, error)
 	Get(ctx context.Context, params models.PhotoParams) (models.Photos, error)
 	GetList(ctx context.Context, params models.PhotoParams) ([]models.Photos, *response.PaginationParam, error)
-	Update(ctx context.Context, photo models.Photos, params models.PhotoParams) (models.Photos, error)
-	Delete(ctx context.Context, params models.PhotoParams) error
+	GetListByIDs(ctx context.Context, userID int64, ids []int64) ([]models.Photos, error)
+	Update(ctx context.Context, photo models.Photos, params models.PhotoParams, tagNames []string) (models.Photos, error)
+	Delete(ctx context.Context, params models.PhotoParams, deletedBy int64) error
+	GetListTrash(ctx context.Context, params models.PhotoParams) ([]models.Photos, *response.PaginationParam, error)
+	GetExpiredTrash(ctx context.Context, before time.Time, limit int) ([]models.Photos, error)
+	Restore(ctx context.Context, params models.PhotoParams) error
+	Purge(ctx context.Context, ids []int64) error
+	Bulk(ctx context.Context, userID int64, params models.BulkPhotoParams) ([]int64, error)
+	SwitchFile(ctx context.Context, params models.SwitchPhotoFileParams) error
+	GetListSimilar(ctx context.Context, photo models.Photos, maxDistance int, limit int) ([]models.Photos, error)
+	GetListMissing(ctx context.Context, column string, afterID int64, limit int) ([]models.Photos, error)
+	SetImage(ctx context.Context, photo models.Photos, image models.PhotoImage) error
+}
+
+const (
+	// maxBandRadius is the widest band distance the similar photos are searched through the band indexes with,
+	// a wider one lists thousands of band values and the photos of the owner are scanned instead
+	maxBandRadius = 3
+
+	titleHighlightOptions   = "StartSel=<mark>, StopSel=</mark>, HighlightAll=true"
+	captionHighlightOptions = "StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=20, MinWords=5"
+)
+
+// htmlReplacements are the SQL literals of the HTML special characters and their entities, the ampersand comes first
+// so the entities are not escaped again
+var htmlReplacements = [][2]string{
+	{"&", "&amp;"},
+	{"<", "&lt;"},
+	{">", "&gt;"},
+	{`"`, "&quot;"},
+	{"''", "&#39;"},
+}
+
+var listQuery = querybuilder.Builder{
+	Table: "photos",
+	SortColumns: map[string]string{
+		"created": "created_at",
+		"updated": "updated_at",
+		"title":   "title",
+	},
+	DefaultSort: "created:desc",
+}
+
+// trashQuery lists the most recently trashed photos first
+var trashQuery = querybuilder.Builder{
+	Table: listQuery.Table,
+	SortColumns: map[string]string{
+		"created": "created_at",
+		"updated": "updated_at",
+		"deleted": "deleted_at",
+		"title":   "title",
+	},
+	DefaultSort: "deleted:desc",
 }
 
 type photos struct {
@@ -27,8 +88,20 @@ func Init(db *database.DB) Interface {
 	}
 }
 
+// Create adds the photo along with the jobs of its file when it is pending
 func (p *photos) Create(ctx context.Context, photo models.Photos) (models.Photos, error) {
-	if err := p.db.ORM.WithContext(ctx).Create(&photo).Error; err != nil {
+	err := p.db.ORM.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := Insert(tx, &photo); err != nil {
+			return err
+		}
+
+		if photo.ProcessingStatus != models.PhotoStatusPending {
+			return nil
+		}
+
+		return photoJobRepo.Enqueue(tx, photo.ID, models.PhotoJobKinds)
+	})
+	if err != nil {
 		return photo, err
 	}
 
@@ -38,7 +111,12 @@ func (p *photos) Create(ctx context.Context, photo models.Photos) (models.Photos
 func (p *photos) Get(ctx context.Context, params models.PhotoParams) (models.Photos, error) {
 	var photo models.Photos
 
-	res := p.db.ORM.WithContext(ctx).Where(params).First(&photo)
+	query, err := p.filter(ctx, params)
+	if err != nil {
+		return photo, err
+	}
+
+	res := query.Preload("Tags").First(&photo)
 	if res.RowsAffected == 0 {
 		return photo, errors.NotFound("Photo not found")
 	} else if res.Error != nil {
@@ -51,35 +129,71 @@ func (p *photos) Get(ctx context.Context, params models.PhotoParams) (models.Pho
 func (p *photos) GetList(ctx context.Context, params models.PhotoParams) ([]models.Photos, *response.PaginationParam, error) {
 	var photos []models.Photos
 
-	pg := response.PaginationParam{
-		Limit: params.Limit,
-		Page:  params.Page,
-	}
+	pg := params.PaginationParam
 	pg.SetDefaultPagination()
 
-	res := p.db.ORM.WithContext(ctx).Where(params).Offset(int(pg.Offset)).Limit(int(pg.Limit)).Find(&photos)
-	if res.Error != nil {
-		return photos, &pg, res.Error
+	query, err := p.filter(ctx, params)
+	if err != nil {
+		return photos, &pg, err
 	}
 
-	pg.ProcessPagination(res.RowsAffected)
+	// Search results are ranked first unless another order is requested
+	rankOrder := ""
+	if params.Keyword != "" && params.Sort == "" {
+		rankOrder = "search_rank DESC"
+	}
+
+	if err := listQuery.List(query.Preload("Tags"), &photos, params.Sort, rankOrder, &pg); err != nil {
+		return photos, &pg, err
+	}
 
 	return photos, &pg, nil
 }
 
-func (p *photos) Update(ctx context.Context, photo models.Photos, params models.PhotoParams) (models.Photos, error) {
-	res := p.db.ORM.WithContext(ctx).Model(models.Photos{}).Where(params).Updates(&photo)
-	if res.RowsAffected == 0 {
-		return photo, errors.NotFound("Photo not found")
-	} else if res.Error != nil {
-		return photo, res.Error
+func (p *photos) GetListByIDs(ctx context.Context, userID int64, ids []int64) ([]models.Photos, error) {
+	var photos []models.Photos
+
+	res := p.db.ORM.WithContext(ctx).Where("id IN ? AND user_id = ?", ids, userID).Order("id ASC").Find(&photos)
+	if res.Error != nil {
+		return photos, res.Error
+	}
+
+	return photos, nil
+}
+
+// Update updates the photo and replaces its tags by the given names in a single transaction. The tags the user does
+// not have yet are created along with the update, a nil list of names leaves the tags untouched.
+func (p *photos) Update(ctx context.Context, photo models.Photos, params models.PhotoParams, tagNames []string) (models.Photos, error) {
+	err := p.db.ORM.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(models.Photos{}).Where(params).Updates(&photo)
+		if res.Error != nil {
+			return res.Error
+		} else if res.RowsAffected == 0 {
+			return errors.NotFound("Photo not found")
+		}
+
+		if tagNames == nil {
+			return nil
+		}
+
+		tags, err := tagRepo.Upsert(tx, models.TagParams{UserID: params.UserID, Names: tagNames})
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&models.Photos{ID: params.ID}).Association("Tags").Replace(tags)
+	})
+	if err != nil {
+		return photo, err
 	}
 
 	return photo, nil
 }
 
-func (p *photos) Delete(ctx context.Context, params models.PhotoParams) error {
-	res := p.db.ORM.WithContext(ctx).Where(params).Delete(&models.Photos{})
+// Delete moves the photo to the trash, it is only removed for good by Purge
+func (p *photos) Delete(ctx context.Context, params models.PhotoParams, deletedBy int64) error {
+	res := p.db.ORM.WithContext(ctx).Model(&models.Photos{}).Where(params).
+		Updates(map[string]interface{}{"deleted_at": time.Now(), "deleted_by": deletedBy})
 	if res.RowsAffected == 0 {
 		return errors.NotFound("Photo not found")
 	} else if res.Error != nil {
@@ -88,3 +202,420 @@ func (p *photos) Delete(ctx context.Context, params models.PhotoParams) error {
 
 	return nil
 }
+
+func (p *photos) GetListTrash(ctx context.Context, params models.PhotoParams) ([]models.Photos, *response.PaginationParam, error) {
+	var photos []models.Photos
+
+	pg := params.PaginationParam
+	pg.SetDefaultPagination()
+
+	query, err := p.filter(ctx, params)
+	if err != nil {
+		return photos, &pg, err
+	}
+
+	query = query.Unscoped().Where("photos.deleted_at IS NOT NULL")
+
+	if err := trashQuery.List(query.Preload("Tags"), &photos, params.Sort, "", &pg); err != nil {
+		return photos, &pg, err
+	}
+
+	return photos, &pg, nil
+}
+
+// GetExpiredTrash returns the photos trashed before the given time, the oldest first
+func (p *photos) GetExpiredTrash(ctx context.Context, before time.Time, limit int) ([]models.Photos, error) {
+	var photos []models.Photos
+
+	res := p.db.ORM.WithContext(ctx).Unscoped().Where("deleted_at <= ?", before).Order("deleted_at ASC").Limit(limit).Find(&photos)
+	if res.Error != nil {
+		return photos, res.Error
+	}
+
+	return photos, nil
+}
+
+func (p *photos) Restore(ctx context.Context, params models.PhotoParams) error {
+	res := p.db.ORM.WithContext(ctx).Unscoped().Model(&models.Photos{}).Where(params).Where("deleted_at IS NOT NULL").
+		Updates(map[string]interface{}{"deleted_at": nil, "deleted_by": nil, "updated_by": params.UserID})
+	if res.RowsAffected == 0 {
+		return errors.NotFound("Photo not found in trash")
+	} else if res.Error != nil {
+		return res.Error
+	}
+
+	return nil
+}
+
+// Purge permanently deletes trashed photos, the rows that refer to them are deleted along with them
+// and the files of the photos and their versions are released
+func (p *photos) Purge(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	return p.db.ORM.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var photoURLs, versionURLs []string
+
+		err := tx.Unscoped().Model(&models.Photos{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ? AND deleted_at IS NOT NULL", ids).Pluck("photo_url", &photoURLs).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(&models.PhotoVersions{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("photo_id IN ?", ids).Pluck("photo_url", &versionURLs).Error
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Where("photo_id IN ?", ids).Delete(&models.PhotoTags{}).Error; err != nil {
+			return err
+		}
+
+		// The likes have no foreign key to cascade the deletion, unlike the other rows that refer to the photos
+		if err := tx.Where("photo_id IN ?", ids).Delete(&models.PhotoLikes{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Unscoped().Where("id IN ? AND deleted_at IS NOT NULL", ids).Delete(&models.Photos{}).Error; err != nil {
+			return err
+		}
+
+		return photoObjectRepo.Release(tx, append(photoURLs, versionURLs...)...)
+	})
+}
+
+// Bulk applies the action to the photos of the user among the given IDs in a single transaction,
+// and returns the IDs it was applied to. The tags the user does not have yet are created along with the add_tags action.
+func (p *photos) Bulk(ctx context.Context, userID int64, params models.BulkPhotoParams) ([]int64, error) {
+	var ids []int64
+
+	err := p.db.ORM.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// The rows are locked so a concurrent request can not change them before the action is applied
+		err := tx.Model(&models.Photos{}).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ? AND user_id = ?", params.IDs, userID).
+			Pluck("id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+
+		switch params.Action {
+		case models.BulkActionDelete:
+			return tx.Model(&models.Photos{}).Where("id IN ?", ids).
+				Updates(map[string]interface{}{"deleted_at": time.Now(), "deleted_by": userID}).Error
+		case models.BulkActionVisibility:
+			return tx.Model(&models.Photos{}).Where("id IN ?", ids).
+				Updates(models.Photos{Visibility: params.Visibility, UpdatedBy: &userID}).Error
+		case models.BulkActionAddTags:
+			tags, err := tagRepo.Upsert(tx, models.TagParams{UserID: userID, Names: params.Tags})
+			if err != nil {
+				return err
+			}
+
+			photoTags := make([]models.PhotoTags, 0, len(ids)*len(tags))
+			for _, id := range ids {
+				for _, tag := range tags {
+					photoTags = append(photoTags, models.PhotoTags{PhotoID: id, TagID: tag.ID})
+				}
+			}
+
+			if len(photoTags) > 0 {
+				if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&photoTags).Error; err != nil {
+					return err
+				}
+			}
+		case models.BulkActionRemoveTags:
+			tagIDs := tx.Model(&models.Tags{}).Select("id").Where("user_id = ? AND name IN ?", userID, params.Tags)
+			if err := tx.Where("photo_id IN ? AND tag_id IN (?)", ids, tagIDs).Delete(&models.PhotoTags{}).Error; err != nil {
+				return err
+			}
+		default:
+			return errors.BadRequest("Unknown bulk action")
+		}
+
+		return tx.Model(&models.Photos{}).Where("id IN ?", ids).Updates(models.Photos{UpdatedBy: &userID}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// SwitchFile points the photo to the new file in a single transaction. The update only matches while the photo
+// still points to the current file, so two concurrent replacements can not both succeed.
+func (p *photos) SwitchFile(ctx context.Context, params models.SwitchPhotoFileParams) error {
+	return p.db.ORM.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(models.Photos{}).
+			Where("id = ? AND user_id = ? AND photo_url = ?", params.PhotoID, params.UserID, params.CurrentURL).
+			Updates(imageColumns(params.NewImage, map[string]interface{}{
+				"photo_url":    params.NewURL,
+				"content_hash": params.NewHash,
+				"updated_by":   params.UserID,
+			}))
+		if res.Error != nil {
+			return res.Error
+		} else if res.RowsAffected == 0 {
+			return errors.Conflict("Photo file has been changed by another request")
+		}
+
+		if params.Process {
+			if err := photoJobRepo.Enqueue(tx, params.PhotoID, models.PhotoJobKinds); err != nil {
+				return err
+			}
+		}
+
+		if params.RestoredVersionID != 0 {
+			res := tx.Where("id = ? AND photo_id = ?", params.RestoredVersionID, params.PhotoID).Delete(&models.PhotoVersions{})
+			if res.Error != nil {
+				return res.Error
+			} else if res.RowsAffected == 0 {
+				return errors.NotFound("Photo version not found")
+			}
+		}
+
+		// The reference of the current file moves to its version, or goes away when it is not retained
+		if params.RetainUntil == 0 {
+			return photoObjectRepo.Release(tx, params.CurrentURL)
+		}
+
+		version := models.PhotoVersions{
+			PhotoID:     params.PhotoID,
+			UserID:      params.UserID,
+			PhotoURL:    params.CurrentURL,
+			ContentHash: params.CurrentHash,
+			PhotoImage:  params.CurrentImage,
+			ExpiresAt:   params.RetainUntil,
+			CreatedBy:   &params.UserID,
+		}
+
+		return tx.Create(&version).Error
+	})
+}
+
+// GetListSimilar returns the other photos of the owner of the photo within the Hamming distance of its perceptual
+// hash, the closest first. The hashes are compared as 64 bit strings since the bits of the XOR are counted.
+// The candidates are found through the indexed bands of the hash, a distance too wide for the bands to narrow the
+// search down scans every hashed photo of the owner through the user_id index instead.
+func (p *photos) GetListSimilar(ctx context.Context, photo models.Photos, maxDistance int, limit int) ([]models.Photos, error) {
+	var photos []models.Photos
+
+	distance := "length(replace(((perceptual_hash # ?)::bit(64))::text, '0', ''))"
+
+	query := p.db.ORM.WithContext(ctx).Model(&models.Photos{}).
+		Select("photos.*, "+distance+" AS distance", *photo.PerceptualHash).
+		Where("user_id = ? AND id <> ? AND perceptual_hash IS NOT NULL", photo.UserID, photo.ID)
+
+	if radius := maxDistance / database.PerceptualHashBands; radius <= maxBandRadius {
+		bands := make([]string, 0, database.PerceptualHashBands)
+		bandValues := make([]interface{}, 0, database.PerceptualHashBands)
+		for band := 0; band < database.PerceptualHashBands; band++ {
+			bands = append(bands, database.PerceptualHashBand(band)+" IN ?")
+			bandValues = append(bandValues, bandNeighbours(hashBand(*photo.PerceptualHash, band), radius))
+		}
+
+		query = query.Where(strings.Join(bands, " OR "), bandValues...)
+	}
+
+	res := query.
+		Where(distance+" <= ?", *photo.PerceptualHash, maxDistance).
+		Order("distance ASC, id DESC").
+		Limit(limit).
+		Preload("Tags").
+		Find(&photos)
+	if res.Error != nil {
+		return photos, res.Error
+	}
+
+	return photos, nil
+}
+
+// GetListMissing returns the photos after the given ID whose column is not filled yet, for a backfill to go through
+// them once in ID order. The column is never taken from a request.
+func (p *photos) GetListMissing(ctx context.Context, column string, afterID int64, limit int) ([]models.Photos, error) {
+	var photos []models.Photos
+
+	res := p.db.ORM.WithContext(ctx).Where(clause.Eq{Column: clause.Column{Name: column}, Value: nil}).
+		Where("id > ?", afterID).Order("id ASC").Limit(limit).Find(&photos)
+	if res.Error != nil {
+		return photos, res.Error
+	}
+
+	return photos, nil
+}
+
+// SetImage fills the image description of a photo without touching its update time, the photo itself is not changed.
+// The description is dropped when the photo points to another file since it was read.
+func (p *photos) SetImage(ctx context.Context, photo models.Photos, image models.PhotoImage) error {
+	return p.db.ORM.WithContext(ctx).Model(&models.Photos{}).
+		Where("id = ? AND photo_url = ?", photo.ID, photo.PhotoURL).
+		UpdateColumns(imageColumns(image, map[string]interface{}{})).Error
+}
+
+// imageColumns adds the image columns to the updated columns, so an image that can not be decoded empties them
+// hashBand returns the value of a band of the perceptual hash, as computed by database.PerceptualHashBand
+func hashBand(hash int64, band int) int64 {
+	return int64(uint64(hash)>>(band*database.PerceptualHashBandBits)) & (1<<database.PerceptualHashBandBits - 1)
+}
+
+// bandNeighbours returns the band values within the radius of the value, the value itself included
+func bandNeighbours(value int64, radius int) []int64 {
+	neighbours := []int64{value}
+
+	var flip func(from int, value int64, left int)
+	flip = func(from int, value int64, left int) {
+		for bit := from; bit < database.PerceptualHashBandBits && left > 0; bit++ {
+			neighbour := value ^ 1<<bit
+			neighbours = append(neighbours, neighbour)
+			flip(bit+1, neighbour, left-1)
+		}
+	}
+	flip(0, value, radius)
+
+	return neighbours
+}
+
+func imageColumns(image models.PhotoImage, columns map[string]interface{}) map[string]interface{} {
+	columns["perceptual_hash"] = image.PerceptualHash
+	columns["width"] = image.Width
+	columns["height"] = image.Height
+	columns["blur_hash"] = image.BlurHash
+	columns["dominant_color"] = image.DominantColor
+
+	return columns
+}
+
+// ownerHidden reads whether the owner of the photo is hidden by the reports into Photos.OwnerHidden
+const ownerHidden = "EXISTS (SELECT 1 FROM users WHERE users.id = photos.user_id AND users.is_hidden) AS owner_hidden"
+
+// Insert creates the photo within the given transaction along with its tags, which only need a name. The tags the
+// user does not have yet are created with the photo, so a photo that fails to be created leaves no tag behind.
+func Insert(tx *gorm.DB, photo *models.Photos) error {
+	names := make([]string, 0, len(photo.Tags))
+	for _, tag := range photo.Tags {
+		names = append(names, tag.Name)
+	}
+
+	tags, err := tagRepo.Upsert(tx, models.TagParams{UserID: photo.UserID, Names: names})
+	if err != nil {
+		return err
+	}
+	photo.Tags = tags
+
+	// The tags are upserted above, only the join rows are created here
+	return tx.Omit("Tags.*").Create(photo).Error
+}
+
+// SelectOwnerHidden selects the photos along with whether their owner is hidden, so the photos preloaded by the
+// other repositories know whether they are hidden too
+func SelectOwnerHidden(db *gorm.DB) *gorm.DB {
+	return db.Select("photos.*, " + ownerHidden)
+}
+
+// filter applies every photo params condition, including the ones that can not be expressed by the struct itself
+func (p *photos) filter(ctx context.Context, params models.PhotoParams) (*gorm.DB, error) {
+	query := SelectOwnerHidden(p.db.ORM.WithContext(ctx).Model(&models.Photos{})).Where(params)
+
+	if params.Keyword != "" {
+		searchQuery, err := p.search(query, params)
+		if err != nil {
+			return query, err
+		}
+
+		query = searchQuery
+	}
+
+	query, err := listQuery.Filter(query, params.ListFilter)
+	if err != nil {
+		return query, err
+	}
+
+	if params.HasCaption != nil && *params.HasCaption {
+		query = query.Where("photos.caption <> ''")
+	} else if params.HasCaption != nil {
+		query = query.Where("photos.caption = ''")
+	}
+
+	if len(params.Visibilities) > 0 {
+		query = query.Where("photos.visibility IN ?", params.Visibilities)
+	}
+
+	if params.ExcludeHidden {
+		query = query.Where("photos.moderation_status NOT IN ?", models.HiddenPhotoModerations).
+			Where("NOT EXISTS (SELECT 1 FROM users WHERE users.id = photos.user_id AND users.is_hidden)")
+	}
+
+	if len(params.Tags) > 0 {
+		query = query.Where("photos.id IN (?)", p.taggedPhotoIDs(params))
+	}
+
+	return query, nil
+}
+
+// search matches the keyword against the search vector of every configured language,
+// ranks the photos and highlights the matching words of the title and caption.
+func (p *photos) search(query *gorm.DB, params models.PhotoParams) (*gorm.DB, error) {
+	languages := p.db.SearchLanguages()
+	if params.Language != "" {
+		if !slices.Contains(languages, params.Language) {
+			return query, errors.BadRequest(fmt.Sprintf("Language must be one of %s", strings.Join(languages, ", ")))
+		}
+
+		languages = []string{params.Language}
+	}
+
+	tsQueries := []string{}
+	tsQueryArgs := []interface{}{}
+	for _, language := range languages {
+		tsQueries = append(tsQueries, "websearch_to_tsquery(?::regconfig, ?)")
+		tsQueryArgs = append(tsQueryArgs, language, params.Keyword)
+	}
+	tsQuery := "(" + strings.Join(tsQueries, " || ") + ")"
+
+	selectArgs := []interface{}{}
+	selectArgs = append(selectArgs, tsQueryArgs...)
+	selectArgs = append(selectArgs, languages[0])
+	selectArgs = append(selectArgs, tsQueryArgs...)
+	selectArgs = append(selectArgs, titleHighlightOptions, languages[0])
+	selectArgs = append(selectArgs, tsQueryArgs...)
+	selectArgs = append(selectArgs, captionHighlightOptions)
+
+	query = query.
+		Select(fmt.Sprintf(
+			"photos.*, "+ownerHidden+", "+
+				"ts_rank(photos.search_vector, %[1]s) AS search_rank, "+
+				"ts_headline(?::regconfig, %[2]s, %[1]s, ?) AS title_highlight, "+
+				"ts_headline(?::regconfig, %[3]s, %[1]s, ?) AS caption_highlight",
+			tsQuery, escapeHTML("photos.title"), escapeHTML("photos.caption"),
+		), selectArgs...).
+		Where("photos.search_vector @@ "+tsQuery, tsQueryArgs...)
+
+	return query, nil
+}
+
+// escapeHTML escapes the HTML special characters of the column, so the highlights are the only markup of the text.
+// The parser reads an entity as a single token, the words around it are still highlighted.
+func escapeHTML(column string) string {
+	for _, replacement := range htmlReplacements {
+		column = fmt.Sprintf("replace(%s, '%s', '%s')", column, replacement[0], replacement[1])
+	}
+
+	return column
+}
+
+// taggedPhotoIDs builds a subquery of photo IDs that match the tag filter of the params
+func (p *photos) taggedPhotoIDs(params models.PhotoParams) *gorm.DB {
+	query := p.db.ORM.Table("photo_tags").
+		Select("photo_tags.photo_id").
+		Joins("JOIN tags ON tags.id = photo_tags.tag_id").
+		Where("tags.name IN ?", params.Tags).
+		Group("photo_tags.photo_id")
+
+	if params.TagMode != models.TagModeOr {
+		query = query.Having("COUNT(DISTINCT tags.id) = ?", len(params.Tags))
+	}
+
+	return query
+}
@@ -0,0 +1,96 @@
+package photos
+
+import (
+	"math/bits"
+	"testing"
+
+	"rakamin-final-task/database"
+)
+
+func TestBandNeighbours(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  int64
+		radius int
+		want   int
+	}{
+		{name: "same value only", value: 0x1234, radius: 0, want: 1},
+		{name: "one bit away", value: 0x1234, radius: 1, want: 17},
+		{name: "two bits away", value: 0xffff, radius: 2, want: 137},
+		{name: "three bits away", value: 0, radius: 3, want: 697},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := bandNeighbours(tt.value, tt.radius)
+			if len(got) != tt.want {
+				t.Fatalf("bandNeighbours() returned %d values, want %d", len(got), tt.want)
+			}
+
+			seen := map[int64]bool{}
+			for _, neighbour := range got {
+				if seen[neighbour] {
+					t.Errorf("bandNeighbours() returned %#x twice", neighbour)
+				}
+				seen[neighbour] = true
+
+				if neighbour < 0 || neighbour >= 1<<database.PerceptualHashBandBits {
+					t.Errorf("bandNeighbours() returned %#x outside of a band", neighbour)
+				}
+
+				if distance := bits.OnesCount64(uint64(neighbour ^ tt.value)); distance > tt.radius {
+					t.Errorf("bandNeighbours() returned %#x at a distance of %d", neighbour, distance)
+				}
+			}
+		})
+	}
+}
+
+func TestHashBand(t *testing.T) {
+	tests := []struct {
+		name string
+		hash int64
+		want []int64
+	}{
+		{name: "zero", hash: 0, want: []int64{0, 0, 0, 0}},
+		{name: "every band", hash: 0x1234_5678_9abc_def0, want: []int64{0xdef0, 0x9abc, 0x5678, 0x1234}},
+		{name: "sign bit", hash: -1, want: []int64{0xffff, 0xffff, 0xffff, 0xffff}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for band, want := range tt.want {
+				if got := hashBand(tt.hash, band); got != want {
+					t.Errorf("hashBand(%#x, %d) = %#x, want %#x", tt.hash, band, got, want)
+				}
+			}
+		})
+	}
+}
+
+// TestSimilarBandsPrefilter checks that a hash within the distance always shares a band within the radius the
+// similar photos are prefiltered with
+func TestSimilarBandsPrefilter(t *testing.T) {
+	hash := int64(0x0f0f_3c3c_a5a5_ff00)
+
+	for maxDistance := 0; maxDistance/database.PerceptualHashBands <= maxBandRadius; maxDistance++ {
+		radius := maxDistance / database.PerceptualHashBands
+
+		// The flipped bits are spread over the bands so every band is as far as it can be
+		other := hash
+		for flipped := 0; flipped < maxDistance; flipped++ {
+			other ^= 1 << ((flipped%database.PerceptualHashBands)*database.PerceptualHashBandBits + flipped/database.PerceptualHashBands)
+		}
+
+		found := false
+		for band := 0; band < database.PerceptualHashBands; band++ {
+			for _, neighbour := range bandNeighbours(hashBand(hash, band), radius) {
+				found = found || neighbour == hashBand(other, band)
+			}
+		}
+
+		if !found {
+			t.Errorf("a hash at a distance of %d shares no band within %d bits", maxDistance, radius)
+		}
+	}
+}
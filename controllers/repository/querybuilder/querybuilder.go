@@ -0,0 +1,334 @@
+package querybuilder
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"rakamin-final-task/helpers/errors"
+	"rakamin-final-task/helpers/response"
+	"rakamin-final-task/models"
+)
+
+const (
+	dateLayout = "2006-01-02"
+
+	directionAsc  = "asc"
+	directionDesc = "desc"
+)
+
+// Builder applies a models.ListFilter to the list query of a table.
+// Only the fields listed in SortColumns can be sorted, so the sort grammar never reaches the SQL as is.
+type Builder struct {
+	Table string
+
+	// SortColumns maps the public sort field to its column, e.g. "created" to "created_at"
+	SortColumns map[string]string
+	DefaultSort string
+}
+
+type order struct {
+	column string
+	desc   bool
+}
+
+// cursor is the opaque position of a row in a sorted list, it is only valid for the sort it was made for
+type cursor struct {
+	Sort     string        `json:"s"`
+	Values   []interface{} `json:"v"`
+	Backward bool          `json:"b,omitempty"`
+}
+
+func (b Builder) Filter(db *gorm.DB, filter models.ListFilter) (*gorm.DB, error) {
+	if filter.CreatedFrom != "" {
+		createdFrom, err := parseTime(filter.CreatedFrom, false)
+		if err != nil {
+			return db, errors.BadRequest("Invalid created_from, use a unix timestamp, RFC3339 time or YYYY-MM-DD date")
+		}
+
+		db = db.Where(b.column("created_at")+" >= ?", createdFrom)
+	}
+
+	if filter.CreatedTo != "" {
+		createdTo, err := parseTime(filter.CreatedTo, true)
+		if err != nil {
+			return db, errors.BadRequest("Invalid created_to, use a unix timestamp, RFC3339 time or YYYY-MM-DD date")
+		}
+
+		db = db.Where(b.column("created_at")+" <= ?", createdTo)
+	}
+
+	return db, nil
+}
+
+// List finds a page of rows into dest, a pointer to a slice, and fills the pagination.
+// The page is taken by offset, or by keyset when the pagination has a cursor. The next and previous cursors
+// are returned in both cases so a client can switch to keyset pagination from any page.
+// A leading order, such as a search rank, can not be expressed as a keyset, so it is only applied to offset
+// pages and no cursor is returned for them.
+func (b Builder) List(db *gorm.DB, dest interface{}, sortParam string, leadingOrder string, pg *response.PaginationParam) error {
+	// The session lets the same conditions be used by both the count and the list query
+	db = db.Session(&gorm.Session{})
+
+	if strings.TrimSpace(sortParam) == "" {
+		sortParam = b.DefaultSort
+	}
+
+	orders, err := b.orders(sortParam)
+	if err != nil {
+		return err
+	}
+
+	if pg.WithTotal {
+		if err := db.Count(&pg.TotalElement).Error; err != nil {
+			return err
+		}
+	}
+
+	var currentCursor cursor
+	query := db
+	if pg.Cursor != "" {
+		currentCursor, err = decodeCursor(pg.Cursor, sortParam, len(orders))
+		if err != nil {
+			return err
+		}
+
+		query = b.applyKeyset(query, orders, currentCursor)
+		query = b.applyOrders(query, orders, currentCursor.Backward)
+	} else {
+		if leadingOrder != "" {
+			query = query.Order(leadingOrder)
+		}
+
+		query = b.applyOrders(query, orders, false).Offset(int(pg.Offset))
+	}
+
+	// One extra row tells whether there is another page after this one
+	res := query.Limit(int(pg.Limit) + 1).Find(dest)
+	if res.Error != nil {
+		return res.Error
+	}
+
+	rows := reflect.ValueOf(dest).Elem()
+	hasMore := int64(rows.Len()) > pg.Limit
+	if hasMore {
+		rows.Set(rows.Slice(0, int(pg.Limit)))
+	}
+
+	if currentCursor.Backward {
+		reverse(rows)
+	}
+
+	pg.ProcessPagination(int64(rows.Len()))
+
+	if rows.Len() == 0 || (leadingOrder != "" && pg.Cursor == "") {
+		return nil
+	}
+
+	hasNext := hasMore || currentCursor.Backward
+	hasPrev := pg.Offset > 0 || (pg.Cursor != "" && !currentCursor.Backward) || (currentCursor.Backward && hasMore)
+
+	if hasNext {
+		pg.NextCursor, err = encodeCursor(res, rows.Index(rows.Len()-1), orders, sortParam, false)
+		if err != nil {
+			return err
+		}
+	}
+
+	if hasPrev {
+		pg.PrevCursor, err = encodeCursor(res, rows.Index(0), orders, sortParam, true)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// orders parses the sort grammar into the ordered columns, the ID is appended as the tie breaker
+func (b Builder) orders(sortParam string) ([]order, error) {
+	if strings.TrimSpace(sortParam) == "" {
+		sortParam = b.DefaultSort
+	}
+
+	orders := []order{}
+	lastDirection := directionDesc
+	for _, sortField := range strings.Split(sortParam, ",") {
+		field, direction, _ := strings.Cut(strings.TrimSpace(sortField), ":")
+		field = strings.ToLower(strings.TrimSpace(field))
+		direction = strings.ToLower(strings.TrimSpace(direction))
+
+		column, ok := b.SortColumns[field]
+		if !ok {
+			return orders, errors.BadRequest(fmt.Sprintf("Invalid sort field %q, use one of %s", field, b.sortFields()))
+		}
+
+		switch direction {
+		case "", directionAsc:
+			direction = directionAsc
+		case directionDesc:
+		default:
+			return orders, errors.BadRequest(fmt.Sprintf("Invalid sort direction %q, use asc or desc", direction))
+		}
+
+		orders = append(orders, order{column: column, desc: direction == directionDesc})
+		lastDirection = direction
+	}
+
+	orders = append(orders, order{column: "id", desc: lastDirection == directionDesc})
+
+	return orders, nil
+}
+
+func (b Builder) column(name string) string {
+	return fmt.Sprintf("%s.%s", b.Table, name)
+}
+
+func (b Builder) sortFields() string {
+	fields := []string{}
+	for field := range b.SortColumns {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	return strings.Join(fields, ", ")
+}
+
+// applyOrders orders the query, a backward page is read in the opposite order and reversed afterwards
+func (b Builder) applyOrders(db *gorm.DB, orders []order, isBackward bool) *gorm.DB {
+	for _, o := range orders {
+		direction := directionAsc
+		if o.desc != isBackward {
+			direction = directionDesc
+		}
+
+		db = db.Order(fmt.Sprintf("%s %s", b.column(o.column), strings.ToUpper(direction)))
+	}
+
+	return db
+}
+
+// applyKeyset keeps the rows after the cursor position, e.g. for "a ASC, id DESC" it is
+// (a > ?) OR (a = ? AND id < ?)
+func (b Builder) applyKeyset(db *gorm.DB, orders []order, c cursor) *gorm.DB {
+	conditions := []string{}
+	args := []interface{}{}
+
+	for i, o := range orders {
+		condition := []string{}
+		for j := 0; j < i; j++ {
+			condition = append(condition, b.column(orders[j].column)+" = ?")
+			args = append(args, c.Values[j])
+		}
+
+		operator := ">"
+		if o.desc != c.Backward {
+			operator = "<"
+		}
+		condition = append(condition, fmt.Sprintf("%s %s ?", b.column(o.column), operator))
+		args = append(args, c.Values[i])
+
+		conditions = append(conditions, "("+strings.Join(condition, " AND ")+")")
+	}
+
+	return db.Where("("+strings.Join(conditions, " OR ")+")", args...)
+}
+
+func encodeCursor(res *gorm.DB, row reflect.Value, orders []order, sortParam string, isBackward bool) (string, error) {
+	c := cursor{
+		Sort:     sortParam,
+		Backward: isBackward,
+	}
+
+	for _, o := range orders {
+		field := res.Statement.Schema.LookUpField(o.column)
+		if field == nil {
+			return "", fmt.Errorf("cursor column %s is not a field", o.column)
+		}
+
+		value, _ := field.ValueOf(res.Statement.Context, row)
+		c.Values = append(c.Values, value)
+	}
+
+	encoded, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(encoded), nil
+}
+
+func decodeCursor(encoded string, sortParam string, length int) (cursor, error) {
+	var c cursor
+
+	decoded, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return c, errors.BadRequest("Invalid cursor")
+	}
+
+	// Numbers are kept as they are, a unix timestamp should not lose precision as a float
+	decoder := json.NewDecoder(bytes.NewReader(decoded))
+	decoder.UseNumber()
+	if err := decoder.Decode(&c); err != nil {
+		return c, errors.BadRequest("Invalid cursor")
+	}
+
+	if c.Sort != sortParam {
+		return c, errors.BadRequest("Cursor does not match the sort, start again without a cursor")
+	}
+
+	if len(c.Values) != length {
+		return c, errors.BadRequest("Invalid cursor")
+	}
+
+	for i, value := range c.Values {
+		number, ok := value.(json.Number)
+		if !ok {
+			continue
+		}
+
+		if integer, err := number.Int64(); err == nil {
+			c.Values[i] = integer
+		} else if float, err := number.Float64(); err == nil {
+			c.Values[i] = float
+		}
+	}
+
+	return c, nil
+}
+
+func reverse(rows reflect.Value) {
+	swap := reflect.Swapper(rows.Interface())
+	for i, j := 0, rows.Len()-1; i < j; i, j = i+1, j-1 {
+		swap(i, j)
+	}
+}
+
+// parseTime converts the time to a unix timestamp, a date is the start of the day or the end of it when endOfDay is set
+func parseTime(value string, endOfDay bool) (int64, error) {
+	if unix, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return unix, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.Unix(), nil
+	}
+
+	t, err := time.ParseInLocation(dateLayout, value, time.Local)
+	if err != nil {
+		return 0, err
+	}
+
+	if endOfDay {
+		return t.AddDate(0, 0, 1).Unix() - 1, nil
+	}
+
+	return t.Unix(), nil
+}
@@ -0,0 +1,320 @@
+package querybuilder
+
+import (
+	"encoding/base64"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"rakamin-final-task/helpers/errors"
+	"rakamin-final-task/models"
+)
+
+var testQuery = Builder{
+	Table: "photos",
+	SortColumns: map[string]string{
+		"created": "created_at",
+		"title":   "title",
+	},
+	DefaultSort: "created:desc",
+}
+
+// dryRunDB builds the queries without a database, so the statements and their schema can be inspected
+func dryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(postgres.Open("host=127.0.0.1"), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []struct {
+		name       string
+		sort       string
+		photo      models.Photos
+		isBackward bool
+		want       []interface{}
+	}{
+		{
+			name:  "next page of the default sort",
+			sort:  "created:desc",
+			photo: models.Photos{ID: 7, CreatedAt: 1700000000},
+			want:  []interface{}{int64(1700000000), int64(7)},
+		},
+		{
+			name:       "previous page",
+			sort:       "created:desc",
+			photo:      models.Photos{ID: 7, CreatedAt: 1700000000},
+			isBackward: true,
+			want:       []interface{}{int64(1700000000), int64(7)},
+		},
+		{
+			name:  "text and time columns",
+			sort:  "title:asc,created:desc",
+			photo: models.Photos{ID: 3, Title: "Sunset, again", CreatedAt: 1},
+			want:  []interface{}{"Sunset, again", int64(1), int64(3)},
+		},
+		{
+			name:  "ID beyond the float precision",
+			sort:  "created:asc",
+			photo: models.Photos{ID: 1<<53 + 1, CreatedAt: 0},
+			want:  []interface{}{int64(0), int64(1<<53 + 1)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orders, err := testQuery.orders(tt.sort)
+			if err != nil {
+				t.Fatalf("orders(%q) error = %v", tt.sort, err)
+			}
+
+			photos := []models.Photos{tt.photo}
+			res := dryRunDB(t).Find(&photos)
+
+			encoded, err := encodeCursor(res, reflect.ValueOf(photos).Index(0), orders, tt.sort, tt.isBackward)
+			if err != nil {
+				t.Fatalf("encodeCursor() error = %v", err)
+			}
+
+			got, err := decodeCursor(encoded, tt.sort, len(orders))
+			if err != nil {
+				t.Fatalf("decodeCursor() error = %v", err)
+			}
+
+			if got.Sort != tt.sort || got.Backward != tt.isBackward || !reflect.DeepEqual(got.Values, tt.want) {
+				t.Errorf("decodeCursor() = %+v, want sort %q, backward %v and values %v", got, tt.sort, tt.isBackward, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeCursor(t *testing.T) {
+	encode := func(json string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(json))
+	}
+
+	tests := []struct {
+		name    string
+		encoded string
+		sort    string
+		length  int
+		want    []interface{}
+		wantErr bool
+	}{
+		{
+			name:    "integer and text values",
+			encoded: encode(`{"s":"title:asc","v":["a",9007199254740993]}`),
+			sort:    "title:asc",
+			length:  2,
+			want:    []interface{}{"a", int64(9007199254740993)},
+		},
+		{
+			name:    "float value",
+			encoded: encode(`{"s":"title:asc","v":[0.5,1]}`),
+			sort:    "title:asc",
+			length:  2,
+			want:    []interface{}{0.5, int64(1)},
+		},
+		{
+			name:    "null value",
+			encoded: encode(`{"s":"title:asc","v":[null,1]}`),
+			sort:    "title:asc",
+			length:  2,
+			want:    []interface{}{nil, int64(1)},
+		},
+		{
+			name:    "not base64",
+			encoded: "not a cursor!",
+			sort:    "title:asc",
+			length:  2,
+			wantErr: true,
+		},
+		{
+			name:    "padded base64",
+			encoded: base64.URLEncoding.EncodeToString([]byte(`{"s":"title:asc","v":[1]}`)),
+			sort:    "title:asc",
+			length:  1,
+			wantErr: true,
+		},
+		{
+			name:    "not JSON",
+			encoded: encode(`title:asc`),
+			sort:    "title:asc",
+			length:  1,
+			wantErr: true,
+		},
+		{
+			name:    "other sort",
+			encoded: encode(`{"s":"title:asc","v":["a",1]}`),
+			sort:    "title:desc",
+			length:  2,
+			wantErr: true,
+		},
+		{
+			name:    "missing value",
+			encoded: encode(`{"s":"title:asc","v":[1]}`),
+			sort:    "title:asc",
+			length:  2,
+			wantErr: true,
+		},
+		{
+			name:    "extra value",
+			encoded: encode(`{"s":"title:asc","v":["a",1,2]}`),
+			sort:    "title:asc",
+			length:  2,
+			wantErr: true,
+		},
+		{
+			name:    "empty",
+			encoded: "",
+			sort:    "title:asc",
+			length:  2,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeCursor(tt.encoded, tt.sort, tt.length)
+			if tt.wantErr {
+				if errors.GetCode(err) != http.StatusBadRequest {
+					t.Errorf("decodeCursor() error = %v, want a bad request", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("decodeCursor() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(got.Values, tt.want) {
+				t.Errorf("decodeCursor() values = %#v, want %#v", got.Values, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeyset(t *testing.T) {
+	tests := []struct {
+		name   string
+		sort   string
+		cursor cursor
+		want   string
+	}{
+		{
+			name:   "next page descending",
+			sort:   "created:desc",
+			cursor: cursor{Values: []interface{}{int64(100), int64(5)}},
+			want:   `SELECT * FROM "photos" WHERE (((photos.created_at < 100) OR (photos.created_at = 100 AND photos.id < 5))) AND "photos"."deleted_at" IS NULL ORDER BY photos.created_at DESC,photos.id DESC`,
+		},
+		{
+			name:   "previous page descending",
+			sort:   "created:desc",
+			cursor: cursor{Values: []interface{}{int64(100), int64(5)}, Backward: true},
+			want:   `SELECT * FROM "photos" WHERE (((photos.created_at > 100) OR (photos.created_at = 100 AND photos.id > 5))) AND "photos"."deleted_at" IS NULL ORDER BY photos.created_at ASC,photos.id ASC`,
+		},
+		{
+			name:   "next page ascending",
+			sort:   "created:asc",
+			cursor: cursor{Values: []interface{}{int64(100), int64(5)}},
+			want:   `SELECT * FROM "photos" WHERE (((photos.created_at > 100) OR (photos.created_at = 100 AND photos.id > 5))) AND "photos"."deleted_at" IS NULL ORDER BY photos.created_at ASC,photos.id ASC`,
+		},
+		{
+			name:   "mixed directions, the ID follows the last one",
+			sort:   "title:asc,created:desc",
+			cursor: cursor{Values: []interface{}{"a", int64(100), int64(5)}},
+			want:   `SELECT * FROM "photos" WHERE (((photos.title > 'a') OR (photos.title = 'a' AND photos.created_at < 100) OR (photos.title = 'a' AND photos.created_at = 100 AND photos.id < 5))) AND "photos"."deleted_at" IS NULL ORDER BY photos.title ASC,photos.created_at DESC,photos.id DESC`,
+		},
+		{
+			name:   "previous page of mixed directions",
+			sort:   "title:asc,created:desc",
+			cursor: cursor{Values: []interface{}{"a", int64(100), int64(5)}, Backward: true},
+			want:   `SELECT * FROM "photos" WHERE (((photos.title < 'a') OR (photos.title = 'a' AND photos.created_at > 100) OR (photos.title = 'a' AND photos.created_at = 100 AND photos.id > 5))) AND "photos"."deleted_at" IS NULL ORDER BY photos.title DESC,photos.created_at ASC,photos.id ASC`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orders, err := testQuery.orders(tt.sort)
+			if err != nil {
+				t.Fatalf("orders(%q) error = %v", tt.sort, err)
+			}
+
+			got := dryRunDB(t).ToSQL(func(tx *gorm.DB) *gorm.DB {
+				query := testQuery.applyKeyset(tx.Model(&models.Photos{}), orders, tt.cursor)
+				return testQuery.applyOrders(query, orders, tt.cursor.Backward).Find(&[]models.Photos{})
+			})
+
+			if got != tt.want {
+				t.Errorf("keyset query\n got: %s\nwant: %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrders(t *testing.T) {
+	tests := []struct {
+		name    string
+		sort    string
+		want    []order
+		wantErr bool
+	}{
+		{
+			name: "default sort",
+			sort: " ",
+			want: []order{{column: "created_at", desc: true}, {column: "id", desc: true}},
+		},
+		{
+			name: "default direction is ascending",
+			sort: "Title",
+			want: []order{{column: "title"}, {column: "id"}},
+		},
+		{
+			name: "several fields",
+			sort: "title:desc, created:ASC",
+			want: []order{{column: "title", desc: true}, {column: "created_at"}, {column: "id"}},
+		},
+		{
+			name:    "unknown field",
+			sort:    "id:asc",
+			wantErr: true,
+		},
+		{
+			name:    "unknown direction",
+			sort:    "created:up",
+			wantErr: true,
+		},
+		{
+			name:    "empty field",
+			sort:    "created,",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := testQuery.orders(tt.sort)
+			if tt.wantErr {
+				if errors.GetCode(err) != http.StatusBadRequest {
+					t.Errorf("orders() error = %v, want a bad request", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("orders() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("orders() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package reports
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	photoModerationRepo "rakamin-final-task/controllers/repository/photo_moderations"
+	"rakamin-final-task/controllers/repository/querybuilder"
+	"rakamin-final-task/database"
+	"rakamin-final-task/helpers/errors"
+	"rakamin-final-task/helpers/moderation"
+	"rakamin-final-task/helpers/response"
+	"rakamin-final-task/models"
+)
+
+type Interface interface {
+	Create(ctx context.Context, report models.Reports, hideThreshold int) (models.Reports, error)
+	Get(ctx context.Context, params models.ReportParams) (models.Reports, error)
+	GetList(ctx context.Context, params models.ReportParams) ([]models.Reports, *response.PaginationParam, error)
+	Resolve(ctx context.Context, report models.Reports) error
+}
+
+// listQuery lists the oldest reports first, so the queue is resolved in order
+var listQuery = querybuilder.Builder{
+	Table: "reports",
+	SortColumns: map[string]string{
+		"created": "created_at",
+		"updated": "updated_at",
+	},
+	DefaultSort: "created:asc",
+}
+
+type reports struct {
+	db *database.DB
+}
+
+func Init(db *database.DB) Interface {
+	return &reports{
+		db: db,
+	}
+}
+
+// Create files the report and hides its target in a single transaction once hideThreshold distinct users have a
+// pending report on it. A user can only have one pending report per target.
+func (r *reports) Create(ctx context.Context, report models.Reports, hideThreshold int) (models.Reports, error) {
+	err := r.db.ORM.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		column, targetID := target(report)
+
+		// The target is locked so the concurrent reports on it are counted one after the other and exactly one of
+		// them reaches the threshold
+		var targetModel interface{} = &models.Photos{}
+		if report.PhotoID == nil {
+			targetModel = &models.Users{}
+		}
+
+		var lockedIDs []int64
+		err := tx.Model(targetModel).Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", targetID).Pluck("id", &lockedIDs).Error
+		if err != nil {
+			return err
+		}
+
+		res := tx.Clauses(clause.OnConflict{
+			Columns:     []clause.Column{{Name: "reporter_id"}, {Name: column}},
+			TargetWhere: clause.Where{Exprs: []clause.Expression{gorm.Expr("status = ?", models.ReportStatusPending)}},
+			DoNothing:   true,
+		}).Create(&report)
+		if res.Error != nil {
+			return res.Error
+		} else if res.RowsAffected == 0 {
+			return errors.Conflict("You have already reported it")
+		}
+
+		if hideThreshold <= 0 {
+			return nil
+		}
+
+		var count int64
+		if err := tx.Model(&models.Reports{}).
+			Where(column+" = ? AND status = ?", targetID, models.ReportStatusPending).
+			Count(&count).Error; err != nil {
+			return err
+		}
+
+		// the target is hidden once, when the threshold is reached, so a moderator who already reviewed the flag
+		// is not overruled by the following reports
+		if count != int64(hideThreshold) {
+			return nil
+		}
+
+		if report.PhotoID == nil {
+			return tx.Model(&models.Users{}).Where("id = ?", targetID).UpdateColumn("is_hidden", true).Error
+		}
+
+		photoModeration := models.PhotoModerations{
+			PhotoID: targetID,
+			Source:  models.ModerationSourceReport,
+			Action:  moderation.ActionFlag,
+			Reasons: "Reported by many users",
+			Status:  models.ModerationStatusPending,
+		}
+		if err := tx.Create(&photoModeration).Error; err != nil {
+			return err
+		}
+
+		return photoModerationRepo.UpdateStatus(tx, targetID)
+	})
+
+	return report, err
+}
+
+func (r *reports) Get(ctx context.Context, params models.ReportParams) (models.Reports, error) {
+	var report models.Reports
+
+	res := r.db.ORM.WithContext(ctx).Where(params).Preload("Photo").Preload("ReportedUser").First(&report)
+	if res.RowsAffected == 0 {
+		return report, errors.NotFound("Report not found")
+	} else if res.Error != nil {
+		return report, res.Error
+	}
+
+	return report, nil
+}
+
+func (r *reports) GetList(ctx context.Context, params models.ReportParams) ([]models.Reports, *response.PaginationParam, error) {
+	var reports []models.Reports
+
+	pg := params.PaginationParam
+	pg.SetDefaultPagination()
+
+	query := r.db.ORM.WithContext(ctx).Model(&models.Reports{}).Where(params).Preload("Photo").Preload("ReportedUser")
+
+	switch params.Target {
+	case models.ReportTargetPhoto:
+		query = query.Where("reports.photo_id IS NOT NULL")
+	case models.ReportTargetUser:
+		query = query.Where("reports.reported_user_id IS NOT NULL")
+	}
+
+	query, err := listQuery.Filter(query, params.ListFilter)
+	if err != nil {
+		return reports, &pg, err
+	}
+
+	if err := listQuery.List(query, &reports, params.Sort, "", &pg); err != nil {
+		return reports, &pg, err
+	}
+
+	return reports, &pg, nil
+}
+
+// Resolve records the outcome of a moderator on all the pending reports of the target of the report in a single
+// transaction. A dismissal shows the target again, an upheld report rejects the photo or keeps the user hidden.
+func (r *reports) Resolve(ctx context.Context, report models.Reports) error {
+	return r.db.ORM.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		column, targetID := target(report)
+		resolvedAt := time.Now().Unix()
+
+		res := tx.Model(&models.Reports{}).
+			Where(column+" = ? AND status = ?", targetID, models.ReportStatusPending).
+			Updates(map[string]interface{}{
+				"status":      models.ReportStatusResolved,
+				"outcome":     report.Outcome,
+				"resolved_by": report.ResolvedBy,
+				"resolved_at": resolvedAt,
+				"note":        report.Note,
+			})
+		if res.Error != nil {
+			return res.Error
+		} else if res.RowsAffected == 0 {
+			return errors.Conflict("Report has already been resolved")
+		}
+
+		upheld := report.Outcome == models.ReportOutcomeUpheld
+		if report.PhotoID == nil {
+			return tx.Model(&models.Users{}).Where("id = ?", targetID).UpdateColumn("is_hidden", upheld).Error
+		}
+
+		status := models.ModerationStatusApproved
+		if upheld {
+			status = models.ModerationStatusRejected
+		}
+
+		res = tx.Model(&models.PhotoModerations{}).
+			Where("photo_id = ? AND source = ? AND status = ?", targetID, models.ModerationSourceReport, models.ModerationStatusPending).
+			Updates(map[string]interface{}{
+				"status":      status,
+				"reviewed_by": report.ResolvedBy,
+				"reviewed_at": resolvedAt,
+				"note":        report.Note,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+
+		// an upheld report rejects the photo even when it has not been reported by enough users to be flagged
+		if upheld && res.RowsAffected == 0 {
+			photoModeration := models.PhotoModerations{
+				PhotoID:    targetID,
+				Source:     models.ModerationSourceReport,
+				Action:     moderation.ActionReject,
+				Reasons:    "Report upheld",
+				Status:     status,
+				ReviewedBy: report.ResolvedBy,
+				ReviewedAt: &resolvedAt,
+				Note:       report.Note,
+			}
+			if err := tx.Create(&photoModeration).Error; err != nil {
+				return err
+			}
+		}
+
+		return photoModerationRepo.UpdateStatus(tx, targetID)
+	})
+}
+
+// target returns the column and the ID of the target of the report, either a photo or a user
+func target(report models.Reports) (string, int64) {
+	if report.PhotoID != nil {
+		return "photo_id", *report.PhotoID
+	}
+
+	return "reported_user_id", *report.ReportedUserID
+}
@@ -0,0 +1,150 @@
+package reports
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"rakamin-final-task/database"
+	"rakamin-final-task/helpers/errors"
+	"rakamin-final-task/models"
+)
+
+// mockDB runs the repository against a mocked connection, so the statements of a transaction are checked
+// without a database
+func mockDB(t *testing.T) (*database.DB, sqlmock.Sqlmock) {
+	t.Helper()
+
+	conn, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	orm, err := gorm.Open(postgres.New(postgres.Config{Conn: conn}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &database.DB{ORM: orm}, mock
+}
+
+func TestCreate(t *testing.T) {
+	const targetID = int64(5)
+
+	tests := []struct {
+		name          string
+		isPhoto       bool
+		hideThreshold int
+		isDuplicate   bool
+		pendingCount  int64
+		wantHidden    bool
+		wantCode      int64
+	}{
+		{
+			name:          "below the threshold",
+			hideThreshold: 3,
+			pendingCount:  2,
+		},
+		{
+			name:          "user reaching the threshold is hidden",
+			hideThreshold: 3,
+			pendingCount:  3,
+			wantHidden:    true,
+		},
+		{
+			name:          "photo reaching the threshold is flagged",
+			isPhoto:       true,
+			hideThreshold: 3,
+			pendingCount:  3,
+			wantHidden:    true,
+		},
+		{
+			name:          "past the threshold the moderator review stands",
+			isPhoto:       true,
+			hideThreshold: 3,
+			pendingCount:  4,
+		},
+		{
+			name:          "hiding disabled",
+			hideThreshold: 0,
+		},
+		{
+			name:          "pending report of the same reporter",
+			hideThreshold: 3,
+			isDuplicate:   true,
+			wantCode:      http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := mockDB(t)
+
+			report := models.Reports{ReporterID: 2, Reason: "spam"}
+			table, column := "users", "reported_user_id"
+			if tt.isPhoto {
+				report.PhotoID = new(int64)
+				*report.PhotoID = targetID
+				table, column = "photos", "photo_id"
+			} else {
+				report.ReportedUserID = new(int64)
+				*report.ReportedUserID = targetID
+			}
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id" FROM "` + table + `" WHERE id = $1 AND "` + table + `"."deleted_at" IS NULL FOR UPDATE`)).
+				WithArgs(targetID).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(targetID))
+
+			inserted := sqlmock.NewRows([]string{"id"})
+			if !tt.isDuplicate {
+				inserted.AddRow(1)
+			}
+			mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "reports"`) + `.*` + regexp.QuoteMeta(`ON CONFLICT ("reporter_id","`+column+`")  WHERE status = $13 DO NOTHING`)).
+				WillReturnRows(inserted)
+
+			if !tt.isDuplicate && tt.hideThreshold > 0 {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "reports" WHERE `+column+` = $1 AND status = $2`)).
+					WithArgs(targetID, models.ReportStatusPending).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(tt.pendingCount))
+			}
+
+			switch {
+			case tt.wantHidden && tt.isPhoto:
+				mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "photo_moderations"`)).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "photos" SET "moderation_status"=CASE`)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			case tt.wantHidden:
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "users" SET "is_hidden"=$1 WHERE id = $2`)).
+					WithArgs(true, targetID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+
+			if tt.wantCode != 0 {
+				mock.ExpectRollback()
+			} else {
+				mock.ExpectCommit()
+			}
+
+			_, err := Init(db).Create(context.Background(), report, tt.hideThreshold)
+			if tt.wantCode != 0 {
+				if errors.GetCode(err) != tt.wantCode {
+					t.Errorf("Create() error = %v, want a %d", err, tt.wantCode)
+				}
+			} else if err != nil {
+				t.Errorf("Create() error = %v", err)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
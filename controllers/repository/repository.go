@@ -1,22 +1,64 @@
 package repository
 
 import (
+	commentRepo "rakamin-final-task/controllers/repository/comments"
+	likeRepo "rakamin-final-task/controllers/repository/likes"
+	photoJobRepo "rakamin-final-task/controllers/repository/photo_jobs"
+	photoModerationRepo "rakamin-final-task/controllers/repository/photo_moderations"
+	photoObjectRepo "rakamin-final-task/controllers/repository/photo_objects"
+	photoRenderRepo "rakamin-final-task/controllers/repository/photo_renders"
+	photoRenditionRepo "rakamin-final-task/controllers/repository/photo_renditions"
+	photoUploadRepo "rakamin-final-task/controllers/repository/photo_uploads"
+	photoVersionRepo "rakamin-final-task/controllers/repository/photo_versions"
+	photoRepo "rakamin-final-task/controllers/repository/photos"
+	reportRepo "rakamin-final-task/controllers/repository/reports"
+	shareLinkRepo "rakamin-final-task/controllers/repository/share_links"
+	tagRepo "rakamin-final-task/controllers/repository/tags"
+	tusUploadRepo "rakamin-final-task/controllers/repository/tus_uploads"
 	userTokenRepo "rakamin-final-task/controllers/repository/user_token"
 	userRepo "rakamin-final-task/controllers/repository/users"
-	photoRepo "rakamin-final-task/controllers/repository/photos"
+	watermarkRepo "rakamin-final-task/controllers/repository/watermarks"
 	"rakamin-final-task/database"
 )
 
 type Repository struct {
-	Users     userRepo.Interface
-	UserToken userTokenRepo.Interface
-	Photos    photoRepo.Interface
+	Users            userRepo.Interface
+	UserToken        userTokenRepo.Interface
+	Photos           photoRepo.Interface
+	Tags             tagRepo.Interface
+	ShareLink        shareLinkRepo.Interface
+	Likes            likeRepo.Interface
+	Comments         commentRepo.Interface
+	PhotoVersions    photoVersionRepo.Interface
+	PhotoUploads     photoUploadRepo.Interface
+	TusUploads       tusUploadRepo.Interface
+	PhotoObjects     photoObjectRepo.Interface
+	PhotoRenditions  photoRenditionRepo.Interface
+	Watermarks       watermarkRepo.Interface
+	PhotoRenders     photoRenderRepo.Interface
+	PhotoJobs        photoJobRepo.Interface
+	PhotoModerations photoModerationRepo.Interface
+	Reports          reportRepo.Interface
 }
 
 func Init(db *database.DB) Repository {
 	return Repository{
-		Users:     userRepo.Init(db),
-		UserToken: userTokenRepo.Init(db),
-		Photos:    photoRepo.Init(db),
+		Users:            userRepo.Init(db),
+		UserToken:        userTokenRepo.Init(db),
+		Photos:           photoRepo.Init(db),
+		Tags:             tagRepo.Init(db),
+		ShareLink:        shareLinkRepo.Init(db),
+		Likes:            likeRepo.Init(db),
+		Comments:         commentRepo.Init(db),
+		PhotoVersions:    photoVersionRepo.Init(db),
+		PhotoUploads:     photoUploadRepo.Init(db),
+		TusUploads:       tusUploadRepo.Init(db),
+		PhotoObjects:     photoObjectRepo.Init(db),
+		PhotoRenditions:  photoRenditionRepo.Init(db),
+		Watermarks:       watermarkRepo.Init(db),
+		PhotoRenders:     photoRenderRepo.Init(db),
+		PhotoJobs:        photoJobRepo.Init(db),
+		PhotoModerations: photoModerationRepo.Init(db),
+		Reports:          reportRepo.Init(db),
 	}
 }
@@ -0,0 +1,120 @@
+package share_links
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	photoRepo "rakamin-final-task/controllers/repository/photos"
+	"rakamin-final-task/database"
+	"rakamin-final-task/helpers/errors"
+	"rakamin-final-task/models"
+)
+
+type Interface interface {
+	Create(ctx context.Context, shareLink models.ShareLinks) (models.ShareLinks, error)
+	Get(ctx context.Context, params models.ShareLinkParams) (models.ShareLinks, error)
+	GetList(ctx context.Context, params models.ShareLinkParams) ([]models.ShareLinks, error)
+	Update(ctx context.Context, shareLink models.ShareLinks, params models.ShareLinkParams) (models.ShareLinks, error)
+	IncrementViewCount(ctx context.Context, params models.ShareLinkParams, now int64) error
+	ReservePasswordAttempt(ctx context.Context, params models.ShareLinkParams, now int64, maxAttempts int64, lockoutSec int64) error
+	ResetPasswordAttempts(ctx context.Context, params models.ShareLinkParams) error
+}
+
+type shareLinks struct {
+	db *database.DB
+}
+
+func Init(db *database.DB) Interface {
+	return &shareLinks{
+		db: db,
+	}
+}
+
+func (s *shareLinks) Create(ctx context.Context, shareLink models.ShareLinks) (models.ShareLinks, error) {
+	if err := s.db.ORM.WithContext(ctx).Create(&shareLink).Error; err != nil {
+		return shareLink, err
+	}
+
+	return shareLink, nil
+}
+
+func (s *shareLinks) Get(ctx context.Context, params models.ShareLinkParams) (models.ShareLinks, error) {
+	var shareLink models.ShareLinks
+
+	res := s.db.ORM.WithContext(ctx).Preload("Photo", photoRepo.SelectOwnerHidden).Where(params).First(&shareLink)
+	if res.RowsAffected == 0 {
+		return shareLink, errors.NotFound("Share link not found")
+	} else if res.Error != nil {
+		return shareLink, res.Error
+	}
+
+	return shareLink, nil
+}
+
+func (s *shareLinks) GetList(ctx context.Context, params models.ShareLinkParams) ([]models.ShareLinks, error) {
+	var shareLinks []models.ShareLinks
+
+	res := s.db.ORM.WithContext(ctx).Where(params).Order("created_at DESC").Find(&shareLinks)
+	if res.Error != nil {
+		return shareLinks, res.Error
+	}
+
+	return shareLinks, nil
+}
+
+func (s *shareLinks) Update(ctx context.Context, shareLink models.ShareLinks, params models.ShareLinkParams) (models.ShareLinks, error) {
+	res := s.db.ORM.WithContext(ctx).Model(models.ShareLinks{}).Where(params).Updates(&shareLink)
+	if res.RowsAffected == 0 {
+		return shareLink, errors.NotFound("Share link not found")
+	} else if res.Error != nil {
+		return shareLink, res.Error
+	}
+
+	return shareLink, nil
+}
+
+// IncrementViewCount counts a view only while the link is still usable, so concurrent views can not exceed the view limit
+func (s *shareLinks) IncrementViewCount(ctx context.Context, params models.ShareLinkParams, now int64) error {
+	res := s.db.ORM.WithContext(ctx).
+		Model(models.ShareLinks{}).
+		Where(params).
+		Where("is_revoked = ?", false).
+		Where("expires_at IS NULL OR expires_at > ?", now).
+		Where("max_views IS NULL OR view_count < max_views").
+		UpdateColumn("view_count", gorm.Expr("view_count + 1"))
+	if res.Error != nil {
+		return res.Error
+	} else if res.RowsAffected == 0 {
+		return errors.Gone("Share link is no longer available")
+	}
+
+	return nil
+}
+
+// ReservePasswordAttempt counts a password attempt before the password is compared, so concurrent guesses can not
+// get past the limit. The link is locked for lockoutSec by the attempt that reaches maxAttempts, and again by every
+// attempt after the lockout until a right password resets the count.
+func (s *shareLinks) ReservePasswordAttempt(ctx context.Context, params models.ShareLinkParams, now int64, maxAttempts int64, lockoutSec int64) error {
+	res := s.db.ORM.WithContext(ctx).
+		Model(models.ShareLinks{}).
+		Where(params).
+		Where("locked_until IS NULL OR locked_until <= ?", now).
+		UpdateColumns(map[string]interface{}{
+			"password_attempts": gorm.Expr("password_attempts + 1"),
+			"locked_until":      gorm.Expr("CASE WHEN password_attempts + 1 >= ? THEN ? ELSE NULL END", maxAttempts, now+lockoutSec),
+		})
+	if res.Error != nil {
+		return res.Error
+	} else if res.RowsAffected == 0 {
+		return errors.TooManyRequests("Too many wrong share link passwords, try again later")
+	}
+
+	return nil
+}
+
+func (s *shareLinks) ResetPasswordAttempts(ctx context.Context, params models.ShareLinkParams) error {
+	return s.db.ORM.WithContext(ctx).
+		Model(models.ShareLinks{}).
+		Where(params).
+		UpdateColumns(map[string]interface{}{"password_attempts": 0, "locked_until": nil}).Error
+}
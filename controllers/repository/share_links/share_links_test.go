@@ -0,0 +1,90 @@
+package share_links
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"rakamin-final-task/database"
+	"rakamin-final-task/helpers/errors"
+	"rakamin-final-task/models"
+)
+
+const reserveQuery = `UPDATE "share_links" SET "locked_until"=CASE WHEN password_attempts + 1 >= $1 THEN $2 ELSE NULL END,"password_attempts"=password_attempts + 1 WHERE "share_links"."token" = $3 AND (locked_until IS NULL OR locked_until <= $4) AND "share_links"."deleted_at" IS NULL`
+
+// mockDB runs the repository against a mocked connection, so the statements of a transaction are checked
+// without a database
+func mockDB(t *testing.T) (*database.DB, sqlmock.Sqlmock) {
+	t.Helper()
+
+	conn, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	orm, err := gorm.Open(postgres.New(postgres.Config{Conn: conn}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &database.DB{ORM: orm}, mock
+}
+
+func TestReservePasswordAttempt(t *testing.T) {
+	const (
+		now         = int64(1700000000)
+		maxAttempts = int64(5)
+		lockoutSec  = int64(900)
+	)
+
+	tests := []struct {
+		name     string
+		isLocked bool
+		wantCode int64
+	}{
+		{
+			name: "attempt counted on an unlocked link",
+		},
+		{
+			name:     "locked link refuses the attempt",
+			isLocked: true,
+			wantCode: http.StatusTooManyRequests,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := mockDB(t)
+
+			// The update only matches an unlocked link, so a locked one is left as it is
+			rowsAffected := int64(1)
+			if tt.isLocked {
+				rowsAffected = 0
+			}
+
+			mock.ExpectBegin()
+			mock.ExpectExec(reserveQuery).
+				WithArgs(maxAttempts, now+lockoutSec, "token", now).
+				WillReturnResult(sqlmock.NewResult(0, rowsAffected))
+			mock.ExpectCommit()
+
+			err := Init(db).ReservePasswordAttempt(context.Background(), models.ShareLinkParams{Token: "token"}, now, maxAttempts, lockoutSec)
+			if tt.wantCode != 0 {
+				if errors.GetCode(err) != tt.wantCode {
+					t.Errorf("ReservePasswordAttempt() error = %v, want a %d", err, tt.wantCode)
+				}
+			} else if err != nil {
+				t.Errorf("ReservePasswordAttempt() error = %v", err)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
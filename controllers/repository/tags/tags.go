@@ -0,0 +1,106 @@
+package tags
+
+import (
+	"context"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"rakamin-final-task/database"
+	"rakamin-final-task/models"
+)
+
+type Interface interface {
+	Upsert(ctx context.Context, params models.TagParams) ([]models.Tags, error)
+	GetList(ctx context.Context, params models.TagParams) ([]models.Tags, error)
+	Suggest(ctx context.Context, params models.TagParams) ([]models.Tags, error)
+}
+
+type tags struct {
+	db *database.DB
+}
+
+func Init(db *database.DB) Interface {
+	return &tags{
+		db: db,
+	}
+}
+
+func (t *tags) Upsert(ctx context.Context, params models.TagParams) ([]models.Tags, error) {
+	return Upsert(t.db.ORM.WithContext(ctx), params)
+}
+
+// Upsert inserts the missing tags of the user within the given transaction and returns all of them, so the tags of a
+// photo are only created along with it
+func Upsert(tx *gorm.DB, params models.TagParams) ([]models.Tags, error) {
+	var tags []models.Tags
+
+	if len(params.Names) == 0 {
+		return tags, nil
+	}
+
+	newTags := make([]models.Tags, 0, len(params.Names))
+	for _, name := range params.Names {
+		newTags = append(newTags, models.Tags{
+			Name:      name,
+			UserID:    params.UserID,
+			CreatedBy: &params.UserID,
+			UpdatedBy: &params.UserID,
+		})
+	}
+
+	// Existing tags are kept as they are, only the missing ones are inserted
+	err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&newTags).Error
+	if err != nil {
+		return tags, err
+	}
+
+	err = tx.Where("user_id = ? AND name IN ?", params.UserID, params.Names).Find(&tags).Error
+	if err != nil {
+		return tags, err
+	}
+
+	return tags, nil
+}
+
+func (t *tags) GetList(ctx context.Context, params models.TagParams) ([]models.Tags, error) {
+	var tags []models.Tags
+
+	err := t.withUsageCount(ctx, params).Order("usage_count DESC, tags.name ASC").Find(&tags).Error
+	if err != nil {
+		return tags, err
+	}
+
+	return tags, nil
+}
+
+func (t *tags) Suggest(ctx context.Context, params models.TagParams) ([]models.Tags, error) {
+	var tags []models.Tags
+
+	err := t.withUsageCount(ctx, params).
+		Where("tags.name LIKE ?", escapeLike(params.Prefix)+"%").
+		Having("COUNT(photos.id) > 0").
+		Order("usage_count DESC, tags.name ASC").
+		Limit(int(params.Limit)).
+		Find(&tags).Error
+	if err != nil {
+		return tags, err
+	}
+
+	return tags, nil
+}
+
+// withUsageCount counts the photos of every tag, deleted photos are not counted
+func (t *tags) withUsageCount(ctx context.Context, params models.TagParams) *gorm.DB {
+	return t.db.ORM.WithContext(ctx).
+		Model(&models.Tags{}).
+		Select("tags.*, COUNT(photos.id) AS usage_count").
+		Joins("LEFT JOIN photo_tags ON photo_tags.tag_id = tags.id").
+		Joins("LEFT JOIN photos ON photos.id = photo_tags.photo_id AND photos.deleted_at IS NULL").
+		Where("tags.user_id = ?", params.UserID).
+		Group("tags.id")
+}
+
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
@@ -0,0 +1,111 @@
+package tus_uploads
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	photoJobRepo "rakamin-final-task/controllers/repository/photo_jobs"
+	photoRepo "rakamin-final-task/controllers/repository/photos"
+	"rakamin-final-task/database"
+	"rakamin-final-task/helpers/errors"
+	"rakamin-final-task/models"
+)
+
+type Interface interface {
+	Create(ctx context.Context, tusUpload models.TusUploads) (models.TusUploads, error)
+	Get(ctx context.Context, params models.TusUploadParams) (models.TusUploads, error)
+	UpdateOffset(ctx context.Context, params models.TusUploadParams, from int64, to int64) error
+	Complete(ctx context.Context, params models.TusUploadParams, photo *models.Photos) error
+	GetExpired(ctx context.Context, now int64, limit int) ([]models.TusUploads, error)
+	Delete(ctx context.Context, ids []int64) error
+}
+
+type tusUploads struct {
+	db *database.DB
+}
+
+func Init(db *database.DB) Interface {
+	return &tusUploads{
+		db: db,
+	}
+}
+
+func (t *tusUploads) Create(ctx context.Context, tusUpload models.TusUploads) (models.TusUploads, error) {
+	if err := t.db.ORM.WithContext(ctx).Create(&tusUpload).Error; err != nil {
+		return tusUpload, err
+	}
+
+	return tusUpload, nil
+}
+
+func (t *tusUploads) Get(ctx context.Context, params models.TusUploadParams) (models.TusUploads, error) {
+	var tusUpload models.TusUploads
+
+	res := t.db.ORM.WithContext(ctx).Where(params).First(&tusUpload)
+	if res.RowsAffected == 0 {
+		return tusUpload, errors.NotFound("Upload not found")
+	} else if res.Error != nil {
+		return tusUpload, res.Error
+	}
+
+	return tusUpload, nil
+}
+
+// UpdateOffset moves the offset only if it has not moved since it was read
+func (t *tusUploads) UpdateOffset(ctx context.Context, params models.TusUploadParams, from int64, to int64) error {
+	res := t.db.ORM.WithContext(ctx).Model(&models.TusUploads{}).
+		Where("id = ? AND user_id = ? AND \"offset\" = ?", params.ID, params.UserID, from).
+		Updates(map[string]interface{}{"offset": to, "updated_by": params.UserID})
+	if res.Error != nil {
+		return res.Error
+	} else if res.RowsAffected == 0 {
+		return errors.Conflict("Upload offset has been changed by another request")
+	}
+
+	return nil
+}
+
+// Complete creates the photo of an upload whose last byte has been received along with the jobs processing its file
+// in a single transaction, an upload can only be completed once
+func (t *tusUploads) Complete(ctx context.Context, params models.TusUploadParams, photo *models.Photos) error {
+	return t.db.ORM.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := photoRepo.Insert(tx, photo); err != nil {
+			return err
+		}
+
+		res := tx.Model(&models.TusUploads{}).
+			Where("id = ? AND user_id = ? AND photo_id IS NULL", params.ID, params.UserID).
+			Updates(map[string]interface{}{"photo_id": photo.ID, "updated_by": params.UserID})
+		if res.Error != nil {
+			return res.Error
+		} else if res.RowsAffected == 0 {
+			return errors.Conflict("Upload has already been completed")
+		}
+
+		return photoJobRepo.Enqueue(tx, photo.ID, models.PhotoJobKinds)
+	})
+}
+
+// GetExpired returns the oldest uploads that expired before being completed
+func (t *tusUploads) GetExpired(ctx context.Context, now int64, limit int) ([]models.TusUploads, error) {
+	var tusUploads []models.TusUploads
+
+	res := t.db.ORM.WithContext(ctx).
+		Where("photo_id IS NULL AND expires_at <= ?", now).
+		Order("expires_at ASC").
+		Limit(limit).
+		Find(&tusUploads)
+	if res.Error != nil {
+		return tusUploads, res.Error
+	}
+
+	return tusUploads, nil
+}
+
+func (t *tusUploads) Delete(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	return t.db.ORM.WithContext(ctx).Where("id IN ?", ids).Delete(&models.TusUploads{}).Error
+}
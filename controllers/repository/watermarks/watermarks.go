@@ -0,0 +1,153 @@
+package watermarks
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm/clause"
+	"rakamin-final-task/database"
+	"rakamin-final-task/helpers/errors"
+	"rakamin-final-task/models"
+)
+
+type Interface interface {
+	Get(ctx context.Context, userID int64) (models.Watermarks, error)
+	Save(ctx context.Context, watermark models.Watermarks, previousVersion int64) (models.Watermarks, error)
+	GetPhoto(ctx context.Context, photoID int64) (models.PhotoWatermarks, error)
+	GetListPhoto(ctx context.Context, photoIDs []int64) ([]models.PhotoWatermarks, error)
+	SavePhoto(ctx context.Context, photoWatermark models.PhotoWatermarks) error
+	DeletePhoto(ctx context.Context, photoID int64) error
+	GetListOutdated(ctx context.Context, afterID int64, limit int) ([]models.Photos, error)
+	GetListDisabled(ctx context.Context, limit int) ([]models.PhotoWatermarks, error)
+}
+
+type watermarks struct {
+	db *database.DB
+}
+
+func Init(db *database.DB) Interface {
+	return &watermarks{
+		db: db,
+	}
+}
+
+func (w *watermarks) Get(ctx context.Context, userID int64) (models.Watermarks, error) {
+	var watermark models.Watermarks
+
+	res := w.db.ORM.WithContext(ctx).Where("user_id = ?", userID).Limit(1).Find(&watermark)
+	if res.Error != nil {
+		return watermark, res.Error
+	} else if res.RowsAffected == 0 {
+		return watermark, errors.NotFound("Watermark not found")
+	}
+
+	return watermark, nil
+}
+
+// Save stores the settings only if they have not been saved by another request since the previous version was read,
+// the settings of a user without previous version are created
+func (w *watermarks) Save(ctx context.Context, watermark models.Watermarks, previousVersion int64) (models.Watermarks, error) {
+	if previousVersion == 0 {
+		res := w.db.ORM.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&watermark)
+		if res.Error != nil {
+			return watermark, res.Error
+		} else if res.RowsAffected == 0 {
+			return watermark, errors.Conflict("Watermark has been changed by another request")
+		}
+
+		return watermark, nil
+	}
+
+	watermark.UpdatedAt = time.Now().Unix()
+	res := w.db.ORM.WithContext(ctx).Model(&models.Watermarks{}).
+		Where("user_id = ? AND version = ?", watermark.UserID, previousVersion).
+		Updates(map[string]interface{}{
+			"enabled":    watermark.Enabled,
+			"type":       watermark.Type,
+			"text":       watermark.Text,
+			"logo_url":   watermark.LogoURL,
+			"position":   watermark.Position,
+			"opacity":    watermark.Opacity,
+			"version":    watermark.Version,
+			"updated_at": watermark.UpdatedAt,
+		})
+	if res.Error != nil {
+		return watermark, res.Error
+	} else if res.RowsAffected == 0 {
+		return watermark, errors.Conflict("Watermark has been changed by another request")
+	}
+
+	return watermark, nil
+}
+
+func (w *watermarks) GetPhoto(ctx context.Context, photoID int64) (models.PhotoWatermarks, error) {
+	var photoWatermark models.PhotoWatermarks
+
+	res := w.db.ORM.WithContext(ctx).Where("photo_id = ?", photoID).Limit(1).Find(&photoWatermark)
+	if res.Error != nil {
+		return photoWatermark, res.Error
+	} else if res.RowsAffected == 0 {
+		return photoWatermark, errors.NotFound("Photo watermark not found")
+	}
+
+	return photoWatermark, nil
+}
+
+func (w *watermarks) GetListPhoto(ctx context.Context, photoIDs []int64) ([]models.PhotoWatermarks, error) {
+	var photoWatermarks []models.PhotoWatermarks
+
+	res := w.db.ORM.WithContext(ctx).Where("photo_id IN ?", photoIDs).Find(&photoWatermarks)
+	if res.Error != nil {
+		return photoWatermarks, res.Error
+	}
+
+	return photoWatermarks, nil
+}
+
+// SavePhoto creates the watermarked copy of the photo or replaces the previous one
+func (w *watermarks) SavePhoto(ctx context.Context, photoWatermark models.PhotoWatermarks) error {
+	return w.db.ORM.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "photo_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"file_name", "source_url", "version", "updated_at"}),
+	}).Create(&photoWatermark).Error
+}
+
+func (w *watermarks) DeletePhoto(ctx context.Context, photoID int64) error {
+	return w.db.ORM.WithContext(ctx).Where("photo_id = ?", photoID).Delete(&models.PhotoWatermarks{}).Error
+}
+
+// GetListOutdated returns the photos of the users with an enabled watermark that can be served to other users, the
+// ones that are not private or are shared through a link, that have no watermarked copy yet or a copy rendered from
+// another file or another version of the settings
+func (w *watermarks) GetListOutdated(ctx context.Context, afterID int64, limit int) ([]models.Photos, error) {
+	var photos []models.Photos
+
+	res := w.db.ORM.WithContext(ctx).Model(&models.Photos{}).Select("photos.*").
+		Joins("JOIN watermarks ON watermarks.user_id = photos.user_id AND watermarks.enabled").
+		Joins("LEFT JOIN photo_watermarks ON photo_watermarks.photo_id = photos.id").
+		Where("photos.visibility <> ? OR photos.id IN (?)", models.VisibilityPrivate,
+			w.db.ORM.Model(&models.ShareLinks{}).Select("photo_id").Where("is_revoked IS NOT TRUE")).
+		Where("photo_watermarks.photo_id IS NULL OR photo_watermarks.version <> watermarks.version OR photo_watermarks.source_url <> photos.photo_url").
+		Where("photos.id > ?", afterID).Order("photos.id ASC").Limit(limit).Find(&photos)
+	if res.Error != nil {
+		return photos, res.Error
+	}
+
+	return photos, nil
+}
+
+// GetListDisabled returns the watermarked copies of the photos whose owner has disabled the watermark
+func (w *watermarks) GetListDisabled(ctx context.Context, limit int) ([]models.PhotoWatermarks, error) {
+	var photoWatermarks []models.PhotoWatermarks
+
+	res := w.db.ORM.WithContext(ctx).Model(&models.PhotoWatermarks{}).Select("photo_watermarks.*").
+		Joins("JOIN photos ON photos.id = photo_watermarks.photo_id").
+		Joins("LEFT JOIN watermarks ON watermarks.user_id = photos.user_id AND watermarks.enabled").
+		Where("watermarks.user_id IS NULL").
+		Order("photo_watermarks.photo_id ASC").Limit(limit).Find(&photoWatermarks)
+	if res.Error != nil {
+		return photoWatermarks, res.Error
+	}
+
+	return photoWatermarks, nil
+}
@@ -0,0 +1,237 @@
+package engagements
+
+import (
+	"context"
+
+	commentRepo "rakamin-final-task/controllers/repository/comments"
+	likeRepo "rakamin-final-task/controllers/repository/likes"
+	photoRepo "rakamin-final-task/controllers/repository/photos"
+	photoUsecase "rakamin-final-task/controllers/usecase/photos"
+	"rakamin-final-task/helpers/appcontext"
+	"rakamin-final-task/helpers/errors"
+	"rakamin-final-task/helpers/response"
+	"rakamin-final-task/helpers/validator"
+	"rakamin-final-task/models"
+)
+
+type Interface interface {
+	Like(ctx context.Context, param models.PhotoLikeParams) (models.Photos, error)
+	Unlike(ctx context.Context, param models.PhotoLikeParams) (models.Photos, error)
+	CreateComment(ctx context.Context, param models.CommentParams, body models.CreateCommentParams) (models.Comments, error)
+	GetListComment(ctx context.Context, param models.CommentParams) ([]models.Comments, *response.PaginationParam, error)
+	UpdateComment(ctx context.Context, param models.CommentParams, body models.UpdateCommentParams) (models.Comments, error)
+	DeleteComment(ctx context.Context, param models.CommentParams) error
+}
+
+type engagements struct {
+	photo     photoRepo.Interface
+	like      likeRepo.Interface
+	comment   commentRepo.Interface
+	photos    photoUsecase.Interface
+	validator validator.Interface
+}
+
+type InitParam struct {
+	PhotoRepo    photoRepo.Interface
+	LikeRepo     likeRepo.Interface
+	CommentRepo  commentRepo.Interface
+	PhotoUsecase photoUsecase.Interface
+	Validator    validator.Interface
+}
+
+func Init(param InitParam) Interface {
+	return &engagements{
+		photo:     param.PhotoRepo,
+		like:      param.LikeRepo,
+		comment:   param.CommentRepo,
+		photos:    param.PhotoUsecase,
+		validator: param.Validator,
+	}
+}
+
+func (e *engagements) Like(ctx context.Context, param models.PhotoLikeParams) (models.Photos, error) {
+	userID := appcontext.GetUserID(ctx)
+
+	photo, err := e.getVisiblePhoto(ctx, param.PhotoID, userID)
+	if err != nil {
+		return photo, err
+	}
+
+	like := models.PhotoLikes{
+		PhotoID: photo.ID,
+		UserID:  userID,
+	}
+	if err := e.like.Create(ctx, like); err != nil {
+		return photo, err
+	}
+
+	return e.getSignedPhoto(ctx, photo.ID, userID)
+}
+
+func (e *engagements) Unlike(ctx context.Context, param models.PhotoLikeParams) (models.Photos, error) {
+	userID := appcontext.GetUserID(ctx)
+
+	photo, err := e.getVisiblePhoto(ctx, param.PhotoID, userID)
+	if err != nil {
+		return photo, err
+	}
+
+	likeParam := models.PhotoLikeParams{
+		PhotoID: photo.ID,
+		UserID:  userID,
+	}
+	if err := e.like.Delete(ctx, likeParam); err != nil {
+		return photo, err
+	}
+
+	return e.getSignedPhoto(ctx, photo.ID, userID)
+}
+
+func (e *engagements) CreateComment(ctx context.Context, param models.CommentParams, body models.CreateCommentParams) (models.Comments, error) {
+	var comment models.Comments
+
+	if err := e.validator.ValidateStruct(body); err != nil {
+		validationErr, _ := e.validator.GetValidationErrors(err)
+		return comment, errors.ValidationError(validationErr)
+	}
+
+	userID := appcontext.GetUserID(ctx)
+
+	photo, err := e.getVisiblePhoto(ctx, param.PhotoID, userID)
+	if err != nil {
+		return comment, err
+	}
+
+	if photo.IsCommentDisabled != nil && *photo.IsCommentDisabled {
+		return comment, errors.Forbidden("Comments are disabled on this photo")
+	}
+
+	comment = models.Comments{
+		PhotoID:   photo.ID,
+		UserID:    userID,
+		Content:   body.Content,
+		CreatedBy: &userID,
+	}
+
+	// Replies have to belong to the same photo as their parent
+	if body.ParentID != 0 {
+		parentParam := models.CommentParams{
+			ID:      body.ParentID,
+			PhotoID: photo.ID,
+		}
+		if _, err := e.comment.Get(ctx, parentParam); err != nil {
+			return comment, errors.NotFound("Parent comment not found")
+		}
+
+		comment.ParentID = &body.ParentID
+	}
+
+	return e.comment.Create(ctx, comment)
+}
+
+func (e *engagements) GetListComment(ctx context.Context, param models.CommentParams) ([]models.Comments, *response.PaginationParam, error) {
+	photo, err := e.getVisiblePhoto(ctx, param.PhotoID, appcontext.GetUserID(ctx))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	commentParam := models.CommentParams{
+		PhotoID:         photo.ID,
+		ParentID:        param.ParentID,
+		ListFilter:      param.ListFilter,
+		PaginationParam: param.PaginationParam,
+	}
+
+	return e.comment.GetList(ctx, commentParam)
+}
+
+func (e *engagements) UpdateComment(ctx context.Context, param models.CommentParams, body models.UpdateCommentParams) (models.Comments, error) {
+	var comment models.Comments
+
+	if err := e.validator.ValidateStruct(body); err != nil {
+		validationErr, _ := e.validator.GetValidationErrors(err)
+		return comment, errors.ValidationError(validationErr)
+	}
+
+	userID := appcontext.GetUserID(ctx)
+
+	photo, err := e.getVisiblePhoto(ctx, param.PhotoID, userID)
+	if err != nil {
+		return comment, err
+	}
+
+	// Only the author can edit a comment
+	commentParam := models.CommentParams{
+		ID:      param.ID,
+		PhotoID: photo.ID,
+		UserID:  userID,
+	}
+
+	commentField := models.Comments{
+		Content:   body.Content,
+		UpdatedBy: &userID,
+	}
+
+	if _, err := e.comment.Update(ctx, commentField, commentParam); err != nil {
+		return comment, err
+	}
+
+	return e.comment.Get(ctx, commentParam)
+}
+
+// DeleteComment deletes the comment along with its replies, so no reply is left under a deleted comment
+func (e *engagements) DeleteComment(ctx context.Context, param models.CommentParams) error {
+	userID := appcontext.GetUserID(ctx)
+
+	photo, err := e.getVisiblePhoto(ctx, param.PhotoID, userID)
+	if err != nil {
+		return err
+	}
+
+	commentParam := models.CommentParams{
+		ID:      param.ID,
+		PhotoID: photo.ID,
+	}
+
+	comment, err := e.comment.Get(ctx, commentParam)
+	if err != nil {
+		return err
+	}
+
+	// The photo owner can remove any comment on their photo
+	if comment.UserID != userID && photo.UserID != userID {
+		return errors.Forbidden("You are not allowed to delete this comment")
+	}
+
+	comment.DeletedBy = &userID
+
+	return e.comment.Delete(ctx, comment)
+}
+
+// getSignedPhoto returns the photo with its counters up to date and its URL signed for the user
+func (e *engagements) getSignedPhoto(ctx context.Context, photoID int64, userID int64) (models.Photos, error) {
+	photo, err := e.photo.Get(ctx, models.PhotoParams{ID: photoID})
+	if err != nil {
+		return photo, err
+	}
+
+	if err := e.photos.SignPhoto(ctx, &photo, userID); err != nil {
+		return photo, err
+	}
+
+	return photo, nil
+}
+
+// getVisiblePhoto returns the photo when the user can see it, hidden photos are reported as not found
+func (e *engagements) getVisiblePhoto(ctx context.Context, photoID int64, userID int64) (models.Photos, error) {
+	photo, err := e.photo.Get(ctx, models.PhotoParams{ID: photoID})
+	if err != nil {
+		return photo, err
+	}
+
+	if !photo.IsVisibleTo(userID) {
+		return models.Photos{}, errors.NotFound("Photo not found")
+	}
+
+	return photo, nil
+}
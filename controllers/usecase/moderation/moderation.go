@@ -0,0 +1,159 @@
+package moderation
+
+import (
+	"context"
+
+	"rakamin-final-task/config"
+	photoModerationRepo "rakamin-final-task/controllers/repository/photo_moderations"
+	photoRepo "rakamin-final-task/controllers/repository/photos"
+	reportRepo "rakamin-final-task/controllers/repository/reports"
+	userRepo "rakamin-final-task/controllers/repository/users"
+	"rakamin-final-task/helpers/appcontext"
+	"rakamin-final-task/helpers/errors"
+	"rakamin-final-task/helpers/files"
+	"rakamin-final-task/helpers/response"
+	"rakamin-final-task/helpers/storage"
+	"rakamin-final-task/helpers/validator"
+	"rakamin-final-task/models"
+)
+
+type Interface interface {
+	GetListQueue(ctx context.Context, param models.ModerationParams) ([]models.PhotoModerations, *response.PaginationParam, error)
+	Approve(ctx context.Context, param models.ModerationParams, body models.ReviewModerationParams) (models.PhotoModerations, error)
+	Reject(ctx context.Context, param models.ModerationParams, body models.ReviewModerationParams) (models.PhotoModerations, error)
+	ReportPhoto(ctx context.Context, param models.PhotoParams, body models.CreateReportParams) (models.Reports, error)
+	ReportUser(ctx context.Context, param models.UserParams, body models.CreateReportParams) (models.Reports, error)
+	GetListReport(ctx context.Context, param models.ReportParams) ([]models.Reports, *response.PaginationParam, error)
+	ResolveReport(ctx context.Context, param models.ReportParams, body models.ResolveReportParams) (models.Reports, error)
+}
+
+const (
+	photoPath = "photos"
+)
+
+type moderation struct {
+	photoModeration photoModerationRepo.Interface
+	photo           photoRepo.Interface
+	user            userRepo.Interface
+	report          reportRepo.Interface
+	config          config.Moderation
+	storage         storage.Interface
+	validator       validator.Interface
+}
+
+type InitParam struct {
+	PhotoModerationRepo photoModerationRepo.Interface
+	PhotoRepo           photoRepo.Interface
+	UserRepo            userRepo.Interface
+	ReportRepo          reportRepo.Interface
+	Config              config.Moderation
+	Storage             storage.Interface
+	Validator           validator.Interface
+}
+
+func Init(param InitParam) Interface {
+	return &moderation{
+		photoModeration: param.PhotoModerationRepo,
+		photo:           param.PhotoRepo,
+		user:            param.UserRepo,
+		report:          param.ReportRepo,
+		config:          param.Config,
+		storage:         param.Storage,
+		validator:       param.Validator,
+	}
+}
+
+// GetListQueue returns the pending flags, oldest first, along with their photos so a moderator can see them.
+// The reviewed flags are listed by their status.
+func (m *moderation) GetListQueue(ctx context.Context, param models.ModerationParams) ([]models.PhotoModerations, *response.PaginationParam, error) {
+	if err := m.validator.ValidateStruct(param); err != nil {
+		validationErr, _ := m.validator.GetValidationErrors(err)
+		return nil, nil, errors.ValidationError(validationErr)
+	}
+
+	moderationParam := models.ModerationParams{
+		Status:          param.Status,
+		ListFilter:      param.ListFilter,
+		PaginationParam: param.PaginationParam,
+	}
+	if moderationParam.Status == "" {
+		moderationParam.Status = models.ModerationStatusPending
+	}
+
+	moderations, pg, err := m.photoModeration.GetList(ctx, moderationParam)
+	if err != nil {
+		return moderations, pg, err
+	}
+
+	for i := range moderations {
+		if err := m.signPhoto(ctx, moderations[i].Photo); err != nil {
+			return moderations, pg, err
+		}
+	}
+
+	return moderations, pg, nil
+}
+
+// Approve publishes the flagged photo again once none of its flags is pending
+func (m *moderation) Approve(ctx context.Context, param models.ModerationParams, body models.ReviewModerationParams) (models.PhotoModerations, error) {
+	return m.review(ctx, param, body, models.ModerationStatusApproved)
+}
+
+// Reject hides the flagged photo for good, from everyone but its owner
+func (m *moderation) Reject(ctx context.Context, param models.ModerationParams, body models.ReviewModerationParams) (models.PhotoModerations, error) {
+	return m.review(ctx, param, body, models.ModerationStatusRejected)
+}
+
+func (m *moderation) review(ctx context.Context, param models.ModerationParams, body models.ReviewModerationParams, status string) (models.PhotoModerations, error) {
+	var moderation models.PhotoModerations
+
+	if err := m.validator.ValidateStruct(body); err != nil {
+		validationErr, _ := m.validator.GetValidationErrors(err)
+		return moderation, errors.ValidationError(validationErr)
+	}
+
+	moderationParam := models.ModerationParams{
+		ID: param.ID,
+	}
+
+	moderation, err := m.photoModeration.Get(ctx, moderationParam)
+	if err != nil {
+		return moderation, err
+	}
+
+	userID := appcontext.GetUserID(ctx)
+
+	moderation.Status = status
+	moderation.ReviewedBy = &userID
+	moderation.Note = body.Note
+
+	if err := m.photoModeration.Review(ctx, moderation); err != nil {
+		return moderation, err
+	}
+
+	moderation, err = m.photoModeration.Get(ctx, moderationParam)
+	if err != nil {
+		return moderation, err
+	}
+
+	if err := m.signPhoto(ctx, moderation.Photo); err != nil {
+		return moderation, err
+	}
+
+	return moderation, nil
+}
+
+// signPhoto signs the URL of the photo file for the moderator, the photo is nil once it has been deleted
+func (m *moderation) signPhoto(ctx context.Context, photo *models.Photos) error {
+	if photo == nil {
+		return nil
+	}
+
+	signedURL, err := m.storage.SignedURL(ctx, files.GetFileNameFromURL(photo.PhotoURL), photoPath)
+	if err != nil {
+		return err
+	}
+	photo.PhotoURL = signedURL
+
+	return nil
+}
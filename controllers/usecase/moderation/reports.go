@@ -0,0 +1,154 @@
+package moderation
+
+import (
+	"context"
+	"net/http"
+
+	"rakamin-final-task/helpers/appcontext"
+	"rakamin-final-task/helpers/errors"
+	"rakamin-final-task/helpers/response"
+	"rakamin-final-task/models"
+)
+
+// ReportPhoto reports a photo the user can see, the photo is hidden until a moderator resolves the reports once
+// enough users have reported it
+func (m *moderation) ReportPhoto(ctx context.Context, param models.PhotoParams, body models.CreateReportParams) (models.Reports, error) {
+	var report models.Reports
+
+	if err := m.validator.ValidateStruct(body); err != nil {
+		validationErr, _ := m.validator.GetValidationErrors(err)
+		return report, errors.ValidationError(validationErr)
+	}
+
+	userID := appcontext.GetUserID(ctx)
+
+	photo, err := m.photo.Get(ctx, models.PhotoParams{ID: param.ID})
+	if err != nil {
+		return report, err
+	}
+
+	if !photo.IsVisibleTo(userID) {
+		return report, errors.NotFound("Photo not found")
+	} else if photo.UserID == userID {
+		return report, errors.BadRequest("You can not report your own photo")
+	}
+
+	report = models.Reports{
+		ReporterID: userID,
+		PhotoID:    &photo.ID,
+		Reason:     body.Reason,
+		Details:    body.Details,
+	}
+
+	return m.report.Create(ctx, report, m.config.ReportHideThreshold)
+}
+
+// ReportUser reports another user, the photos of the user are hidden until a moderator resolves the reports once
+// enough users have reported them
+func (m *moderation) ReportUser(ctx context.Context, param models.UserParams, body models.CreateReportParams) (models.Reports, error) {
+	var report models.Reports
+
+	if err := m.validator.ValidateStruct(body); err != nil {
+		validationErr, _ := m.validator.GetValidationErrors(err)
+		return report, errors.ValidationError(validationErr)
+	}
+
+	userID := appcontext.GetUserID(ctx)
+
+	user, err := m.user.Get(ctx, models.UserParams{ID: param.ID})
+	if errors.GetCode(err) == http.StatusNotFound {
+		return report, errors.NotFound("User not found")
+	} else if err != nil {
+		return report, err
+	}
+
+	if user.ID == userID {
+		return report, errors.BadRequest("You can not report yourself")
+	}
+
+	report = models.Reports{
+		ReporterID:     userID,
+		ReportedUserID: &user.ID,
+		Reason:         body.Reason,
+		Details:        body.Details,
+	}
+
+	return m.report.Create(ctx, report, m.config.ReportHideThreshold)
+}
+
+// GetListReport returns the pending reports, oldest first, along with their photo or user so a moderator can see
+// them. The resolved reports are listed by their status.
+func (m *moderation) GetListReport(ctx context.Context, param models.ReportParams) ([]models.Reports, *response.PaginationParam, error) {
+	if err := m.validator.ValidateStruct(param); err != nil {
+		validationErr, _ := m.validator.GetValidationErrors(err)
+		return nil, nil, errors.ValidationError(validationErr)
+	}
+
+	reportParam := models.ReportParams{
+		Status:          param.Status,
+		Target:          param.Target,
+		ListFilter:      param.ListFilter,
+		PaginationParam: param.PaginationParam,
+	}
+	if reportParam.Status == "" {
+		reportParam.Status = models.ReportStatusPending
+	}
+
+	reports, pg, err := m.report.GetList(ctx, reportParam)
+	if err != nil {
+		return reports, pg, err
+	}
+
+	for i := range reports {
+		if err := m.signPhoto(ctx, reports[i].Photo); err != nil {
+			return reports, pg, err
+		}
+	}
+
+	return reports, pg, nil
+}
+
+// ResolveReport resolves the report along with the other pending reports on the same target. A dismissal shows the
+// target again, an upheld report rejects the photo or keeps the user hidden.
+func (m *moderation) ResolveReport(ctx context.Context, param models.ReportParams, body models.ResolveReportParams) (models.Reports, error) {
+	var report models.Reports
+
+	if err := m.validator.ValidateStruct(body); err != nil {
+		validationErr, _ := m.validator.GetValidationErrors(err)
+		return report, errors.ValidationError(validationErr)
+	}
+
+	reportParam := models.ReportParams{
+		ID: param.ID,
+	}
+
+	report, err := m.report.Get(ctx, reportParam)
+	if err != nil {
+		return report, err
+	}
+
+	if report.Status != models.ReportStatusPending {
+		return report, errors.Conflict("Report has already been resolved")
+	}
+
+	userID := appcontext.GetUserID(ctx)
+
+	report.Outcome = body.Outcome
+	report.ResolvedBy = &userID
+	report.Note = body.Note
+
+	if err := m.report.Resolve(ctx, report); err != nil {
+		return report, err
+	}
+
+	report, err = m.report.Get(ctx, reportParam)
+	if err != nil {
+		return report, err
+	}
+
+	if err := m.signPhoto(ctx, report.Photo); err != nil {
+		return report, err
+	}
+
+	return report, nil
+}
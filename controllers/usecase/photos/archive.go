@@ -0,0 +1,161 @@
+package photos
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"rakamin-final-task/helpers/appcontext"
+	"rakamin-final-task/helpers/errors"
+	"rakamin-final-task/helpers/files"
+	"rakamin-final-task/models"
+)
+
+const (
+	maxArchivePhotos       = 500
+	maxArchiveFileNameSize = 100
+
+	// archiveErrorsFileName lists the photos left out of an archive or cut short, the photo entries are images
+	archiveErrorsFileName = "errors.txt"
+)
+
+var unsafeFileNameChars = regexp.MustCompile(`[^\pL\pN._ -]+`)
+
+// GetListArchive returns the photos of the user to put in an archive, the photos that are not found are left out.
+// An archive is always made of selected photos, the photos are not grouped in albums that could be downloaded whole.
+func (p *photos) GetListArchive(ctx context.Context, param models.PhotoArchiveParams) ([]models.Photos, error) {
+	ids := []int64{}
+	for _, values := range param.IDs {
+		for _, value := range strings.Split(values, ",") {
+			value = strings.TrimSpace(value)
+			if value == "" {
+				continue
+			}
+
+			id, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return nil, errors.BadRequest("Invalid ids, use a comma separated list of photo IDs")
+			}
+
+			ids = append(ids, id)
+		}
+	}
+
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+
+	switch {
+	case len(ids) == 0:
+		return nil, errors.BadRequest("Photo IDs are required")
+	case len(ids) > maxArchivePhotos:
+		return nil, errors.BadRequest(fmt.Sprintf("An archive can not have more than %d photos", maxArchivePhotos))
+	}
+
+	photos, err := p.photo.GetListByIDs(ctx, appcontext.GetUserID(ctx), ids)
+	if err != nil {
+		return photos, err
+	}
+
+	if len(photos) == 0 {
+		return photos, errors.NotFound("Photo not found")
+	}
+
+	return photos, nil
+}
+
+// WriteArchive streams a ZIP of the photos to w, one object at a time, so the archive is never held in memory.
+// The photos are stored without compression since images are already compressed. A photo whose file can not be
+// read is left out, or cut short when the read fails midway, and listed in an entry at the end of the archive so a
+// partial archive is not mistaken for a complete one.
+func (p *photos) WriteArchive(ctx context.Context, photos []models.Photos, w io.Writer) error {
+	archive := zip.NewWriter(w)
+
+	failures := []string{}
+	usedNames := map[string]bool{}
+	for _, photo := range photos {
+		header := &zip.FileHeader{
+			Name:     archiveFileName(photo, usedNames),
+			Method:   zip.Store,
+			Modified: time.Unix(photo.UpdatedAt, 0),
+		}
+
+		failure, err := p.writeArchiveEntry(ctx, archive, header, photo)
+		if err != nil {
+			return err
+		}
+
+		if failure != "" {
+			failures = append(failures, failure)
+		}
+	}
+
+	if len(failures) > 0 {
+		entry, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     archiveErrorsFileName,
+			Method:   zip.Deflate,
+			Modified: time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+
+		if _, err := io.WriteString(entry, strings.Join(failures, "\n")+"\n"); err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+// writeArchiveEntry copies the file of the photo into a new entry. A file that can not be read is returned as a
+// failure to list in the archive, the error means the archive itself can not be written anymore.
+func (p *photos) writeArchiveEntry(ctx context.Context, archive *zip.Writer, header *zip.FileHeader, photo models.Photos) (string, error) {
+	object, err := p.storage.Download(ctx, files.GetFileNameFromURL(photo.PhotoURL), photoPath)
+	if err != nil {
+		return fmt.Sprintf("%s (photo %d) is missing, its file could not be read", header.Name, photo.ID), ctx.Err()
+	}
+	defer object.Close()
+
+	entry, err := archive.CreateHeader(header)
+	if err != nil {
+		return "", err
+	}
+
+	// A failed write to the client fails the next entry, a canceled request stops the archive right away
+	if _, err := io.Copy(entry, object); err != nil {
+		return fmt.Sprintf("%s (photo %d) is incomplete, its file could not be read to the end", header.Name, photo.ID), ctx.Err()
+	}
+
+	return "", nil
+}
+
+// archiveFileName names the entry after the photo title, keeping the extension of the stored file.
+// A title used twice gets the photo ID appended so no entry is overwritten on extraction.
+func archiveFileName(photo models.Photos, usedNames map[string]bool) string {
+	ext := path.Ext(files.GetFileNameFromURL(photo.PhotoURL))
+
+	name := strings.TrimSpace(unsafeFileNameChars.ReplaceAllString(photo.Title, ""))
+	name = strings.Trim(name, ". ")
+	if runes := []rune(name); len(runes) > maxArchiveFileNameSize {
+		name = strings.TrimSpace(string(runes[:maxArchiveFileNameSize]))
+	}
+
+	if name == "" {
+		name = fmt.Sprintf("photo-%d", photo.ID)
+	}
+
+	fileName := name + ext
+	if usedNames[strings.ToLower(fileName)] {
+		fileName = fmt.Sprintf("%s-%d%s", name, photo.ID, ext)
+	}
+	usedNames[strings.ToLower(fileName)] = true
+
+	return fileName
+}
@@ -0,0 +1,78 @@
+package photos
+
+import (
+	"context"
+	"image"
+	"net/http"
+
+	"rakamin-final-task/helpers/errors"
+	"rakamin-final-task/helpers/files"
+	"rakamin-final-task/helpers/imaging"
+	"rakamin-final-task/models"
+)
+
+const (
+	backfillBatchSize = 100
+)
+
+// BackfillImages describes the images of the photos uploaded before their images were described at upload.
+// Every photo is tried once, a photo whose image can not be decoded is left without a description.
+func (p *photos) BackfillImages(ctx context.Context) error {
+	var backfillErr error
+
+	afterID := int64(0)
+	for {
+		photos, err := p.photo.GetListMissing(ctx, "blur_hash", afterID, backfillBatchSize)
+		if err != nil {
+			return err
+		} else if len(photos) == 0 {
+			return backfillErr
+		}
+
+		for _, photo := range photos {
+			afterID = photo.ID
+
+			img, err := p.downloadImage(ctx, photo)
+			if errors.GetCode(err) == http.StatusBadRequest {
+				continue
+			} else if err != nil {
+				backfillErr = err
+				continue
+			}
+
+			if err := p.photo.SetImage(ctx, photo, describeImage(img)); err != nil {
+				backfillErr = err
+			}
+		}
+	}
+}
+
+func (p *photos) downloadImage(ctx context.Context, photo models.Photos) (image.Image, error) {
+	content, err := p.storage.Download(ctx, files.GetFileNameFromURL(photo.PhotoURL), photoPath)
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+
+	return imaging.Decode(content)
+}
+
+// describeImage describes the image, the description is empty without an image
+func describeImage(img image.Image) models.PhotoImage {
+	if img == nil {
+		return models.PhotoImage{}
+	}
+
+	perceptualHash := int64(imaging.DHash(img))
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	blurHash := imaging.BlurHash(img)
+	dominantColor := imaging.DominantColor(img)
+
+	return models.PhotoImage{
+		PerceptualHash: &perceptualHash,
+		Width:          &width,
+		Height:         &height,
+		BlurHash:       &blurHash,
+		DominantColor:  &dominantColor,
+	}
+}
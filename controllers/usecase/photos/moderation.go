@@ -0,0 +1,75 @@
+package photos
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"rakamin-final-task/helpers/errors"
+	"rakamin-final-task/helpers/moderation"
+	"rakamin-final-task/models"
+)
+
+// moderateText moderates the title and caption of a photo, a rejected text fails the request. It returns the flag
+// to raise on the photo once it is saved, which is nil when the text is allowed.
+func (p *photos) moderateText(ctx context.Context, title string, caption string) (*models.PhotoModerations, error) {
+	verdict, err := p.moderator.ModerateText(ctx, title+"\n"+caption)
+	if err != nil {
+		return nil, err
+	}
+
+	switch verdict.Action {
+	case moderation.ActionReject:
+		return nil, errors.BadRequest(fmt.Sprintf("Photo text is not allowed: %s", strings.Join(verdict.Reasons, "; ")))
+	case moderation.ActionFlag:
+		return newModeration(models.ModerationSourceText, verdict), nil
+	}
+
+	return nil, nil
+}
+
+// moderateImage moderates the image of the photo file. A flagged image waits in the moderation queue, a rejected
+// one is recorded as rejected at once. A file that can not be decoded has no image to moderate.
+func (p *photos) moderateImage(ctx context.Context, photo models.Photos) error {
+	img, err := p.downloadImage(ctx, photo)
+	if errors.GetCode(err) == http.StatusBadRequest {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	verdict, err := p.moderator.ModerateImage(ctx, img)
+	if err != nil || verdict.Action == moderation.ActionAllow {
+		return err
+	}
+
+	photoModeration := newModeration(models.ModerationSourceImage, verdict)
+	photoModeration.PhotoID = photo.ID
+
+	return p.photoModeration.Create(ctx, *photoModeration)
+}
+
+// flagPhoto raises the flag of the text of the saved photo, the photo is hidden until a moderator reviews it
+func (p *photos) flagPhoto(ctx context.Context, photoID int64, photoModeration *models.PhotoModerations) error {
+	if photoModeration == nil {
+		return nil
+	}
+	photoModeration.PhotoID = photoID
+
+	return p.photoModeration.Create(ctx, *photoModeration)
+}
+
+func newModeration(source string, verdict moderation.Verdict) *models.PhotoModerations {
+	status := models.ModerationStatusPending
+	if verdict.Action == moderation.ActionReject {
+		status = models.ModerationStatusRejected
+	}
+
+	return &models.PhotoModerations{
+		Source:  source,
+		Action:  verdict.Action,
+		Reasons: strings.Join(verdict.Reasons, "; "),
+		Status:  status,
+	}
+}
@@ -0,0 +1,158 @@
+package photos
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"rakamin-final-task/helpers/errors"
+	"rakamin-final-task/models"
+)
+
+// PurgeUnreferencedObjects deletes the files that no photo or version refers to anymore.
+// A file that can not be deleted is kept and retried on the next run.
+func (p *photos) PurgeUnreferencedObjects(ctx context.Context) error {
+	objects, err := p.photoObject.GetUnreferenced(ctx, purgeBatchSize)
+	if err != nil {
+		return err
+	}
+
+	var purgeErr error
+	for _, object := range objects {
+		deleteFile := func() error {
+			if err := p.deleteRenditions(ctx, object.FileName); err != nil {
+				return err
+			}
+
+			if err := p.deleteRenders(ctx, object.FileName, photoPath); err != nil {
+				return err
+			}
+
+			return p.storage.Delete(ctx, object.FileName, photoPath)
+		}
+
+		if err := p.photoObject.Purge(ctx, object.FileName, deleteFile); err != nil {
+			purgeErr = err
+		}
+	}
+
+	return purgeErr
+}
+
+// hashObject reads the content to name its object after its SHA-256, the extension comes from the sniffed type
+// or from the original file name when the type is not a known image type. The content is rewound afterwards.
+func hashObject(content io.ReadSeeker, fileName string) (models.PhotoObjects, error) {
+	var object models.PhotoObjects
+
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return object, err
+	}
+
+	head := make([]byte, sniffSize)
+	n, err := io.ReadFull(content, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return object, err
+	}
+	head = head[:n]
+
+	hash := sha256.New()
+	hash.Write(head)
+
+	size, err := io.Copy(hash, content)
+	if err != nil {
+		return object, err
+	}
+
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return object, err
+	}
+
+	extension, ok := uploadExtensions[http.DetectContentType(head)]
+	if !ok {
+		extension = strings.ToLower(filepath.Ext(fileName))
+	}
+
+	object = models.PhotoObjects{
+		Hash: hex.EncodeToString(hash.Sum(nil)),
+		Size: size + int64(n),
+	}
+	object.FileName = object.Hash + extension
+
+	return object, nil
+}
+
+// storeObject adds a reference to the object and uploads its content unless the file is already stored,
+// it returns the URL of the file
+func (p *photos) storeObject(ctx context.Context, object models.PhotoObjects, content io.ReadSeeker) (string, error) {
+	return p.putObject(ctx, object, func() error {
+		if _, err := content.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+
+		_, err := p.storage.UploadFromReader(ctx, content, object.FileName, photoPath)
+		return err
+	})
+}
+
+// copyObject adds a reference to the object and copies it from another file of the storage unless the file is
+// already stored, it returns the URL of the file
+func (p *photos) copyObject(ctx context.Context, object models.PhotoObjects, fileName string) (string, error) {
+	return p.putObject(ctx, object, func() error {
+		return p.storage.Copy(ctx, fileName, object.FileName, photoPath)
+	})
+}
+
+// putObject adds a reference to the object and puts its file unless it is already stored. The reference is taken
+// first so the file can not be purged in the meantime.
+func (p *photos) putObject(ctx context.Context, object models.PhotoObjects, put func() error) (string, error) {
+	if err := p.photoObject.Acquire(ctx, object); err != nil {
+		return "", err
+	}
+
+	photoURL, err := p.storage.ObjectURL(object.FileName, photoPath)
+	if err != nil {
+		p.photoObject.Release(ctx, object.FileName)
+		return "", err
+	}
+
+	_, err = p.storage.Attributes(ctx, object.FileName, photoPath)
+	if errors.GetCode(err) == http.StatusNotFound {
+		err = put()
+	}
+	if err != nil {
+		p.photoObject.Release(ctx, object.FileName)
+		return "", err
+	}
+
+	return photoURL, nil
+}
+
+// duplicateOf returns the ID of a photo of the user with the same content according to the duplicate policy,
+// which is the configured one unless the upload asks otherwise. A rejected duplicate is a conflict.
+func (p *photos) duplicateOf(ctx context.Context, userID int64, hash string, policy string) (*int64, error) {
+	if policy == "" {
+		policy = p.config.DuplicatePolicy
+	}
+
+	if policy != models.DuplicatePolicyWarn && policy != models.DuplicatePolicyReject {
+		return nil, nil
+	}
+
+	photo, err := p.photo.Get(ctx, models.PhotoParams{UserID: userID, ContentHash: hash})
+	if errors.GetCode(err) == http.StatusNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if policy == models.DuplicatePolicyReject {
+		return nil, errors.Conflict(fmt.Sprintf("Photo is a duplicate of photo %d", photo.ID))
+	}
+
+	return &photo.ID, nil
+}
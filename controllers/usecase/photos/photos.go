@@ -3,66 +3,304 @@ package photos
 import (
 	"context"
 	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
+	"rakamin-final-task/config"
+	photoJobRepo "rakamin-final-task/controllers/repository/photo_jobs"
+	photoModerationRepo "rakamin-final-task/controllers/repository/photo_moderations"
+	photoObjectRepo "rakamin-final-task/controllers/repository/photo_objects"
+	photoRenderRepo "rakamin-final-task/controllers/repository/photo_renders"
+	photoRenditionRepo "rakamin-final-task/controllers/repository/photo_renditions"
+	photoUploadRepo "rakamin-final-task/controllers/repository/photo_uploads"
+	photoVersionRepo "rakamin-final-task/controllers/repository/photo_versions"
 	photoRepo "rakamin-final-task/controllers/repository/photos"
+	tusUploadRepo "rakamin-final-task/controllers/repository/tus_uploads"
+	watermarkRepo "rakamin-final-task/controllers/repository/watermarks"
 	"rakamin-final-task/helpers/appcontext"
+	"rakamin-final-task/helpers/errors"
 	"rakamin-final-task/helpers/files"
+	"rakamin-final-task/helpers/imaging"
+	"rakamin-final-task/helpers/moderation"
 	"rakamin-final-task/helpers/response"
 	"rakamin-final-task/helpers/storage"
+	"rakamin-final-task/helpers/validator"
 	"rakamin-final-task/models"
 )
 
 type Interface interface {
 	Create(ctx context.Context, param models.CreatePhotoParams, photoFile *files.File) (models.Photos, error)
+	CreateBatch(ctx context.Context, param models.CreateBatchPhotoParams, photoFiles []*files.File) ([]models.BatchPhotoResult, error)
 	Get(ctx context.Context, param models.PhotoParams) (models.Photos, error)
 	GetList(ctx context.Context, param models.PhotoParams) ([]models.Photos, *response.PaginationParam, error)
 	Update(ctx context.Context, param models.PhotoParams, body models.UpdatePhotoParams) (models.Photos, error)
 	Delete(ctx context.Context, param models.PhotoParams) error
+	Bulk(ctx context.Context, body models.BulkPhotoParams) ([]models.BulkPhotoResult, error)
+	GetListArchive(ctx context.Context, param models.PhotoArchiveParams) ([]models.Photos, error)
+	WriteArchive(ctx context.Context, photos []models.Photos, w io.Writer) error
+	CreateUpload(ctx context.Context, body models.CreatePhotoUploadParams) (models.PhotoUploads, error)
+	CompleteUpload(ctx context.Context, param models.PhotoUploadParams, body models.CreatePhotoParams) (models.Photos, error)
+	PutSignedContent(ctx context.Context, param models.SignedFileParams, content io.Reader) error
+	PurgeExpiredUploads(ctx context.Context) error
+	TusMaxSize() int64
+	CreateTusUpload(ctx context.Context, body models.CreateTusUploadParams) (models.TusUploads, error)
+	GetTusUpload(ctx context.Context, param models.TusUploadParams) (models.TusUploads, error)
+	PatchTusUpload(ctx context.Context, param models.TusUploadParams, body models.PatchTusUploadParams, content io.Reader) (models.TusUploads, error)
+	DeleteTusUpload(ctx context.Context, param models.TusUploadParams) error
+	PurgeExpiredTusUploads(ctx context.Context) error
+	GetPublic(ctx context.Context, param models.PhotoParams) (models.Photos, error)
+	GetContent(ctx context.Context, param models.PhotoParams, accept string) (models.Photos, *storage.ObjectReader, error)
+	GetSignedContent(ctx context.Context, param models.SignedFileParams, accept string) (*storage.ObjectReader, error)
+	GetPublicList(ctx context.Context, param models.PhotoParams) ([]models.Photos, *response.PaginationParam, error)
+	ReplaceFile(ctx context.Context, param models.PhotoParams, photoFile *files.File) (models.Photos, error)
+	GetListVersion(ctx context.Context, param models.PhotoVersionParams) ([]models.PhotoVersions, error)
+	RestoreVersion(ctx context.Context, param models.PhotoVersionParams) (models.Photos, error)
+	PurgeExpiredVersions(ctx context.Context) error
+	GetListTrash(ctx context.Context, param models.PhotoParams) ([]models.Photos, *response.PaginationParam, error)
+	Restore(ctx context.Context, param models.PhotoParams) (models.Photos, error)
+	PurgeTrash(ctx context.Context) error
+	PurgeUnreferencedObjects(ctx context.Context) error
+	GetListSimilar(ctx context.Context, param models.SimilarPhotoParams) ([]models.Photos, error)
+	BackfillImages(ctx context.Context) error
+	RenderWatermarks(ctx context.Context) error
+	Render(ctx context.Context, param models.RenderPhotoParams) (models.Photos, *storage.ObjectReader, error)
+	ProcessPhotos(ctx context.Context) error
+	GetStatus(ctx context.Context, param models.PhotoStatusParams) (models.PhotoStatus, error)
+	SignPhoto(ctx context.Context, photo *models.Photos, userID int64) error
 }
 
 const (
 	photoPath = "photos"
+
+	purgeBatchSize = 100
+
+	defaultBatchUploadLimit = 50
+	defaultUploadWorkers    = 4
 )
 
 type photos struct {
-	photo   photoRepo.Interface
-	storage storage.Interface
+	photo           photoRepo.Interface
+	photoVersion    photoVersionRepo.Interface
+	photoUpload     photoUploadRepo.Interface
+	tusUpload       tusUploadRepo.Interface
+	photoObject     photoObjectRepo.Interface
+	photoRendition  photoRenditionRepo.Interface
+	watermark       watermarkRepo.Interface
+	photoRender     photoRenderRepo.Interface
+	photoJob        photoJobRepo.Interface
+	photoModeration photoModerationRepo.Interface
+	config          config.Storage
+	storage         storage.Interface
+	validator       validator.Interface
+
+	// encoders write the formats the standard library can not encode, by format name
+	encoders map[string]imaging.Encoder
+
+	// moderator decides whether the uploaded photos and their texts can be published
+	moderator moderation.Moderator
+
+	// tusLocks serializes the requests of every tus upload, their data is on the local disk of this instance
+	tusLocksMu sync.Mutex
+	tusLocks   map[int64]*tusLock
 }
 
 type InitParam struct {
-	PhotoRepo photoRepo.Interface
-	Storage   storage.Interface
+	PhotoRepo           photoRepo.Interface
+	PhotoVersionRepo    photoVersionRepo.Interface
+	PhotoUploadRepo     photoUploadRepo.Interface
+	TusUploadRepo       tusUploadRepo.Interface
+	PhotoObjectRepo     photoObjectRepo.Interface
+	PhotoRenditionRepo  photoRenditionRepo.Interface
+	WatermarkRepo       watermarkRepo.Interface
+	PhotoRenderRepo     photoRenderRepo.Interface
+	PhotoJobRepo        photoJobRepo.Interface
+	PhotoModerationRepo photoModerationRepo.Interface
+	Config              config.Storage
+	Storage             storage.Interface
+	Validator           validator.Interface
+	Encoders            map[string]imaging.Encoder
+	Moderator           moderation.Moderator
 }
 
 func Init(param InitParam) Interface {
 	return &photos{
-		photo:   param.PhotoRepo,
-		storage: param.Storage,
+		photo:           param.PhotoRepo,
+		photoVersion:    param.PhotoVersionRepo,
+		photoUpload:     param.PhotoUploadRepo,
+		tusUpload:       param.TusUploadRepo,
+		photoObject:     param.PhotoObjectRepo,
+		photoRendition:  param.PhotoRenditionRepo,
+		watermark:       param.WatermarkRepo,
+		photoRender:     param.PhotoRenderRepo,
+		photoJob:        param.PhotoJobRepo,
+		photoModeration: param.PhotoModerationRepo,
+		config:          param.Config,
+		storage:         param.Storage,
+		validator:       param.Validator,
+		encoders:        param.Encoders,
+		moderator:       param.Moderator,
 	}
 }
 
 func (p *photos) Create(ctx context.Context, param models.CreatePhotoParams, photoFile *files.File) (models.Photos, error) {
 	var photo models.Photos
 
+	param.Tags = normalizeTags(param.Tags)
+	if err := p.validator.ValidateStruct(param); err != nil {
+		validationErr, _ := p.validator.GetValidationErrors(err)
+		return photo, errors.ValidationError(validationErr)
+	}
+
+	return p.create(ctx, param, photoFile, p.photo.Create)
+}
+
+// CreateBatch creates a photo for every file with a bounded number of concurrent uploads. A failing file does not
+// fail the batch, its error is returned in its result instead.
+func (p *photos) CreateBatch(ctx context.Context, param models.CreateBatchPhotoParams, photoFiles []*files.File) ([]models.BatchPhotoResult, error) {
+	limit := p.config.BatchUploadLimit
+	if limit <= 0 {
+		limit = defaultBatchUploadLimit
+	}
+
+	switch {
+	case len(photoFiles) == 0:
+		return nil, errors.BadRequest("File not found")
+	case len(photoFiles) > limit:
+		return nil, errors.BadRequest(fmt.Sprintf("A batch can not have more than %d files", limit))
+	case len(param.Titles) != len(photoFiles) || len(param.Captions) != len(photoFiles):
+		return nil, errors.BadRequest("Every file must have a title and a caption")
+	}
+
+	workers := p.config.UploadWorkers
+	if workers <= 0 {
+		workers = defaultUploadWorkers
+	}
+	workers = min(workers, len(photoFiles))
+
+	tags := normalizeTags(param.Tags)
+
+	results := make([]models.BatchPhotoResult, len(photoFiles))
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range indexes {
+				photoParam := models.CreatePhotoParams{
+					Title:       param.Titles[i],
+					Caption:     param.Captions[i],
+					Tags:        tags,
+					Visibility:  param.Visibility,
+					OnDuplicate: param.OnDuplicate,
+				}
+
+				result := models.BatchPhotoResult{
+					Index:    i,
+					FileName: photoFiles[i].Meta.Filename,
+				}
+
+				photo, err := p.createBatchItem(ctx, photoParam, photoFiles[i])
+
+				result.Code = errors.GetCode(err)
+				if err != nil {
+					result.Message = errors.GetMessage(err)
+				} else {
+					result.IsSuccess = true
+					result.Photo = &photo
+				}
+
+				results[i] = result
+			}
+		}()
+	}
+
+	for i := range photoFiles {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return results, nil
+}
+
+func (p *photos) createBatchItem(ctx context.Context, param models.CreatePhotoParams, photoFile *files.File) (models.Photos, error) {
+	// A batch is not rejected as a whole for a single file that is not an image
+	if !photoFile.IsImage() {
+		return models.Photos{}, errors.BadRequest("File is not an image")
+	}
+
+	if err := p.validator.ValidateStruct(param); err != nil {
+		validationErr, _ := p.validator.GetValidationErrors(err)
+		return models.Photos{}, errors.ValidationError(validationErr)
+	}
+
+	return p.create(ctx, param, photoFile, p.photo.Create)
+}
+
+// create stores the file under the hash of its content and creates its photo with save, the params must be validated
+// beforehand
+func (p *photos) create(ctx context.Context, param models.CreatePhotoParams, photoFile *files.File, save func(context.Context, models.Photos) (models.Photos, error)) (models.Photos, error) {
+	var photo models.Photos
+
 	userID := appcontext.GetUserID(ctx)
 
-	// format: {userID}_{timestamp}
-	photoFile.SetFileName(fmt.Sprintf("%d_%d", userID, time.Now().Unix()))
-	photoURL, err := p.storage.Upload(ctx, photoFile, photoPath)
+	flag, err := p.moderateText(ctx, param.Title, param.Caption)
 	if err != nil {
 		return photo, err
 	}
 
+	object, err := hashObject(photoFile.Content, photoFile.Meta.Filename)
+	if err != nil {
+		return photo, err
+	}
+
+	duplicateOf, err := p.duplicateOf(ctx, userID, object.Hash, param.OnDuplicate)
+	if err != nil {
+		return photo, err
+	}
+
+	photoURL, err := p.storeObject(ctx, object, photoFile.Content)
+	if err != nil {
+		return photo, err
+	}
+
+	if param.Visibility == "" {
+		param.Visibility = models.VisibilityPrivate
+	}
+
 	photo = models.Photos{
-		Title:    param.Title,
-		Caption:  param.Caption,
-		UserID:   userID,
-		PhotoURL: photoURL,
+		Title:            param.Title,
+		Caption:          param.Caption,
+		UserID:           userID,
+		PhotoURL:         photoURL,
+		ContentHash:      object.Hash,
+		ProcessingStatus: models.PhotoStatusPending,
+		Visibility:       param.Visibility,
+		Tags:             tagsByName(param.Tags),
+	}
+	if flag != nil {
+		photo.ModerationStatus = models.PhotoModerationFlagged
 	}
 
-	photo, err = p.photo.Create(ctx, photo)
+	photo, err = save(ctx, photo)
 	if err != nil {
+		p.photoObject.Release(ctx, photoURL)
+		return photo, err
+	}
+	photo.DuplicateOf = duplicateOf
+
+	if err := p.flagPhoto(ctx, photo.ID, flag); err != nil {
+		return photo, err
+	}
+
+	if err := p.signPhoto(ctx, &photo); err != nil {
 		return photo, err
 	}
 
@@ -77,12 +315,7 @@ func (p *photos) Get(ctx context.Context, param models.PhotoParams) (models.Phot
 		UserID: userID,
 	}
 
-	photo, err := p.photo.Get(ctx, photoParam)
-	if err != nil {
-		return photo, err
-	}
-
-	return photo, nil
+	return p.getSigned(ctx, photoParam)
 }
 
 func (p *photos) GetList(ctx context.Context, param models.PhotoParams) ([]models.Photos, *response.PaginationParam, error) {
@@ -90,6 +323,11 @@ func (p *photos) GetList(ctx context.Context, param models.PhotoParams) ([]model
 
 	photoParam := models.PhotoParams{
 		UserID:          userID,
+		Tags:            normalizeTags(param.Tags),
+		TagMode:         param.TagMode,
+		Language:        param.Language,
+		HasCaption:      param.HasCaption,
+		ListFilter:      param.ListFilter,
 		PaginationParam: param.PaginationParam,
 	}
 
@@ -98,6 +336,10 @@ func (p *photos) GetList(ctx context.Context, param models.PhotoParams) ([]model
 		return photos, pg, err
 	}
 
+	if err := p.signPhotos(ctx, photos); err != nil {
+		return photos, pg, err
+	}
+
 	return photos, pg, nil
 }
 
@@ -111,15 +353,42 @@ func (p *photos) Update(ctx context.Context, param models.PhotoParams, body mode
 		UserID: userID,
 	}
 
+	if body.Tags != nil {
+		body.Tags = normalizeTags(body.Tags)
+	}
+
+	if err := p.validator.ValidateStruct(body); err != nil {
+		validationErr, _ := p.validator.GetValidationErrors(err)
+		return photo, errors.ValidationError(validationErr)
+	}
+
+	flag, err := p.moderateText(ctx, body.Title, body.Caption)
+	if err != nil {
+		return photo, err
+	}
+
 	photo.Title = body.Title
 	photo.Caption = body.Caption
+	photo.Visibility = body.Visibility
+	photo.IsCommentDisabled = body.IsCommentDisabled
+	photo.UpdatedBy = &userID
+
+	// The flagged text is hidden along with the update, before its flag is raised
+	if flag != nil {
+		photo.ModerationStatus = models.PhotoModerationFlagged
+	}
 
-	photo, err := p.photo.Update(ctx, photo, photoParam)
+	// A nil tags field means the tags are left untouched
+	photo, err = p.photo.Update(ctx, photo, photoParam, body.Tags)
 	if err != nil {
 		return photo, err
 	}
 
-	return photo, nil
+	if err := p.flagPhoto(ctx, param.ID, flag); err != nil {
+		return photo, err
+	}
+
+	return p.getSigned(ctx, photoParam)
 }
 
 func (p *photos) Delete(ctx context.Context, param models.PhotoParams) error {
@@ -130,16 +399,436 @@ func (p *photos) Delete(ctx context.Context, param models.PhotoParams) error {
 		UserID: userID,
 	}
 
+	if err := p.photo.Delete(ctx, photoParam, userID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Bulk applies a single action to many photos at once. The photos that are not found, or not owned by the user,
+// are reported as failed while the action is still applied to the others.
+func (p *photos) Bulk(ctx context.Context, body models.BulkPhotoParams) ([]models.BulkPhotoResult, error) {
+	userID := appcontext.GetUserID(ctx)
+
+	body.Tags = normalizeTags(body.Tags)
+	if err := p.validator.ValidateStruct(body); err != nil {
+		validationErr, _ := p.validator.GetValidationErrors(err)
+		return nil, errors.ValidationError(validationErr)
+	}
+
+	switch {
+	case body.Action == models.BulkActionMoveToAlbum:
+		return nil, errors.BadRequest("Photos can not be moved to an album, the photos are not grouped in albums")
+	case body.Action == models.BulkActionVisibility && body.Visibility == "":
+		return nil, errors.BadRequest("Visibility is required to change the visibility")
+	case (body.Action == models.BulkActionAddTags || body.Action == models.BulkActionRemoveTags) && len(body.Tags) == 0:
+		return nil, errors.BadRequest("Tags are required to add or remove tags")
+	}
+
+	body.IDs = slices.Clone(body.IDs)
+	slices.Sort(body.IDs)
+	body.IDs = slices.Compact(body.IDs)
+
+	appliedIDs, err := p.photo.Bulk(ctx, userID, body)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]models.BulkPhotoResult, 0, len(body.IDs))
+	for _, id := range body.IDs {
+		result := models.BulkPhotoResult{ID: id, IsSuccess: true, Code: http.StatusOK}
+		if !slices.Contains(appliedIDs, id) {
+			result = models.BulkPhotoResult{ID: id, Code: http.StatusNotFound, Message: "Photo not found"}
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+func (p *photos) GetListTrash(ctx context.Context, param models.PhotoParams) ([]models.Photos, *response.PaginationParam, error) {
+	photoParam := models.PhotoParams{
+		UserID:          appcontext.GetUserID(ctx),
+		ListFilter:      param.ListFilter,
+		PaginationParam: param.PaginationParam,
+	}
+
+	photos, pg, err := p.photo.GetListTrash(ctx, photoParam)
+	if err != nil {
+		return photos, pg, err
+	}
+
+	if err := p.signPhotos(ctx, photos); err != nil {
+		return photos, pg, err
+	}
+
+	if p.config.TrashRetentionSec > 0 {
+		for i := range photos {
+			photos[i].PurgeAt = photos[i].DeletedAt.Time.Unix() + p.config.TrashRetentionSec
+		}
+	}
+
+	return photos, pg, nil
+}
+
+func (p *photos) Restore(ctx context.Context, param models.PhotoParams) (models.Photos, error) {
+	photoParam := models.PhotoParams{
+		ID:     param.ID,
+		UserID: appcontext.GetUserID(ctx),
+	}
+
+	if err := p.photo.Restore(ctx, photoParam); err != nil {
+		return models.Photos{}, err
+	}
+
+	return p.getSigned(ctx, photoParam)
+}
+
+// PurgeTrash permanently deletes the photos that have been in the trash for longer than the retention,
+// along with their retained versions. Their files are deleted once no other photo refers to them.
+func (p *photos) PurgeTrash(ctx context.Context) error {
+	if p.config.TrashRetentionSec <= 0 {
+		return nil
+	}
+
+	before := time.Now().Add(-time.Duration(p.config.TrashRetentionSec) * time.Second)
+	photos, err := p.photo.GetExpiredTrash(ctx, before, purgeBatchSize)
+	if err != nil {
+		return err
+	}
+
+	purgedIDs := make([]int64, 0, len(photos))
+	for _, photo := range photos {
+		purgedIDs = append(purgedIDs, photo.ID)
+	}
+
+	if err := p.deleteWatermarkFiles(ctx, purgedIDs); err != nil {
+		return err
+	}
+
+	return p.photo.Purge(ctx, purgedIDs)
+}
+
+// GetContent returns a reader of the photo file, or of the rendition the client accepts, for a user who can see
+// the photo. The other users get the watermarked copy instead when the owner has enabled a watermark.
+// The caller must close it.
+func (p *photos) GetContent(ctx context.Context, param models.PhotoParams, accept string) (models.Photos, *storage.ObjectReader, error) {
+	photo, err := p.photo.Get(ctx, models.PhotoParams{ID: param.ID})
+	if err != nil {
+		return photo, nil, err
+	}
+
+	userID := appcontext.GetUserID(ctx)
+	if !photo.IsVisibleTo(userID) {
+		return models.Photos{}, nil, errors.NotFound("Photo not found")
+	}
+
+	if photo.UserID != userID {
+		fileName, ok, err := p.watermarkedFile(ctx, photo)
+		if err != nil {
+			return photo, nil, err
+		} else if ok {
+			content, err := p.openFile(ctx, fileName, watermarkPath)
+			return photo, content, err
+		}
+	}
+
+	content, err := p.openContent(ctx, files.GetFileNameFromURL(photo.PhotoURL), accept)
+	if err != nil {
+		return photo, nil, err
+	}
+
+	return photo, content, nil
+}
+
+// GetSignedContent returns a reader of a photo file, a watermarked copy or a watermark logo requested through its
+// signed URL. A photo file is replaced by the rendition the client accepts. The caller must close it.
+func (p *photos) GetSignedContent(ctx context.Context, param models.SignedFileParams, accept string) (*storage.ObjectReader, error) {
+	if param.Path != photoPath && param.Path != watermarkPath && param.Path != logoPath {
+		return nil, errors.NotFound("File not found")
+	}
+
+	if err := p.storage.VerifySignedURL(http.MethodGet, param.FileName, param.Path, param.Expires, param.Signature); err != nil {
+		return nil, err
+	}
+
+	if param.Path != photoPath {
+		return p.openFile(ctx, param.FileName, param.Path)
+	}
+
+	return p.openContent(ctx, param.FileName, accept)
+}
+
+// GetPublic returns a photo to anyone who knows its ID, as long as it is public or unlisted and not hidden by
+// moderation. Followers only photos are never returned since an anonymous caller can not be a follower.
+func (p *photos) GetPublic(ctx context.Context, param models.PhotoParams) (models.Photos, error) {
+	photoParam := models.PhotoParams{
+		ID:            param.ID,
+		Visibilities:  []string{models.VisibilityPublic, models.VisibilityUnlisted},
+		ExcludeHidden: true,
+	}
+
 	photo, err := p.photo.Get(ctx, photoParam)
+	if err != nil {
+		return photo, err
+	}
+
+	if err := p.signPublicPhoto(ctx, &photo); err != nil {
+		return photo, err
+	}
+
+	return photo, nil
+}
+
+// GetPublicList returns the public feed, newest photos first. Unlisted photos and the photos hidden by moderation
+// are left out of the feed.
+func (p *photos) GetPublicList(ctx context.Context, param models.PhotoParams) ([]models.Photos, *response.PaginationParam, error) {
+	photoParam := models.PhotoParams{
+		Tags:            normalizeTags(param.Tags),
+		TagMode:         param.TagMode,
+		Language:        param.Language,
+		HasCaption:      param.HasCaption,
+		ListFilter:      param.ListFilter,
+		Visibilities:    []string{models.VisibilityPublic},
+		ExcludeHidden:   true,
+		PaginationParam: param.PaginationParam,
+	}
+
+	photos, pg, err := p.photo.GetList(ctx, photoParam)
+	if err != nil {
+		return photos, pg, err
+	}
+
+	for i := range photos {
+		if err := p.signPublicPhoto(ctx, &photos[i]); err != nil {
+			return photos, pg, err
+		}
+	}
+
+	return photos, pg, nil
+}
+
+// ReplaceFile uploads a new file for the photo. The previous file is kept as a version for the configured retention,
+// or released once the database points to the new file when there is no retention. The same content as the current
+// file leaves the photo as it is.
+func (p *photos) ReplaceFile(ctx context.Context, param models.PhotoParams, photoFile *files.File) (models.Photos, error) {
+	userID := appcontext.GetUserID(ctx)
+
+	photoParam := models.PhotoParams{
+		ID:     param.ID,
+		UserID: userID,
+	}
+
+	photo, err := p.photo.Get(ctx, photoParam)
+	if err != nil {
+		return photo, err
+	}
+
+	object, err := hashObject(photoFile.Content, photoFile.Meta.Filename)
+	if err != nil {
+		return photo, err
+	}
+
+	if object.FileName == files.GetFileNameFromURL(photo.PhotoURL) {
+		return p.getSigned(ctx, photoParam)
+	}
+
+	photoURL, err := p.storeObject(ctx, object, photoFile.Content)
+	if err != nil {
+		return photo, err
+	}
+
+	switchParam := models.SwitchPhotoFileParams{
+		PhotoID:      photo.ID,
+		UserID:       userID,
+		CurrentURL:   photo.PhotoURL,
+		CurrentHash:  photo.ContentHash,
+		CurrentImage: photo.PhotoImage,
+		NewURL:       photoURL,
+		NewHash:      object.Hash,
+		Process:      true,
+		RetainUntil:  p.retainUntil(),
+	}
+
+	if err := p.photo.SwitchFile(ctx, switchParam); err != nil {
+		p.photoObject.Release(ctx, photoURL)
+		return photo, err
+	}
+
+	return p.getSigned(ctx, photoParam)
+}
+
+func (p *photos) GetListVersion(ctx context.Context, param models.PhotoVersionParams) ([]models.PhotoVersions, error) {
+	versionParam := models.PhotoVersionParams{
+		PhotoID: param.PhotoID,
+		UserID:  appcontext.GetUserID(ctx),
+	}
+
+	versions, err := p.photoVersion.GetList(ctx, versionParam)
+	if err != nil {
+		return versions, err
+	}
+
+	for i := range versions {
+		versions[i].PhotoURL, err = p.storage.SignedURL(ctx, files.GetFileNameFromURL(versions[i].PhotoURL), photoPath)
+		if err != nil {
+			return versions, err
+		}
+	}
+
+	return versions, nil
+}
+
+// RestoreVersion rolls the photo back to a previous file, the current file becomes a version in turn
+func (p *photos) RestoreVersion(ctx context.Context, param models.PhotoVersionParams) (models.Photos, error) {
+	userID := appcontext.GetUserID(ctx)
+
+	photoParam := models.PhotoParams{
+		ID:     param.PhotoID,
+		UserID: userID,
+	}
+
+	photo, err := p.photo.Get(ctx, photoParam)
+	if err != nil {
+		return photo, err
+	}
+
+	versionParam := models.PhotoVersionParams{
+		ID:      param.ID,
+		PhotoID: photo.ID,
+		UserID:  userID,
+	}
+
+	version, err := p.photoVersion.Get(ctx, versionParam)
+	if err != nil {
+		return photo, err
+	}
+
+	if version.ExpiresAt <= time.Now().Unix() {
+		return photo, errors.Gone("Photo version has expired")
+	}
+
+	switchParam := models.SwitchPhotoFileParams{
+		PhotoID:           photo.ID,
+		UserID:            userID,
+		CurrentURL:        photo.PhotoURL,
+		CurrentHash:       photo.ContentHash,
+		CurrentImage:      photo.PhotoImage,
+		NewURL:            version.PhotoURL,
+		NewHash:           version.ContentHash,
+		NewImage:          version.PhotoImage,
+		RetainUntil:       p.retainUntil(),
+		RestoredVersionID: version.ID,
+	}
+
+	if err := p.photo.SwitchFile(ctx, switchParam); err != nil {
+		return photo, err
+	}
+
+	return p.getSigned(ctx, photoParam)
+}
+
+// PurgeExpiredVersions deletes the expired versions, their files are deleted once nothing else refers to them
+func (p *photos) PurgeExpiredVersions(ctx context.Context) error {
+	versions, err := p.photoVersion.GetExpired(ctx, time.Now().Unix(), purgeBatchSize)
 	if err != nil {
 		return err
 	}
 
-	p.storage.Delete(ctx, photo.PhotoURL, photoPath)
+	purgedIDs := make([]int64, 0, len(versions))
+	for _, version := range versions {
+		purgedIDs = append(purgedIDs, version.ID)
+	}
+
+	return p.photoVersion.Delete(ctx, purgedIDs)
+}
+
+// getSigned returns the photo with a signed URL, see signPhoto
+func (p *photos) getSigned(ctx context.Context, param models.PhotoParams) (models.Photos, error) {
+	photo, err := p.photo.Get(ctx, param)
+	if err != nil {
+		return photo, err
+	}
 
-	if err := p.photo.Delete(ctx, photoParam); err != nil {
+	if err := p.signPhoto(ctx, &photo); err != nil {
+		return photo, err
+	}
+
+	return photo, nil
+}
+
+// signPhoto replaces the stored URL of the photo with a signed URL that expires, so the file can only be read
+// for as long as the photo can be seen. The stored URL is only used to find the file in the storage.
+func (p *photos) signPhoto(ctx context.Context, photo *models.Photos) error {
+	signedURL, err := p.storage.SignedURL(ctx, files.GetFileNameFromURL(photo.PhotoURL), photoPath)
+	if err != nil {
 		return err
 	}
 
+	photo.PhotoURL = signedURL
+	photo.RenderURLs = p.renderURLs(photo.ID, false)
+
+	return nil
+}
+
+// SignPhoto signs the URL of the photo for the user, the owner gets the photo file while the other users get the
+// watermarked copy as they do from the public endpoints
+func (p *photos) SignPhoto(ctx context.Context, photo *models.Photos, userID int64) error {
+	if photo.UserID == userID {
+		return p.signPhoto(ctx, photo)
+	}
+
+	return p.signPublicPhoto(ctx, photo)
+}
+
+func (p *photos) signPhotos(ctx context.Context, photos []models.Photos) error {
+	for i := range photos {
+		if err := p.signPhoto(ctx, &photos[i]); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
+
+func (p *photos) retainUntil() int64 {
+	if p.config.VersionRetentionSec <= 0 {
+		return 0
+	}
+
+	return time.Now().Unix() + p.config.VersionRetentionSec
+}
+
+// tagsByName describes the tags of a new photo by their name, the repository creates them along with the photo
+func tagsByName(names []string) []models.Tags {
+	tags := make([]models.Tags, 0, len(names))
+	for _, name := range names {
+		tags = append(tags, models.Tags{Name: name})
+	}
+
+	return tags
+}
+
+// normalizeTags lowercases the tags, strips the leading hash, joins inner spaces with a dash and removes duplicates.
+// A single tag may also contain several comma separated tags.
+func normalizeTags(rawTags []string) []string {
+	tags := []string{}
+	isExist := map[string]bool{}
+
+	for _, rawTag := range rawTags {
+		for _, tag := range strings.Split(rawTag, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "#")
+			tag = strings.ToLower(strings.Join(strings.Fields(tag), "-"))
+			if tag == "" || isExist[tag] {
+				continue
+			}
+
+			isExist[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
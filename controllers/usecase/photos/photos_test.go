@@ -0,0 +1,58 @@
+package photos
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	tests := []struct {
+		name    string
+		rawTags []string
+		want    []string
+	}{
+		{
+			name:    "no tags",
+			rawTags: nil,
+			want:    []string{},
+		},
+		{
+			name:    "lowercased without the leading hash",
+			rawTags: []string{"#Sunset", "  BEACH "},
+			want:    []string{"sunset", "beach"},
+		},
+		{
+			name:    "inner spaces joined with a dash",
+			rawTags: []string{"golden   hour", "new\tyork"},
+			want:    []string{"golden-hour", "new-york"},
+		},
+		{
+			name:    "comma separated tags",
+			rawTags: []string{"sea,sand", " sky , #sun"},
+			want:    []string{"sea", "sand", "sky", "sun"},
+		},
+		{
+			name:    "duplicates keep the first position",
+			rawTags: []string{"Sea", "sky", "#sea", "SKY,sea"},
+			want:    []string{"sea", "sky"},
+		},
+		{
+			name:    "empty tags removed",
+			rawTags: []string{"", " ", "#", ",,", "ok"},
+			want:    []string{"ok"},
+		},
+		{
+			name:    "only the leading hash is stripped",
+			rawTags: []string{"##double", "c#"},
+			want:    []string{"#double", "c#"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeTags(tt.rawTags); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("normalizeTags(%q) = %q, want %q", tt.rawTags, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package photos
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"rakamin-final-task/helpers/appcontext"
+	"rakamin-final-task/helpers/errors"
+	"rakamin-final-task/helpers/files"
+	"rakamin-final-task/helpers/storage"
+	"rakamin-final-task/models"
+)
+
+const (
+	processBatchSize = 10
+
+	// processLease is how long a claimed job is left to its worker before it is claimed again
+	processLease = 5 * time.Minute
+
+	// processMaxAttempts is how many times a job is tried before the photo fails, processRetryDelay is the wait
+	// before the next attempt and grows with every attempt
+	processMaxAttempts = 3
+	processRetryDelay  = 30 * time.Second
+
+	statusPollInterval = time.Second
+)
+
+// ProcessPhotos runs the due jobs of the uploaded photo files until none is left. A job that fails is tried again
+// later, the photo fails once the job has no attempt left.
+func (p *photos) ProcessPhotos(ctx context.Context) error {
+	for {
+		jobs, err := p.photoJob.Claim(ctx, processBatchSize, processLease)
+		if err != nil {
+			return err
+		} else if len(jobs) == 0 {
+			return nil
+		}
+
+		for _, job := range jobs {
+			err := p.runJob(ctx, job)
+			switch {
+			case err == nil:
+				job.Status, job.Error = models.PhotoJobStatusDone, ""
+			case job.Attempts >= processMaxAttempts:
+				job.Status, job.Error = models.PhotoJobStatusFailed, err.Error()
+			default:
+				job.Status, job.Error = models.PhotoJobStatusPending, err.Error()
+				job.RunAt = time.Now().Add(time.Duration(job.Attempts) * processRetryDelay).Unix()
+			}
+
+			if err := p.photoJob.Finish(ctx, job); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (p *photos) runJob(ctx context.Context, job models.PhotoJobs) error {
+	// A photo in the trash is not processed, the backfill describes its image once it is restored
+	photo, err := p.photo.Get(ctx, models.PhotoParams{ID: job.PhotoID})
+	if errors.GetCode(err) == http.StatusNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	switch job.Kind {
+	case models.PhotoJobImage:
+		return p.processImage(ctx, photo)
+	case models.PhotoJobModerate:
+		return p.moderateImage(ctx, photo)
+	case models.PhotoJobStore:
+		return p.storeUpload(ctx, photo)
+	}
+
+	return fmt.Errorf("unknown photo job %s", job.Kind)
+}
+
+// processImage describes the image of the photo file and stores its renditions. A file that can not be decoded is
+// left without a description and is served as it is. The photo is described even when a rendition fails, the
+// attempt fails then so the rendition is tried again.
+func (p *photos) processImage(ctx context.Context, photo models.Photos) error {
+	fileName := files.GetFileNameFromURL(photo.PhotoURL)
+
+	attrs, err := p.storage.Attributes(ctx, fileName, photoPath)
+	if err != nil {
+		return err
+	}
+
+	img, err := p.downloadImage(ctx, photo)
+	if err != nil && errors.GetCode(err) != http.StatusBadRequest {
+		return err
+	}
+
+	renditionErr := p.createRenditions(ctx, models.PhotoObjects{FileName: fileName, Size: attrs.Size}, img)
+
+	if err := p.photo.SetImage(ctx, photo, describeImage(img)); err != nil {
+		return err
+	}
+
+	return renditionErr
+}
+
+// storeUpload switches the photo of a direct upload to a copy of its file named after its content, as the files
+// uploaded through the API are, and enqueues the jobs of the copy. The file is hashed here rather than when the
+// upload is completed, so a large file never goes through a request, and it is copied within the storage.
+// A file that is already named after its content has been stored by a previous attempt.
+func (p *photos) storeUpload(ctx context.Context, photo models.Photos) error {
+	fileName := files.GetFileNameFromURL(photo.PhotoURL)
+
+	attrs, err := p.storage.Attributes(ctx, fileName, photoPath)
+	if err != nil {
+		return err
+	}
+
+	content := storage.NewObjectReader(ctx, p.storage, fileName, photoPath, attrs)
+	defer content.Close()
+
+	object, err := hashObject(content, fileName)
+	if err != nil || object.FileName == fileName {
+		return err
+	}
+
+	photoURL, err := p.copyObject(ctx, object, fileName)
+	if err != nil {
+		return err
+	}
+
+	// The uploaded file is released along with the switch, the declared content hash is replaced by the verified one
+	switchParam := models.SwitchPhotoFileParams{
+		PhotoID:      photo.ID,
+		UserID:       photo.UserID,
+		CurrentURL:   photo.PhotoURL,
+		CurrentHash:  photo.ContentHash,
+		CurrentImage: photo.PhotoImage,
+		NewURL:       photoURL,
+		NewHash:      object.Hash,
+		Process:      true,
+	}
+	if err := p.photo.SwitchFile(ctx, switchParam); err != nil {
+		p.photoObject.Release(ctx, photoURL)
+		return err
+	}
+
+	return nil
+}
+
+// GetStatus returns the processing status of a photo of the user. A pending photo is waited for up to the requested
+// number of seconds, so a client learns that the processing completed as soon as it does.
+func (p *photos) GetStatus(ctx context.Context, param models.PhotoStatusParams) (models.PhotoStatus, error) {
+	var status models.PhotoStatus
+
+	if err := p.validator.ValidateStruct(param); err != nil {
+		validationErr, _ := p.validator.GetValidationErrors(err)
+		return status, errors.ValidationError(validationErr)
+	}
+
+	photoParam := models.PhotoParams{
+		ID:     param.ID,
+		UserID: appcontext.GetUserID(ctx),
+	}
+
+	deadline := time.NewTimer(time.Duration(param.Wait) * time.Second)
+	defer deadline.Stop()
+
+	poll := time.NewTicker(statusPollInterval)
+	defer poll.Stop()
+
+	for {
+		photo, err := p.photo.Get(ctx, photoParam)
+		if err != nil {
+			return status, err
+		}
+
+		status = models.PhotoStatus{
+			ID:               photo.ID,
+			ProcessingStatus: photo.ProcessingStatus,
+		}
+
+		if photo.ProcessingStatus != models.PhotoStatusPending || param.Wait == 0 {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return status, ctx.Err()
+		case <-deadline.C:
+			param.Wait = 0
+		case <-poll.C:
+		}
+	}
+
+	jobs, err := p.photoJob.GetList(ctx, status.ID)
+	if err != nil {
+		return status, err
+	}
+	status.Jobs = jobs
+
+	return status, nil
+}
@@ -0,0 +1,228 @@
+package photos
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"rakamin-final-task/config"
+	"rakamin-final-task/helpers/errors"
+	"rakamin-final-task/helpers/files"
+	"rakamin-final-task/helpers/imaging"
+	"rakamin-final-task/helpers/storage"
+	"rakamin-final-task/models"
+)
+
+const (
+	renderPath = "renders"
+
+	renderFormatJPEG = "jpeg"
+	renderFormatPNG  = "png"
+)
+
+// Render returns a reader of the photo transformed by a preset, requested through one of the render URLs of the
+// photo. A watermarked URL renders the watermarked copy when the owner has enabled a watermark. The render is
+// stored on the first request and served from the storage afterwards. The caller must close it.
+func (p *photos) Render(ctx context.Context, param models.RenderPhotoParams) (models.Photos, *storage.ObjectReader, error) {
+	var photo models.Photos
+
+	if !p.isRenderPreset(param) {
+		return photo, nil, errors.BadRequest("Render parameters do not match a preset")
+	}
+
+	if p.config.Render.Secret == "" || !hmac.Equal([]byte(param.Signature), []byte(p.signRender(param))) {
+		return photo, nil, errors.Forbidden("Invalid signature")
+	} else if param.Expires < time.Now().Unix() {
+		return photo, nil, errors.Forbidden("Signed URL has expired")
+	}
+
+	// The watermarked URLs are the ones handed out to the other users, they stop working once the photo is hidden
+	photo, err := p.photo.Get(ctx, models.PhotoParams{ID: param.ID, ExcludeHidden: param.Watermark})
+	if err != nil {
+		return photo, nil, err
+	}
+
+	sourceName, sourcePath := files.GetFileNameFromURL(photo.PhotoURL), photoPath
+	if param.Watermark {
+		fileName, ok, err := p.watermarkedFile(ctx, photo)
+		if err != nil {
+			return photo, nil, err
+		} else if ok {
+			sourceName, sourcePath = fileName, watermarkPath
+		}
+	}
+
+	extension, contentType, err := p.renderFormat(param.Format)
+	if err != nil {
+		return photo, nil, err
+	}
+
+	render := models.PhotoRenders{
+		FileName: fmt.Sprintf("%s-%dx%d-%s-q%d%s", strings.TrimSuffix(sourceName, filepath.Ext(sourceName)),
+			param.Width, param.Height, param.Fit, param.Quality, extension),
+		SourceName:  sourceName,
+		SourcePath:  sourcePath,
+		ContentType: contentType,
+	}
+
+	_, err = p.photoRender.Get(ctx, render.FileName)
+	if errors.GetCode(err) == http.StatusNotFound {
+		err = p.createRender(ctx, param, &render)
+	}
+	if err != nil {
+		return photo, nil, err
+	}
+
+	content, err := p.openFile(ctx, render.FileName, renderPath)
+	if err != nil {
+		return photo, nil, err
+	}
+	content.Attrs.ContentType = render.ContentType
+
+	return photo, content, nil
+}
+
+// createRender transforms the source of the render, stores it and adds its row
+func (p *photos) createRender(ctx context.Context, param models.RenderPhotoParams, render *models.PhotoRenders) error {
+	source, err := p.storage.Download(ctx, render.SourceName, render.SourcePath)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	img, err := imaging.Decode(source)
+	if err != nil {
+		return err
+	}
+
+	resized := imaging.Resize(img, param.Width, param.Height, param.Fit)
+
+	var content []byte
+	if encoder, ok := p.encoders[param.Format]; ok {
+		content, err = encoder.Encode(ctx, resized, param.Quality)
+	} else {
+		content, err = imaging.Encode(resized, filepath.Ext(render.FileName), param.Quality)
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := p.storage.UploadFromBytes(ctx, bytes.NewReader(content), render.FileName, renderPath); err != nil {
+		return err
+	}
+
+	render.Size = int64(len(content))
+
+	return p.photoRender.Create(ctx, *render)
+}
+
+// deleteRenders deletes the renders of the source file
+func (p *photos) deleteRenders(ctx context.Context, sourceName string, sourcePath string) error {
+	renders, err := p.photoRender.GetList(ctx, sourceName, sourcePath)
+	if err != nil {
+		return err
+	}
+
+	fileNames := make([]string, 0, len(renders))
+	for _, render := range renders {
+		if err := p.storage.Delete(ctx, render.FileName, renderPath); err != nil {
+			return err
+		}
+		fileNames = append(fileNames, render.FileName)
+	}
+
+	return p.photoRender.Delete(ctx, fileNames)
+}
+
+// renderURLs returns the signed render URLs of the photo for every preset. The URLs stay the same within a window
+// of the signed URL TTL, so the renders can be cached by the clients, and are valid for one to two TTLs.
+func (p *photos) renderURLs(photoID int64, watermark bool) map[string]string {
+	if len(p.config.Render.Presets) == 0 || p.config.Render.Secret == "" {
+		return nil
+	}
+
+	ttl := max(p.config.SignedURLTTLSec, 1)
+	expires := (time.Now().Unix()/ttl + 2) * ttl
+
+	renderURLs := make(map[string]string, len(p.config.Render.Presets))
+	for name, preset := range p.config.Render.Presets {
+		param := models.RenderPhotoParams{
+			ID:        photoID,
+			Width:     preset.Width,
+			Height:    preset.Height,
+			Fit:       preset.Fit,
+			Format:    preset.Format,
+			Quality:   preset.Quality,
+			Watermark: watermark,
+			Expires:   expires,
+		}
+
+		query := url.Values{}
+		query.Set("w", strconv.Itoa(param.Width))
+		query.Set("h", strconv.Itoa(param.Height))
+		query.Set("fit", param.Fit)
+		query.Set("fmt", param.Format)
+		query.Set("q", strconv.Itoa(param.Quality))
+		if param.Watermark {
+			query.Set("wm", "true")
+		}
+		query.Set("expires", strconv.FormatInt(param.Expires, 10))
+		query.Set("signature", p.signRender(param))
+
+		renderURLs[name] = fmt.Sprintf("%s/photos/%d/render?%s", strings.TrimSuffix(p.config.Render.BaseURL, "/"), photoID, query.Encode())
+	}
+
+	return renderURLs
+}
+
+func (p *photos) isRenderPreset(param models.RenderPhotoParams) bool {
+	for _, preset := range p.config.Render.Presets {
+		requested := config.RenderPreset{
+			Width:   param.Width,
+			Height:  param.Height,
+			Fit:     param.Fit,
+			Format:  param.Format,
+			Quality: param.Quality,
+		}
+
+		if preset == requested {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *photos) signRender(param models.RenderPhotoParams) string {
+	mac := hmac.New(sha256.New, []byte(p.config.Render.Secret))
+	fmt.Fprintf(mac, "%d\n%d\n%d\n%s\n%s\n%d\n%t\n%d", param.ID, param.Width, param.Height, param.Fit, param.Format,
+		param.Quality, param.Watermark, param.Expires)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// renderFormat returns the extension and content type of a render format
+func (p *photos) renderFormat(format string) (string, string, error) {
+	switch format {
+	case renderFormatJPEG:
+		return ".jpg", "image/jpeg", nil
+	case renderFormatPNG:
+		return ".png", "image/png", nil
+	}
+
+	encoder, ok := p.encoders[format]
+	if !ok {
+		return "", "", errors.BadRequest("Render format is not supported")
+	}
+
+	return encoder.Extension(), encoder.ContentType(), nil
+}
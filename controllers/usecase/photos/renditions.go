@@ -0,0 +1,176 @@
+package photos
+
+import (
+	"bytes"
+	"context"
+	goerr "errors"
+	"fmt"
+	"image"
+	"mime"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"rakamin-final-task/helpers/imaging"
+	"rakamin-final-task/helpers/storage"
+	"rakamin-final-task/models"
+)
+
+const (
+	renditionPath = "renditions"
+)
+
+// createRenditions stores the image of the object in every configured format it is not already in. A rendition that
+// is not smaller than the file is skipped, the file is then served as it is. The formats that fail are returned
+// as a single error once the others are stored, so they are rendered again by the next attempt.
+func (p *photos) createRenditions(ctx context.Context, object models.PhotoObjects, img image.Image) error {
+	if img == nil || len(p.config.Renditions.Formats) == 0 {
+		return nil
+	}
+
+	renditions, err := p.photoRendition.GetList(ctx, object.FileName)
+	if err != nil {
+		return err
+	}
+
+	rendered := make(map[string]bool, len(renditions))
+	for _, rendition := range renditions {
+		rendered[rendition.Format] = true
+	}
+
+	var errs []error
+	extension := filepath.Ext(object.FileName)
+	for _, format := range p.config.Renditions.Formats {
+		encoder, ok := p.encoders[format]
+		if !ok || rendered[format] || strings.EqualFold(extension, encoder.Extension()) {
+			continue
+		}
+
+		content, err := encoder.Encode(ctx, img, p.renditionQuality(format))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("encode %s rendition: %w", format, err))
+			continue
+		} else if int64(len(content)) >= object.Size {
+			continue
+		}
+
+		rendition := models.PhotoRenditions{
+			ObjectName:  object.FileName,
+			Format:      encoder.Format(),
+			FileName:    strings.TrimSuffix(object.FileName, extension) + encoder.Extension(),
+			ContentType: encoder.ContentType(),
+			Size:        int64(len(content)),
+		}
+
+		if _, err := p.storage.UploadFromBytes(ctx, bytes.NewReader(content), rendition.FileName, renditionPath); err != nil {
+			errs = append(errs, fmt.Errorf("upload %s rendition: %w", format, err))
+			continue
+		}
+
+		// A file without its row would never be found by deleteRenditions
+		if err := p.photoRendition.Create(ctx, rendition); err != nil {
+			p.storage.Delete(ctx, rendition.FileName, renditionPath)
+			errs = append(errs, fmt.Errorf("create %s rendition: %w", format, err))
+		}
+	}
+
+	return goerr.Join(errs...)
+}
+
+// deleteRenditions deletes the rendition files of the object, their rows are deleted along with the object
+func (p *photos) deleteRenditions(ctx context.Context, fileName string) error {
+	renditions, err := p.photoRendition.GetList(ctx, fileName)
+	if err != nil {
+		return err
+	}
+
+	for _, rendition := range renditions {
+		if err := p.storage.Delete(ctx, rendition.FileName, renditionPath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// openContent returns a reader of the file, or of its rendition when the client accepts the rendition format
+// at least as much as the format of the file. The caller must close it.
+func (p *photos) openContent(ctx context.Context, fileName string, accept string) (*storage.ObjectReader, error) {
+	if rendition, ok := p.acceptedRendition(ctx, fileName, accept); ok {
+		attrs, err := p.storage.Attributes(ctx, rendition.FileName, renditionPath)
+		if err == nil {
+			attrs.ContentType = rendition.ContentType
+			return storage.NewObjectReader(ctx, p.storage, rendition.FileName, renditionPath, attrs), nil
+		}
+	}
+
+	return p.openFile(ctx, fileName, photoPath)
+}
+
+func (p *photos) openFile(ctx context.Context, fileName string, path string) (*storage.ObjectReader, error) {
+	attrs, err := p.storage.Attributes(ctx, fileName, path)
+	if err != nil {
+		return nil, err
+	}
+
+	return storage.NewObjectReader(ctx, p.storage, fileName, path, attrs), nil
+}
+
+// acceptedRendition returns the rendition with the highest quality in the Accept header, ties go to the format
+// configured first. Only the types listed in the header count, a wildcard is not enough to serve a rendition.
+func (p *photos) acceptedRendition(ctx context.Context, fileName string, accept string) (models.PhotoRenditions, bool) {
+	var accepted models.PhotoRenditions
+
+	if accept == "" || len(p.config.Renditions.Formats) == 0 {
+		return accepted, false
+	}
+
+	renditions, err := p.photoRendition.GetList(ctx, fileName)
+	if err != nil || len(renditions) == 0 {
+		return accepted, false
+	}
+
+	best := acceptedQuality(accept, mime.TypeByExtension(filepath.Ext(fileName)))
+	found := false
+	for _, format := range p.config.Renditions.Formats {
+		for _, rendition := range renditions {
+			if rendition.Format != format {
+				continue
+			}
+
+			quality := acceptedQuality(accept, rendition.ContentType)
+			if quality > 0 && (quality > best || !found && quality == best) {
+				accepted, best, found = rendition, quality, true
+			}
+		}
+	}
+
+	return accepted, found
+}
+
+func (p *photos) renditionQuality(format string) int {
+	if format == imaging.FormatAVIF {
+		return p.config.Renditions.AVIF.Quality
+	}
+
+	return p.config.Renditions.WebP.Quality
+}
+
+// acceptedQuality returns the quality the Accept header gives to the content type, zero when it is not listed
+func acceptedQuality(accept string, contentType string) float64 {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(part)
+		if err != nil || mediaType != contentType {
+			continue
+		}
+
+		quality, err := strconv.ParseFloat(params["q"], 64)
+		if err != nil {
+			return 1
+		}
+
+		return quality
+	}
+
+	return 0
+}
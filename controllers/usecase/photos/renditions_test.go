@@ -0,0 +1,75 @@
+package photos
+
+import "testing"
+
+func TestAcceptedQuality(t *testing.T) {
+	tests := []struct {
+		name        string
+		accept      string
+		contentType string
+		want        float64
+	}{
+		{
+			name:        "no header",
+			accept:      "",
+			contentType: "image/avif",
+			want:        0,
+		},
+		{
+			name:        "not listed",
+			accept:      "image/webp,image/apng,*/*;q=0.8",
+			contentType: "image/avif",
+			want:        0,
+		},
+		{
+			name:        "wildcards are not a listing",
+			accept:      "image/*,*/*",
+			contentType: "image/webp",
+			want:        0,
+		},
+		{
+			name:        "listed without quality",
+			accept:      "image/avif,image/webp,*/*;q=0.8",
+			contentType: "image/webp",
+			want:        1,
+		},
+		{
+			name:        "listed with quality and spaces",
+			accept:      "image/avif;q=0.9, image/webp ; q=0.5",
+			contentType: "image/webp",
+			want:        0.5,
+		},
+		{
+			name:        "refused",
+			accept:      "image/avif;q=0",
+			contentType: "image/avif",
+			want:        0,
+		},
+		{
+			name:        "case insensitive media type",
+			accept:      "Image/WebP;q=0.7",
+			contentType: "image/webp",
+			want:        0.7,
+		},
+		{
+			name:        "invalid quality counts as listed",
+			accept:      "image/avif;q=high",
+			contentType: "image/avif",
+			want:        1,
+		},
+		{
+			name:        "invalid part skipped",
+			accept:      ";;,image/avif;q=0.3",
+			contentType: "image/avif",
+			want:        0.3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := acceptedQuality(tt.accept, tt.contentType); got != tt.want {
+				t.Errorf("acceptedQuality(%q, %q) = %v, want %v", tt.accept, tt.contentType, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package photos
+
+import (
+	"context"
+
+	"rakamin-final-task/helpers/appcontext"
+	"rakamin-final-task/helpers/errors"
+	"rakamin-final-task/models"
+)
+
+const (
+	defaultSimilarDistance = 10
+	defaultSimilarLimit    = 20
+)
+
+// GetListSimilar returns the photos of the user that look like the given photo by the distance of their
+// perceptual hashes, resized or recompressed copies of an image are usually within a distance of ten
+func (p *photos) GetListSimilar(ctx context.Context, param models.SimilarPhotoParams) ([]models.Photos, error) {
+	if err := p.validator.ValidateStruct(param); err != nil {
+		validationErr, _ := p.validator.GetValidationErrors(err)
+		return nil, errors.ValidationError(validationErr)
+	}
+
+	photo, err := p.photo.Get(ctx, models.PhotoParams{ID: param.ID, UserID: appcontext.GetUserID(ctx)})
+	if err != nil {
+		return nil, err
+	}
+
+	if photo.PerceptualHash == nil {
+		return nil, errors.Conflict("Photo can not be compared, its image has not been hashed")
+	}
+
+	maxDistance := defaultSimilarDistance
+	if param.MaxDistance != nil {
+		maxDistance = *param.MaxDistance
+	}
+
+	limit := param.Limit
+	if limit == 0 {
+		limit = defaultSimilarLimit
+	}
+
+	photos, err := p.photo.GetListSimilar(ctx, photo, maxDistance, limit)
+	if err != nil {
+		return photos, err
+	}
+
+	if err := p.signPhotos(ctx, photos); err != nil {
+		return photos, err
+	}
+
+	return photos, nil
+}
@@ -0,0 +1,336 @@
+package photos
+
+import (
+	"context"
+	goerr "errors"
+	"io"
+	"io/fs"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"rakamin-final-task/helpers/appcontext"
+	"rakamin-final-task/helpers/errors"
+	"rakamin-final-task/helpers/files"
+	"rakamin-final-task/models"
+)
+
+const (
+	defaultTusDir       = "./storage/tus"
+	defaultTusMaxSize   = 50 << 20
+	defaultTusExpirySec = 24 * 60 * 60
+)
+
+// TusMaxSize is the largest upload accepted, as announced in the Tus-Max-Size header
+func (p *photos) TusMaxSize() int64 {
+	if p.config.Tus.MaxSize <= 0 {
+		return defaultTusMaxSize
+	}
+
+	return p.config.Tus.MaxSize
+}
+
+// CreateTusUpload validates the photo described by the metadata, so a client does not upload a file that can
+// not become a photo, and creates the empty upload
+func (p *photos) CreateTusUpload(ctx context.Context, body models.CreateTusUploadParams) (models.TusUploads, error) {
+	var tusUpload models.TusUploads
+
+	userID := appcontext.GetUserID(ctx)
+
+	switch {
+	case body.Length <= 0:
+		return tusUpload, errors.BadRequest("Upload-Length must be greater than zero")
+	case body.Length > p.TusMaxSize():
+		return tusUpload, errors.EntityTooLarge("Upload-Length exceeds Tus-Max-Size")
+	case !strings.HasPrefix(body.Metadata["filetype"], "image/"):
+		return tusUpload, errors.BadRequest("File is not an image")
+	}
+
+	photoParam := tusPhotoParams(body.Metadata)
+	if err := p.validator.ValidateStruct(photoParam); err != nil {
+		validationErr, _ := p.validator.GetValidationErrors(err)
+		return tusUpload, errors.ValidationError(validationErr)
+	}
+
+	expirySec := p.config.Tus.ExpirySec
+	if expirySec <= 0 {
+		expirySec = defaultTusExpirySec
+	}
+
+	// The file name only keeps the extension of the stored file
+	fileName := filepath.Base(body.Metadata["filename"])
+	if fileName == "." || fileName == string(filepath.Separator) {
+		fileName = "photo" + uploadExtensions[body.Metadata["filetype"]]
+	}
+
+	tusUpload = models.TusUploads{
+		UserID:      userID,
+		Length:      body.Length,
+		FileName:    fileName,
+		ContentType: body.Metadata["filetype"],
+		ExpiresAt:   time.Now().Unix() + expirySec,
+		Title:       photoParam.Title,
+		Caption:     photoParam.Caption,
+		Visibility:  photoParam.Visibility,
+		Tags:        strings.Join(photoParam.Tags, ","),
+		CreatedBy:   &userID,
+	}
+
+	tusUpload, err := p.tusUpload.Create(ctx, tusUpload)
+	if err != nil {
+		return tusUpload, err
+	}
+
+	if err := os.MkdirAll(p.tusDir(), 0o755); err != nil {
+		return tusUpload, err
+	}
+
+	file, err := os.Create(p.tusFilePath(tusUpload.ID))
+	if err != nil {
+		return tusUpload, err
+	}
+
+	return tusUpload, file.Close()
+}
+
+func (p *photos) GetTusUpload(ctx context.Context, param models.TusUploadParams) (models.TusUploads, error) {
+	tusUploadParam := models.TusUploadParams{
+		ID:     param.ID,
+		UserID: appcontext.GetUserID(ctx),
+	}
+
+	tusUpload, err := p.tusUpload.Get(ctx, tusUploadParam)
+	if err != nil {
+		return tusUpload, err
+	}
+
+	if tusUpload.PhotoID == nil && tusUpload.ExpiresAt <= time.Now().Unix() {
+		return tusUpload, errors.Gone("Upload has expired")
+	}
+
+	return tusUpload, nil
+}
+
+// PatchTusUpload appends the chunk at the upload offset. The bytes received before the connection is lost are
+// kept, so the client resumes from there. The photo is created once the last byte is received.
+func (p *photos) PatchTusUpload(ctx context.Context, param models.TusUploadParams, body models.PatchTusUploadParams, content io.Reader) (models.TusUploads, error) {
+	unlock := p.lockTusUpload(param.ID)
+	defer unlock()
+
+	tusUpload, err := p.GetTusUpload(ctx, param)
+	if err != nil {
+		return tusUpload, err
+	}
+
+	switch {
+	case tusUpload.PhotoID != nil:
+		return tusUpload, errors.Conflict("Upload has already been completed")
+	case body.Offset != tusUpload.Offset:
+		return tusUpload, errors.Conflict("Upload-Offset does not match the upload offset")
+	}
+
+	tusUploadParam := models.TusUploadParams{ID: tusUpload.ID, UserID: tusUpload.UserID}
+
+	file, err := os.OpenFile(p.tusFilePath(tusUpload.ID), os.O_WRONLY, 0o644)
+	if goerr.Is(err, fs.ErrNotExist) {
+		return tusUpload, errors.NotFound("Upload not found")
+	} else if err != nil {
+		return tusUpload, err
+	}
+	defer file.Close()
+
+	// Bytes written past the saved offset by a request that failed before saving it are overwritten
+	if err := file.Truncate(tusUpload.Offset); err != nil {
+		return tusUpload, err
+	}
+
+	if _, err := file.Seek(tusUpload.Offset, io.SeekStart); err != nil {
+		return tusUpload, err
+	}
+
+	written, copyErr := io.Copy(file, io.LimitReader(content, tusUpload.Length-tusUpload.Offset))
+	if written > 0 {
+		if err := file.Sync(); err != nil {
+			return tusUpload, err
+		}
+
+		if err := p.tusUpload.UpdateOffset(ctx, tusUploadParam, tusUpload.Offset, tusUpload.Offset+written); err != nil {
+			return tusUpload, err
+		}
+		tusUpload.Offset += written
+	}
+
+	if copyErr != nil {
+		return tusUpload, copyErr
+	}
+
+	if tusUpload.Offset < tusUpload.Length {
+		return tusUpload, nil
+	}
+
+	photo, err := p.completeTusUpload(ctx, tusUploadParam, tusUpload)
+	if err != nil {
+		return tusUpload, err
+	}
+	tusUpload.PhotoID = &photo.ID
+
+	os.Remove(p.tusFilePath(tusUpload.ID))
+
+	return tusUpload, nil
+}
+
+// completeTusUpload creates the photo of a complete upload the same way as a photo uploaded in a single request. The
+// photo is linked to the upload along with its creation, so a retried request does not create it twice.
+func (p *photos) completeTusUpload(ctx context.Context, param models.TusUploadParams, tusUpload models.TusUploads) (models.Photos, error) {
+	file, err := os.Open(p.tusFilePath(tusUpload.ID))
+	if err != nil {
+		return models.Photos{}, err
+	}
+	defer file.Close()
+
+	meta := &multipart.FileHeader{
+		Filename: tusUpload.FileName,
+		Header:   textproto.MIMEHeader{"Content-Type": {tusUpload.ContentType}},
+		Size:     tusUpload.Length,
+	}
+
+	photoParam := models.CreatePhotoParams{
+		Title:      tusUpload.Title,
+		Caption:    tusUpload.Caption,
+		Visibility: tusUpload.Visibility,
+	}
+	if tusUpload.Tags != "" {
+		photoParam.Tags = normalizeTags(strings.Split(tusUpload.Tags, ","))
+	}
+
+	if err := p.validator.ValidateStruct(photoParam); err != nil {
+		validationErr, _ := p.validator.GetValidationErrors(err)
+		return models.Photos{}, errors.ValidationError(validationErr)
+	}
+
+	save := func(ctx context.Context, photo models.Photos) (models.Photos, error) {
+		err := p.tusUpload.Complete(ctx, param, &photo)
+		return photo, err
+	}
+
+	return p.create(ctx, photoParam, files.Init(file, meta), save)
+}
+
+// DeleteTusUpload terminates an upload that is not complete yet and deletes its data
+func (p *photos) DeleteTusUpload(ctx context.Context, param models.TusUploadParams) error {
+	unlock := p.lockTusUpload(param.ID)
+	defer unlock()
+
+	tusUpload, err := p.GetTusUpload(ctx, param)
+	if err != nil {
+		return err
+	}
+
+	if tusUpload.PhotoID != nil {
+		return errors.Conflict("Upload has already been completed")
+	}
+
+	if err := p.removeTusFile(tusUpload.ID); err != nil {
+		return err
+	}
+
+	return p.tusUpload.Delete(ctx, []int64{tusUpload.ID})
+}
+
+// PurgeExpiredTusUploads deletes the data of the uploads that expired before being completed, then the uploads
+func (p *photos) PurgeExpiredTusUploads(ctx context.Context) error {
+	tusUploads, err := p.tusUpload.GetExpired(ctx, time.Now().Unix(), purgeBatchSize)
+	if err != nil {
+		return err
+	}
+
+	var purgeErr error
+	purgedIDs := []int64{}
+	for _, tusUpload := range tusUploads {
+		if err := p.removeTusFile(tusUpload.ID); err != nil {
+			purgeErr = err
+			continue
+		}
+
+		purgedIDs = append(purgedIDs, tusUpload.ID)
+	}
+
+	if err := p.tusUpload.Delete(ctx, purgedIDs); err != nil {
+		return err
+	}
+
+	return purgeErr
+}
+
+// tusLock serializes the requests of an upload, it is dropped once no request holds or waits for it
+type tusLock struct {
+	sync.Mutex
+	refs int
+}
+
+func (p *photos) lockTusUpload(id int64) func() {
+	p.tusLocksMu.Lock()
+	if p.tusLocks == nil {
+		p.tusLocks = map[int64]*tusLock{}
+	}
+	lock, ok := p.tusLocks[id]
+	if !ok {
+		lock = &tusLock{}
+		p.tusLocks[id] = lock
+	}
+	lock.refs++
+	p.tusLocksMu.Unlock()
+
+	lock.Lock()
+
+	return func() {
+		lock.Unlock()
+
+		p.tusLocksMu.Lock()
+		lock.refs--
+		if lock.refs == 0 {
+			delete(p.tusLocks, id)
+		}
+		p.tusLocksMu.Unlock()
+	}
+}
+
+func (p *photos) removeTusFile(id int64) error {
+	err := os.Remove(p.tusFilePath(id))
+	if err != nil && !goerr.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+func (p *photos) tusDir() string {
+	if p.config.Tus.Dir == "" {
+		return defaultTusDir
+	}
+
+	return p.config.Tus.Dir
+}
+
+func (p *photos) tusFilePath(id int64) string {
+	return filepath.Join(p.tusDir(), strconv.FormatInt(id, 10))
+}
+
+func tusPhotoParams(metadata map[string]string) models.CreatePhotoParams {
+	photoParam := models.CreatePhotoParams{
+		Title:      metadata["title"],
+		Caption:    metadata["caption"],
+		Visibility: metadata["visibility"],
+	}
+
+	if metadata["tags"] != "" {
+		photoParam.Tags = normalizeTags(strings.Split(metadata["tags"], ","))
+	}
+
+	return photoParam
+}
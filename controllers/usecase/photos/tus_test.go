@@ -0,0 +1,248 @@
+package photos
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"rakamin-final-task/config"
+	tusUploadRepo "rakamin-final-task/controllers/repository/tus_uploads"
+	"rakamin-final-task/helpers/errors"
+	"rakamin-final-task/models"
+)
+
+// fakeTusUploads keeps a single upload, its offset is only moved from the offset it is expected at like the
+// conditional update of the repository
+type fakeTusUploads struct {
+	tusUploadRepo.Interface
+
+	mu        sync.Mutex
+	tusUpload models.TusUploads
+
+	// movedBy is added to the offset before it is updated, as if another request had moved it in the meantime
+	movedBy int64
+}
+
+func (f *fakeTusUploads) Get(ctx context.Context, params models.TusUploadParams) (models.TusUploads, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if params.ID != f.tusUpload.ID || params.UserID != f.tusUpload.UserID {
+		return models.TusUploads{}, errors.NotFound("Upload not found")
+	}
+
+	return f.tusUpload, nil
+}
+
+func (f *fakeTusUploads) UpdateOffset(ctx context.Context, params models.TusUploadParams, from int64, to int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.tusUpload.Offset += f.movedBy
+	if f.tusUpload.Offset != from {
+		return errors.Conflict("Upload offset has been changed by another request")
+	}
+
+	f.tusUpload.Offset = to
+	return nil
+}
+
+// newTusTest returns a usecase with the upload and its data already received
+func newTusTest(t *testing.T, tusUpload models.TusUploads, data string) (*photos, *fakeTusUploads) {
+	t.Helper()
+
+	repo := &fakeTusUploads{tusUpload: tusUpload}
+	p := &photos{
+		tusUpload: repo,
+		config:    config.Storage{Tus: config.Tus{Dir: t.TempDir()}},
+	}
+
+	if err := os.WriteFile(p.tusFilePath(tusUpload.ID), []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	return p, repo
+}
+
+func TestPatchTusUpload(t *testing.T) {
+	photoID := int64(3)
+
+	tests := []struct {
+		name       string
+		offset     int64
+		photoID    *int64
+		expiresAt  int64
+		data       string
+		movedBy    int64
+		bodyOffset int64
+		chunk      string
+		wantCode   int64
+		wantOffset int64
+		wantData   string
+	}{
+		{
+			name:       "first chunk",
+			chunk:      "abcd",
+			wantOffset: 4,
+			wantData:   "abcd",
+		},
+		{
+			name:       "resumed at the saved offset",
+			offset:     4,
+			data:       "abcd",
+			bodyOffset: 4,
+			chunk:      "ef",
+			wantOffset: 6,
+			wantData:   "abcdef",
+		},
+		{
+			name:       "bytes past the saved offset are overwritten",
+			offset:     4,
+			data:       "abcdXYZ",
+			bodyOffset: 4,
+			chunk:      "ef",
+			wantOffset: 6,
+			wantData:   "abcdef",
+		},
+		{
+			name:       "offset behind the upload",
+			offset:     4,
+			data:       "abcd",
+			bodyOffset: 2,
+			chunk:      "ef",
+			wantCode:   http.StatusConflict,
+			wantOffset: 4,
+			wantData:   "abcd",
+		},
+		{
+			name:       "offset ahead of the upload",
+			offset:     4,
+			data:       "abcd",
+			bodyOffset: 6,
+			chunk:      "ef",
+			wantCode:   http.StatusConflict,
+			wantOffset: 4,
+			wantData:   "abcd",
+		},
+		{
+			name:       "offset moved by another request",
+			offset:     4,
+			data:       "abcd",
+			movedBy:    2,
+			bodyOffset: 4,
+			chunk:      "ef",
+			wantCode:   http.StatusConflict,
+			wantOffset: 6,
+			wantData:   "abcdef",
+		},
+		{
+			name:       "completed upload",
+			offset:     10,
+			photoID:    &photoID,
+			data:       "abcdefghij",
+			bodyOffset: 10,
+			chunk:      "k",
+			wantCode:   http.StatusConflict,
+			wantOffset: 10,
+			wantData:   "abcdefghij",
+		},
+		{
+			name:       "expired upload",
+			offset:     4,
+			expiresAt:  1,
+			data:       "abcd",
+			bodyOffset: 4,
+			chunk:      "ef",
+			wantCode:   http.StatusGone,
+			wantOffset: 4,
+			wantData:   "abcd",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expiresAt := tt.expiresAt
+			if expiresAt == 0 {
+				expiresAt = time.Now().Add(time.Hour).Unix()
+			}
+
+			tusUpload := models.TusUploads{ID: 1, Length: 10, Offset: tt.offset, PhotoID: tt.photoID, ExpiresAt: expiresAt}
+
+			p, repo := newTusTest(t, tusUpload, tt.data)
+			repo.movedBy = tt.movedBy
+
+			_, err := p.PatchTusUpload(context.Background(), models.TusUploadParams{ID: 1}, models.PatchTusUploadParams{Offset: tt.bodyOffset}, strings.NewReader(tt.chunk))
+			if tt.wantCode != 0 {
+				if errors.GetCode(err) != tt.wantCode {
+					t.Errorf("PatchTusUpload() error = %v, want a %d", err, tt.wantCode)
+				}
+			} else if err != nil {
+				t.Fatalf("PatchTusUpload() error = %v", err)
+			}
+
+			if repo.tusUpload.Offset != tt.wantOffset {
+				t.Errorf("PatchTusUpload() saved offset %d, want %d", repo.tusUpload.Offset, tt.wantOffset)
+			}
+
+			data, err := os.ReadFile(p.tusFilePath(1))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(data) != tt.wantData {
+				t.Errorf("PatchTusUpload() left %q, want %q", data, tt.wantData)
+			}
+		})
+	}
+}
+
+// TestPatchTusUploadConcurrent sends the same offset twice at once, the requests of an upload run one after the
+// other so only the first one is appended
+func TestPatchTusUploadConcurrent(t *testing.T) {
+	tusUpload := models.TusUploads{ID: 1, Length: 10, ExpiresAt: time.Now().Add(time.Hour).Unix()}
+	p, repo := newTusTest(t, tusUpload, "")
+
+	chunks := []string{"abcd", "wxyz"}
+	errs := make([]error, len(chunks))
+
+	var wg sync.WaitGroup
+	for i, chunk := range chunks {
+		wg.Add(1)
+		go func(i int, chunk string) {
+			defer wg.Done()
+			_, errs[i] = p.PatchTusUpload(context.Background(), models.TusUploadParams{ID: 1}, models.PatchTusUploadParams{Offset: 0}, strings.NewReader(chunk))
+		}(i, chunk)
+	}
+	wg.Wait()
+
+	appended := -1
+	for i, err := range errs {
+		switch {
+		case err == nil && appended == -1:
+			appended = i
+		case errors.GetCode(err) != http.StatusConflict:
+			t.Errorf("PatchTusUpload() error = %v, want a single success and a conflict", err)
+		}
+	}
+
+	if appended == -1 {
+		t.Fatal("PatchTusUpload() appended no chunk")
+	}
+
+	data, err := os.ReadFile(p.tusFilePath(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != chunks[appended] || repo.tusUpload.Offset != 4 {
+		t.Errorf("PatchTusUpload() left %q at offset %d, want %q at offset 4", data, repo.tusUpload.Offset, chunks[appended])
+	}
+
+	if len(p.tusLocks) != 0 {
+		t.Errorf("PatchTusUpload() kept %d upload locks, want none", len(p.tusLocks))
+	}
+}
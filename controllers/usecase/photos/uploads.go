@@ -0,0 +1,320 @@
+package photos
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"rakamin-final-task/helpers/appcontext"
+	"rakamin-final-task/helpers/errors"
+	"rakamin-final-task/models"
+)
+
+const (
+	defaultDirectUploadMaxSize = 50 << 20
+
+	// sniffSize is the number of bytes http.DetectContentType looks at
+	sniffSize = 512
+)
+
+var uploadExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+	"image/avif": ".avif",
+}
+
+// CreateUpload reserves a file in the storage and returns the signed URL the client uploads it to
+func (p *photos) CreateUpload(ctx context.Context, body models.CreatePhotoUploadParams) (models.PhotoUploads, error) {
+	var photoUpload models.PhotoUploads
+
+	if err := p.validator.ValidateStruct(body); err != nil {
+		validationErr, _ := p.validator.GetValidationErrors(err)
+		return photoUpload, errors.ValidationError(validationErr)
+	}
+
+	maxSize := p.config.DirectUploadMaxSize
+	if maxSize <= 0 {
+		maxSize = defaultDirectUploadMaxSize
+	}
+
+	if body.Size > maxSize {
+		return photoUpload, errors.BadRequest(fmt.Sprintf("File can not be larger than %d bytes", maxSize))
+	}
+
+	userID := appcontext.GetUserID(ctx)
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return photoUpload, err
+	}
+
+	// format: {userID}_{timestamp}_{random}, the name is reserved before the file exists
+	fileName := fmt.Sprintf("%d_%d_%s%s", userID, time.Now().Unix(), hex.EncodeToString(suffix), uploadExtensions[body.ContentType])
+
+	signedUpload, err := p.storage.SignedUploadURL(ctx, fileName, photoPath, body.ContentType, body.Checksum)
+	if err != nil {
+		return photoUpload, err
+	}
+
+	photoUpload = models.PhotoUploads{
+		UserID:      userID,
+		FileName:    fileName,
+		ContentType: body.ContentType,
+		Size:        body.Size,
+		Checksum:    body.Checksum,
+		ContentHash: strings.ToLower(body.ContentHash),
+		Status:      models.PhotoUploadStatusPending,
+		ExpiresAt:   signedUpload.ExpiresAt,
+		CreatedBy:   &userID,
+	}
+
+	photoUpload, err = p.photoUpload.Create(ctx, photoUpload)
+	if err != nil {
+		return photoUpload, err
+	}
+
+	photoUpload.UploadURL = signedUpload.URL
+	photoUpload.UploadMethod = signedUpload.Method
+	photoUpload.UploadHeaders = signedUpload.Headers
+
+	return photoUpload, nil
+}
+
+// CompleteUpload verifies the uploaded file against the declared size, type and checksum, then creates its photo.
+// A file that does not match is deleted so the client can upload it again while the upload has not expired.
+// The file is never read through the API: the duplicates are found by the declared content hash, and the photo
+// switches to a copy of the file named after its verified content once the store job has hashed it.
+func (p *photos) CompleteUpload(ctx context.Context, param models.PhotoUploadParams, body models.CreatePhotoParams) (models.Photos, error) {
+	var photo models.Photos
+
+	userID := appcontext.GetUserID(ctx)
+
+	body.Tags = normalizeTags(body.Tags)
+	if err := p.validator.ValidateStruct(body); err != nil {
+		validationErr, _ := p.validator.GetValidationErrors(err)
+		return photo, errors.ValidationError(validationErr)
+	}
+
+	flag, err := p.moderateText(ctx, body.Title, body.Caption)
+	if err != nil {
+		return photo, err
+	}
+
+	uploadParam := models.PhotoUploadParams{
+		ID:     param.ID,
+		UserID: userID,
+		Status: models.PhotoUploadStatusPending,
+	}
+
+	photoUpload, err := p.photoUpload.Get(ctx, uploadParam)
+	if err != nil {
+		return photo, err
+	}
+
+	if photoUpload.ExpiresAt <= time.Now().Unix() {
+		return photo, errors.Gone("Photo upload has expired")
+	}
+
+	if err := p.verifyUpload(ctx, photoUpload); err != nil {
+		if errors.GetCode(err) == http.StatusBadRequest {
+			p.storage.Delete(ctx, photoUpload.FileName, photoPath)
+		}
+		return photo, err
+	}
+
+	duplicateOf, err := p.duplicateOf(ctx, userID, photoUpload.ContentHash, body.OnDuplicate)
+	if err != nil {
+		return photo, err
+	}
+
+	// The uploaded file is referred to until the photo switches to the copy, then it is purged as any other file
+	object := models.PhotoObjects{
+		FileName: photoUpload.FileName,
+		Size:     photoUpload.Size,
+	}
+	if err := p.photoObject.Acquire(ctx, object); err != nil {
+		return photo, err
+	}
+
+	photoURL, err := p.storage.ObjectURL(photoUpload.FileName, photoPath)
+	if err != nil {
+		p.photoObject.Release(ctx, photoUpload.FileName)
+		return photo, err
+	}
+
+	if body.Visibility == "" {
+		body.Visibility = models.VisibilityPrivate
+	}
+
+	photo = models.Photos{
+		Title:            body.Title,
+		Caption:          body.Caption,
+		UserID:           userID,
+		PhotoURL:         photoURL,
+		ContentHash:      photoUpload.ContentHash,
+		ProcessingStatus: models.PhotoStatusPending,
+		Visibility:       body.Visibility,
+		Tags:             tagsByName(body.Tags),
+	}
+	if flag != nil {
+		photo.ModerationStatus = models.PhotoModerationFlagged
+	}
+
+	if err := p.photoUpload.Complete(ctx, uploadParam, &photo); err != nil {
+		p.photoObject.Release(ctx, photoURL)
+		return photo, err
+	}
+
+	if err := p.flagPhoto(ctx, photo.ID, flag); err != nil {
+		return photo, err
+	}
+
+	photo, err = p.getSigned(ctx, models.PhotoParams{ID: photo.ID, UserID: userID})
+	if err != nil {
+		return photo, err
+	}
+	photo.DuplicateOf = duplicateOf
+
+	return photo, nil
+}
+
+func (p *photos) verifyUpload(ctx context.Context, photoUpload models.PhotoUploads) error {
+	attrs, err := p.storage.Attributes(ctx, photoUpload.FileName, photoPath)
+	if errors.GetCode(err) == http.StatusNotFound {
+		return errors.NotFound("File has not been uploaded")
+	} else if err != nil {
+		return err
+	}
+
+	if attrs.Size != photoUpload.Size {
+		return errors.BadRequest("Uploaded file size does not match the declared size")
+	}
+
+	if attrs.ContentType != photoUpload.ContentType {
+		return errors.BadRequest("Uploaded file type does not match the declared type")
+	}
+
+	// The stored type is the one declared by the client, the content itself has to be of that type as well
+	head, err := p.storage.DownloadRange(ctx, photoUpload.FileName, photoPath, 0, sniffSize)
+	if err != nil {
+		return err
+	}
+	defer head.Close()
+
+	sniff, err := io.ReadAll(head)
+	if err != nil {
+		return err
+	}
+
+	if sniffContentType(sniff) != photoUpload.ContentType {
+		return errors.BadRequest("Uploaded file is not a " + photoUpload.ContentType + " image")
+	}
+
+	checksum, err := p.storage.Checksum(ctx, photoUpload.FileName, photoPath)
+	if err != nil {
+		return err
+	}
+
+	if checksum != photoUpload.Checksum {
+		return errors.BadRequest("Uploaded file checksum does not match the declared checksum")
+	}
+
+	return nil
+}
+
+// sniffContentType detects the type of the content from its first bytes. AVIF is unknown to http.DetectContentType,
+// it is told by the brands of the ftyp box its files start with.
+func sniffContentType(head []byte) string {
+	contentType := http.DetectContentType(head)
+	if contentType == "application/octet-stream" && isAVIF(head) {
+		return "image/avif"
+	}
+
+	return contentType
+}
+
+// isAVIF tells whether the head of the content is an ftyp box with an AVIF image or sequence brand,
+// either as the major brand or as one of the compatible brands
+func isAVIF(head []byte) bool {
+	if len(head) < 16 || string(head[4:8]) != "ftyp" {
+		return false
+	}
+
+	boxSize := int(binary.BigEndian.Uint32(head[:4]))
+	if boxSize < 16 {
+		return false
+	}
+
+	// The major brand is followed by the minor version, the compatible brands come after it
+	brands := [][]byte{head[8:12]}
+	for offset := 16; offset+4 <= min(boxSize, len(head)); offset += 4 {
+		brands = append(brands, head[offset:offset+4])
+	}
+
+	for _, brand := range brands {
+		if string(brand) == "avif" || string(brand) == "avis" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// PutSignedContent stores a file uploaded to a signed upload URL of the local storage. At most one byte past the
+// declared size is stored, which is enough for an oversized file to fail the completion.
+func (p *photos) PutSignedContent(ctx context.Context, param models.SignedFileParams, content io.Reader) error {
+	if param.Path != photoPath {
+		return errors.NotFound("File not found")
+	}
+
+	if err := p.storage.VerifySignedURL(http.MethodPut, param.FileName, param.Path, param.Expires, param.Signature); err != nil {
+		return err
+	}
+
+	uploadParam := models.PhotoUploadParams{
+		FileName: param.FileName,
+		Status:   models.PhotoUploadStatusPending,
+	}
+
+	photoUpload, err := p.photoUpload.Get(ctx, uploadParam)
+	if err != nil {
+		return err
+	}
+
+	_, err = p.storage.UploadFromReader(ctx, io.LimitReader(content, photoUpload.Size+1), photoUpload.FileName, photoPath)
+
+	return err
+}
+
+// PurgeExpiredUploads deletes the files of the uploads that expired before being completed, then the uploads
+func (p *photos) PurgeExpiredUploads(ctx context.Context) error {
+	photoUploads, err := p.photoUpload.GetExpired(ctx, time.Now().Unix(), purgeBatchSize)
+	if err != nil {
+		return err
+	}
+
+	var purgeErr error
+	purgedIDs := []int64{}
+	for _, photoUpload := range photoUploads {
+		if err := p.storage.Delete(ctx, photoUpload.FileName, photoPath); err != nil {
+			purgeErr = err
+			continue
+		}
+
+		purgedIDs = append(purgedIDs, photoUpload.ID)
+	}
+
+	if err := p.photoUpload.Delete(ctx, purgedIDs); err != nil {
+		return err
+	}
+
+	return purgeErr
+}
@@ -0,0 +1,85 @@
+package photos
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// ftypBox builds the ftyp box an ISO media file starts with, followed by the start of the next box
+func ftypBox(majorBrand string, compatibleBrands ...string) []byte {
+	box := make([]byte, 16, 16+4*len(compatibleBrands)+8)
+	binary.BigEndian.PutUint32(box, uint32(16+4*len(compatibleBrands)))
+	copy(box[4:], "ftyp"+majorBrand)
+	for _, brand := range compatibleBrands {
+		box = append(box, brand...)
+	}
+
+	return append(box, 0, 0, 0, 8, 'm', 'e', 't', 'a')
+}
+
+func TestSniffContentType(t *testing.T) {
+	tests := []struct {
+		name string
+		head []byte
+		want string
+	}{
+		{
+			name: "jpeg",
+			head: []byte("\xff\xd8\xff\xe0\x00\x10JFIF\x00"),
+			want: "image/jpeg",
+		},
+		{
+			name: "png",
+			head: []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"),
+			want: "image/png",
+		},
+		{
+			name: "avif major brand",
+			head: ftypBox("avif", "mif1", "miaf"),
+			want: "image/avif",
+		},
+		{
+			name: "avif sequence major brand",
+			head: ftypBox("avis", "msf1"),
+			want: "image/avif",
+		},
+		{
+			name: "avif compatible brand",
+			head: ftypBox("mif1", "mif1", "avif"),
+			want: "image/avif",
+		},
+		{
+			name: "heic",
+			head: ftypBox("heic", "mif1", "heic"),
+			want: "application/octet-stream",
+		},
+		{
+			name: "brand past the end of the box",
+			head: append(ftypBox("mif1"), "avif"...),
+			want: "application/octet-stream",
+		},
+		{
+			name: "box too small",
+			head: append([]byte{0, 0, 0, 8}, "ftypavif\x00\x00\x00\x00"...),
+			want: "application/octet-stream",
+		},
+		{
+			name: "truncated",
+			head: []byte("\x00\x00\x00\x1cftypav"),
+			want: "application/octet-stream",
+		},
+		{
+			name: "arbitrary bytes",
+			head: []byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08},
+			want: "application/octet-stream",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sniffContentType(tt.head); got != tt.want {
+				t.Errorf("sniffContentType() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
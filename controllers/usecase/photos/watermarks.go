@@ -0,0 +1,263 @@
+package photos
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"rakamin-final-task/helpers/errors"
+	"rakamin-final-task/helpers/files"
+	"rakamin-final-task/helpers/imaging"
+	"rakamin-final-task/models"
+)
+
+const (
+	watermarkPath = "watermarked"
+	logoPath      = "watermarks"
+
+	watermarkQuality = 90
+)
+
+// RenderWatermarks renders the watermarked copies of the photos served to other users that have none yet or whose
+// copy is outdated, after the owner changed their settings or the photo file. The copies are only rendered here,
+// never while a photo is requested. The copies of the owners who disabled their watermark are deleted.
+func (p *photos) RenderWatermarks(ctx context.Context) error {
+	var renderErr error
+
+	settings := map[int64]models.Watermarks{}
+
+	afterID := int64(0)
+	for {
+		photos, err := p.watermark.GetListOutdated(ctx, afterID, purgeBatchSize)
+		if err != nil {
+			return err
+		} else if len(photos) == 0 {
+			break
+		}
+
+		for _, photo := range photos {
+			afterID = photo.ID
+
+			watermark, ok := settings[photo.UserID]
+			if !ok {
+				watermark, err = p.watermark.Get(ctx, photo.UserID)
+				if err != nil {
+					renderErr = err
+					continue
+				}
+				settings[photo.UserID] = watermark
+			}
+
+			if _, err := p.renderWatermark(ctx, photo, watermark); err != nil {
+				renderErr = err
+			}
+		}
+	}
+
+	disabled, err := p.watermark.GetListDisabled(ctx, purgeBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, photoWatermark := range disabled {
+		if err := p.deleteWatermark(ctx, photoWatermark); err != nil {
+			renderErr = err
+		}
+	}
+
+	return renderErr
+}
+
+// watermarkedFile returns the file name of the watermarked copy of the photo when its owner has enabled a watermark.
+// The copy is not found until RenderWatermarks has rendered it, an outdated copy is served until it is rendered
+// again. It is not ok when the photo is served as it is.
+func (p *photos) watermarkedFile(ctx context.Context, photo models.Photos) (string, bool, error) {
+	watermark, err := p.watermark.Get(ctx, photo.UserID)
+	if errors.GetCode(err) == http.StatusNotFound {
+		return "", false, nil
+	} else if err != nil {
+		return "", false, err
+	}
+
+	if !watermark.Enabled {
+		return "", false, nil
+	}
+
+	photoWatermark, err := p.watermark.GetPhoto(ctx, photo.ID)
+	if errors.GetCode(err) == http.StatusNotFound {
+		return "", true, errors.NotFound("Watermarked photo has not been rendered yet")
+	} else if err != nil {
+		return "", true, err
+	}
+
+	if photoWatermark.FileName == "" {
+		return "", true, errors.NotFound("Photo file can not be watermarked")
+	}
+
+	return photoWatermark.FileName, true, nil
+}
+
+// renderWatermark stores a watermarked copy of the photo file and deletes the previous copy. A file that can not
+// be decoded gets a copy without file, so it is not rendered again until the file or the settings change and
+// it is never served without watermark.
+func (p *photos) renderWatermark(ctx context.Context, photo models.Photos, watermark models.Watermarks) (models.PhotoWatermarks, error) {
+	photoWatermark := models.PhotoWatermarks{
+		PhotoID:   photo.ID,
+		SourceURL: photo.PhotoURL,
+		Version:   watermark.Version,
+	}
+
+	previous, err := p.watermark.GetPhoto(ctx, photo.ID)
+	if err != nil && errors.GetCode(err) != http.StatusNotFound {
+		return photoWatermark, err
+	}
+
+	img, err := p.downloadImage(ctx, photo)
+	if err != nil && errors.GetCode(err) != http.StatusBadRequest {
+		return photoWatermark, err
+	}
+
+	if img != nil {
+		photoWatermark.FileName, err = p.uploadWatermarked(ctx, photo, watermark, img)
+		if err != nil {
+			return photoWatermark, err
+		}
+	}
+
+	if err := p.watermark.SavePhoto(ctx, photoWatermark); err != nil {
+		return photoWatermark, err
+	}
+
+	if previous.FileName != "" && previous.FileName != photoWatermark.FileName {
+		if err := p.deleteRenders(ctx, previous.FileName, watermarkPath); err == nil {
+			p.storage.Delete(ctx, previous.FileName, watermarkPath)
+		}
+	}
+
+	return photoWatermark, nil
+}
+
+// uploadWatermarked draws the watermark on the image and uploads it, as a JPEG when the photo file is a JPEG and
+// as a PNG otherwise. The copy is named after the photo, the version of the settings and the photo file.
+func (p *photos) uploadWatermarked(ctx context.Context, photo models.Photos, watermark models.Watermarks, img image.Image) (string, error) {
+	mark := imaging.Mark{
+		Text:     watermark.Text,
+		Position: watermark.Position,
+		Opacity:  watermark.Opacity,
+	}
+
+	if watermark.Type == models.WatermarkTypeImage {
+		logo, err := p.downloadLogo(ctx, watermark)
+		if err != nil {
+			return "", err
+		}
+		mark.Logo = logo
+	}
+
+	marked, err := imaging.Watermark(img, mark)
+	if err != nil {
+		return "", err
+	}
+
+	sourceName := files.GetFileNameFromURL(photo.PhotoURL)
+	sourceExtension := filepath.Ext(sourceName)
+
+	extension := ".png"
+	if strings.EqualFold(sourceExtension, ".jpg") || strings.EqualFold(sourceExtension, ".jpeg") {
+		extension = ".jpg"
+	}
+
+	content, err := imaging.Encode(marked, extension, watermarkQuality)
+	if err != nil {
+		return "", err
+	}
+
+	fileName := fmt.Sprintf("%d-%d-%s%s", photo.ID, watermark.Version, strings.TrimSuffix(sourceName, sourceExtension), extension)
+	if _, err := p.storage.UploadFromBytes(ctx, bytes.NewReader(content), fileName, watermarkPath); err != nil {
+		return "", err
+	}
+
+	return fileName, nil
+}
+
+func (p *photos) downloadLogo(ctx context.Context, watermark models.Watermarks) (image.Image, error) {
+	content, err := p.storage.Download(ctx, files.GetFileNameFromURL(watermark.LogoURL), logoPath)
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+
+	return imaging.Decode(content)
+}
+
+func (p *photos) deleteWatermark(ctx context.Context, photoWatermark models.PhotoWatermarks) error {
+	if photoWatermark.FileName != "" {
+		if err := p.deleteRenders(ctx, photoWatermark.FileName, watermarkPath); err != nil {
+			return err
+		}
+
+		if err := p.storage.Delete(ctx, photoWatermark.FileName, watermarkPath); err != nil {
+			return err
+		}
+	}
+
+	return p.watermark.DeletePhoto(ctx, photoWatermark.PhotoID)
+}
+
+// deleteWatermarkFiles deletes the watermarked copies of the purged photos, their rows are deleted along with the photos
+func (p *photos) deleteWatermarkFiles(ctx context.Context, photoIDs []int64) error {
+	photoWatermarks, err := p.watermark.GetListPhoto(ctx, photoIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, photoWatermark := range photoWatermarks {
+		if photoWatermark.FileName == "" {
+			continue
+		}
+
+		if err := p.deleteRenders(ctx, photoWatermark.FileName, watermarkPath); err != nil {
+			return err
+		}
+
+		if err := p.storage.Delete(ctx, photoWatermark.FileName, watermarkPath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// signPublicPhoto signs the URL of the watermarked copy of the photo instead of its file when its owner has enabled
+// a watermark, the renders are watermarked as well. The URL is left empty while the copy is pending or when the file
+// can not be watermarked.
+func (p *photos) signPublicPhoto(ctx context.Context, photo *models.Photos) error {
+	fileName, ok, err := p.watermarkedFile(ctx, *photo)
+	if errors.GetCode(err) == http.StatusNotFound {
+		photo.PhotoURL = ""
+		return nil
+	} else if err != nil {
+		return err
+	} else if !ok {
+		fileName = files.GetFileNameFromURL(photo.PhotoURL)
+	}
+
+	path := photoPath
+	if ok {
+		path = watermarkPath
+	}
+
+	signedURL, err := p.storage.SignedURL(ctx, fileName, path)
+	if err != nil {
+		return err
+	}
+
+	photo.PhotoURL = signedURL
+	photo.RenderURLs = p.renderURLs(photo.ID, true)
+
+	return nil
+}
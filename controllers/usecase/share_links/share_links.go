@@ -0,0 +1,206 @@
+package share_links
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"time"
+
+	"rakamin-final-task/config"
+	photoRepo "rakamin-final-task/controllers/repository/photos"
+	shareLinkRepo "rakamin-final-task/controllers/repository/share_links"
+	photoUsecase "rakamin-final-task/controllers/usecase/photos"
+	"rakamin-final-task/helpers/appcontext"
+	"rakamin-final-task/helpers/errors"
+	"rakamin-final-task/helpers/password"
+	"rakamin-final-task/helpers/validator"
+	"rakamin-final-task/models"
+)
+
+type Interface interface {
+	Create(ctx context.Context, param models.ShareLinkParams, body models.CreateShareLinkParams) (models.ShareLinks, error)
+	GetList(ctx context.Context, param models.ShareLinkParams) ([]models.ShareLinks, error)
+	Revoke(ctx context.Context, param models.ShareLinkParams) (models.ShareLinks, error)
+	Resolve(ctx context.Context, param models.ResolveShareLinkParams) (models.Photos, error)
+}
+
+const (
+	tokenByteLength = 32
+
+	// maxPasswordAttempts wrong passwords lock a link for passwordLockoutSec, so its password can not be guessed
+	maxPasswordAttempts = 5
+	passwordLockoutSec  = 15 * 60
+)
+
+type shareLinks struct {
+	shareLink shareLinkRepo.Interface
+	photo     photoRepo.Interface
+	config    config.Server
+	photos    photoUsecase.Interface
+	validator validator.Interface
+}
+
+type InitParam struct {
+	ShareLinkRepo shareLinkRepo.Interface
+	PhotoRepo     photoRepo.Interface
+	Config        config.Server
+	PhotoUsecase  photoUsecase.Interface
+	Validator     validator.Interface
+}
+
+func Init(param InitParam) Interface {
+	return &shareLinks{
+		shareLink: param.ShareLinkRepo,
+		photo:     param.PhotoRepo,
+		config:    param.Config,
+		photos:    param.PhotoUsecase,
+		validator: param.Validator,
+	}
+}
+
+// Create makes a share link of a single photo. The photos are not grouped in albums, so there is no album to share.
+func (s *shareLinks) Create(ctx context.Context, param models.ShareLinkParams, body models.CreateShareLinkParams) (models.ShareLinks, error) {
+	var shareLink models.ShareLinks
+
+	if err := s.validator.ValidateStruct(body); err != nil {
+		validationErr, _ := s.validator.GetValidationErrors(err)
+		return shareLink, errors.ValidationError(validationErr)
+	}
+
+	userID := appcontext.GetUserID(ctx)
+
+	// Only the owner can share a photo
+	photoParam := models.PhotoParams{
+		ID:     param.PhotoID,
+		UserID: userID,
+	}
+	if _, err := s.photo.Get(ctx, photoParam); err != nil {
+		return shareLink, err
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		return shareLink, err
+	}
+
+	shareLink = models.ShareLinks{
+		Token:     token,
+		PhotoID:   param.PhotoID,
+		UserID:    userID,
+		CreatedBy: &userID,
+	}
+
+	if body.ExpiresInSec > 0 {
+		expiresAt := time.Now().Unix() + body.ExpiresInSec
+		shareLink.ExpiresAt = &expiresAt
+	}
+
+	if body.MaxViews > 0 {
+		shareLink.MaxViews = &body.MaxViews
+	}
+
+	if body.Password != "" {
+		hashedPassword, err := password.Hash(body.Password, s.config.Password.SaltRound)
+		if err != nil {
+			return shareLink, err
+		}
+
+		shareLink.Password = hashedPassword
+		shareLink.HasPassword = true
+	}
+
+	return s.shareLink.Create(ctx, shareLink)
+}
+
+func (s *shareLinks) GetList(ctx context.Context, param models.ShareLinkParams) ([]models.ShareLinks, error) {
+	shareLinkParam := models.ShareLinkParams{
+		PhotoID: param.PhotoID,
+		UserID:  appcontext.GetUserID(ctx),
+	}
+
+	return s.shareLink.GetList(ctx, shareLinkParam)
+}
+
+func (s *shareLinks) Revoke(ctx context.Context, param models.ShareLinkParams) (models.ShareLinks, error) {
+	userID := appcontext.GetUserID(ctx)
+
+	shareLinkParam := models.ShareLinkParams{
+		ID:      param.ID,
+		PhotoID: param.PhotoID,
+		UserID:  userID,
+	}
+
+	shareLinkField := models.ShareLinks{
+		IsRevoked: &[]bool{true}[0],
+		UpdatedBy: &userID,
+	}
+
+	if _, err := s.shareLink.Update(ctx, shareLinkField, shareLinkParam); err != nil {
+		return models.ShareLinks{}, err
+	}
+
+	return s.shareLink.Get(ctx, shareLinkParam)
+}
+
+// Resolve returns the shared photo and counts the view, the link has to be active and the password has to match.
+// A link is locked for a while after too many wrong passwords.
+func (s *shareLinks) Resolve(ctx context.Context, param models.ResolveShareLinkParams) (models.Photos, error) {
+	var photo models.Photos
+
+	shareLinkParam := models.ShareLinkParams{
+		Token: param.Token,
+	}
+
+	shareLink, err := s.shareLink.Get(ctx, shareLinkParam)
+	if err != nil {
+		return photo, err
+	}
+
+	now := time.Now().Unix()
+	switch {
+	case shareLink.IsRevoked != nil && *shareLink.IsRevoked:
+		return photo, errors.Gone("Share link has been revoked")
+	case shareLink.ExpiresAt != nil && *shareLink.ExpiresAt <= now:
+		return photo, errors.Gone("Share link has expired")
+	case shareLink.MaxViews != nil && shareLink.ViewCount >= *shareLink.MaxViews:
+		return photo, errors.Gone("Share link has reached its view limit")
+	case shareLink.Photo == nil || shareLink.Photo.IsHidden():
+		return photo, errors.NotFound("Photo not found")
+	}
+
+	if shareLink.HasPassword {
+		if err := s.shareLink.ReservePasswordAttempt(ctx, shareLinkParam, now, maxPasswordAttempts, passwordLockoutSec); err != nil {
+			return photo, err
+		}
+
+		if !password.Compare(shareLink.Password, param.Password) {
+			return photo, errors.Unauthorized("Wrong share link password")
+		}
+
+		if err := s.shareLink.ResetPasswordAttempts(ctx, shareLinkParam); err != nil {
+			return photo, err
+		}
+	}
+
+	if err := s.shareLink.IncrementViewCount(ctx, shareLinkParam, now); err != nil {
+		return photo, err
+	}
+
+	// The link only grants access to the file for as long as the signed URL lives, and only to the watermarked copy
+	// when the owner has enabled a watermark
+	photo = *shareLink.Photo
+	if err := s.photos.SignPhoto(ctx, &photo, 0); err != nil {
+		return photo, err
+	}
+
+	return photo, nil
+}
+
+func generateToken() (string, error) {
+	token := make([]byte, tokenByteLength)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(token), nil
+}
@@ -0,0 +1,131 @@
+package share_links
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	shareLinkRepo "rakamin-final-task/controllers/repository/share_links"
+	photoUsecase "rakamin-final-task/controllers/usecase/photos"
+	"rakamin-final-task/helpers/errors"
+	"rakamin-final-task/helpers/password"
+	"rakamin-final-task/models"
+)
+
+// fakeShareLinks keeps a single link and records the password attempts made on it
+type fakeShareLinks struct {
+	shareLinkRepo.Interface
+
+	shareLink models.ShareLinks
+	isLocked  bool
+
+	reserved int
+	resets   int
+	views    int
+}
+
+func (f *fakeShareLinks) Get(ctx context.Context, params models.ShareLinkParams) (models.ShareLinks, error) {
+	return f.shareLink, nil
+}
+
+func (f *fakeShareLinks) ReservePasswordAttempt(ctx context.Context, params models.ShareLinkParams, now int64, maxAttempts int64, lockoutSec int64) error {
+	if f.isLocked {
+		return errors.TooManyRequests("Too many wrong share link passwords, try again later")
+	}
+
+	f.reserved++
+	return nil
+}
+
+func (f *fakeShareLinks) ResetPasswordAttempts(ctx context.Context, params models.ShareLinkParams) error {
+	f.resets++
+	return nil
+}
+
+func (f *fakeShareLinks) IncrementViewCount(ctx context.Context, params models.ShareLinkParams, now int64) error {
+	f.views++
+	return nil
+}
+
+type fakePhotos struct {
+	photoUsecase.Interface
+}
+
+func (f *fakePhotos) SignPhoto(ctx context.Context, photo *models.Photos, userID int64) error {
+	return nil
+}
+
+func TestResolvePassword(t *testing.T) {
+	hashed, err := password.Hash("secret", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		hasPassword  bool
+		isLocked     bool
+		password     string
+		wantCode     int64
+		wantReserved int
+		wantResets   int
+		wantViews    int
+	}{
+		{
+			name:      "no password",
+			wantViews: 1,
+		},
+		{
+			name:         "right password resets the attempts",
+			hasPassword:  true,
+			password:     "secret",
+			wantReserved: 1,
+			wantResets:   1,
+			wantViews:    1,
+		},
+		{
+			name:         "wrong password keeps its attempt",
+			hasPassword:  true,
+			password:     "guess",
+			wantCode:     http.StatusUnauthorized,
+			wantReserved: 1,
+		},
+		{
+			name:        "locked link refuses even the right password",
+			hasPassword: true,
+			isLocked:    true,
+			password:    "secret",
+			wantCode:    http.StatusTooManyRequests,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeShareLinks{
+				shareLink: models.ShareLinks{
+					Token:       "token",
+					HasPassword: tt.hasPassword,
+					Password:    hashed,
+					Photo:       &models.Photos{ID: 1},
+				},
+				isLocked: tt.isLocked,
+			}
+
+			usecase := Init(InitParam{ShareLinkRepo: repo, PhotoUsecase: &fakePhotos{}})
+
+			_, err := usecase.Resolve(context.Background(), models.ResolveShareLinkParams{Token: "token", Password: tt.password})
+			if tt.wantCode != 0 {
+				if errors.GetCode(err) != tt.wantCode {
+					t.Errorf("Resolve() error = %v, want a %d", err, tt.wantCode)
+				}
+			} else if err != nil {
+				t.Errorf("Resolve() error = %v", err)
+			}
+
+			if repo.reserved != tt.wantReserved || repo.resets != tt.wantResets || repo.views != tt.wantViews {
+				t.Errorf("Resolve() reserved %d attempts, reset %d times and counted %d views, want %d, %d and %d",
+					repo.reserved, repo.resets, repo.views, tt.wantReserved, tt.wantResets, tt.wantViews)
+			}
+		})
+	}
+}
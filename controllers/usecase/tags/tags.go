@@ -0,0 +1,58 @@
+package tags
+
+import (
+	"context"
+	"strings"
+
+	tagRepo "rakamin-final-task/controllers/repository/tags"
+	"rakamin-final-task/helpers/appcontext"
+	"rakamin-final-task/models"
+)
+
+type Interface interface {
+	GetList(ctx context.Context) ([]models.Tags, error)
+	Suggest(ctx context.Context, param models.TagParams) ([]models.Tags, error)
+}
+
+const (
+	defaultSuggestLimit = 10
+	maxSuggestLimit     = 50
+)
+
+type tags struct {
+	tag tagRepo.Interface
+}
+
+type InitParam struct {
+	TagRepo tagRepo.Interface
+}
+
+func Init(param InitParam) Interface {
+	return &tags{
+		tag: param.TagRepo,
+	}
+}
+
+func (t *tags) GetList(ctx context.Context) ([]models.Tags, error) {
+	tagParam := models.TagParams{
+		UserID: appcontext.GetUserID(ctx),
+	}
+
+	return t.tag.GetList(ctx, tagParam)
+}
+
+func (t *tags) Suggest(ctx context.Context, param models.TagParams) ([]models.Tags, error) {
+	tagParam := models.TagParams{
+		UserID: appcontext.GetUserID(ctx),
+		Prefix: strings.ToLower(strings.TrimPrefix(strings.TrimSpace(param.Prefix), "#")),
+		Limit:  param.Limit,
+	}
+
+	if tagParam.Limit <= 0 {
+		tagParam.Limit = defaultSuggestLimit
+	} else if tagParam.Limit > maxSuggestLimit {
+		tagParam.Limit = maxSuggestLimit
+	}
+
+	return t.tag.Suggest(ctx, tagParam)
+}
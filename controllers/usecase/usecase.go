@@ -3,24 +3,40 @@ package usecase
 import (
 	"rakamin-final-task/config"
 	"rakamin-final-task/controllers/repository"
-	userUsecase "rakamin-final-task/controllers/usecase/users"
+	engagementUsecase "rakamin-final-task/controllers/usecase/engagements"
+	moderationUsecase "rakamin-final-task/controllers/usecase/moderation"
 	photoUsecase "rakamin-final-task/controllers/usecase/photos"
+	shareLinkUsecase "rakamin-final-task/controllers/usecase/share_links"
+	tagUsecase "rakamin-final-task/controllers/usecase/tags"
+	userUsecase "rakamin-final-task/controllers/usecase/users"
+	watermarkUsecase "rakamin-final-task/controllers/usecase/watermarks"
+	"rakamin-final-task/helpers/imaging"
 	"rakamin-final-task/helpers/jwt"
+	"rakamin-final-task/helpers/moderation"
 	"rakamin-final-task/helpers/storage"
 	"rakamin-final-task/helpers/validator"
 )
 
 type Usecase struct {
-	Users userUsecase.Interface
-	Photos photoUsecase.Interface
+	Users       userUsecase.Interface
+	Photos      photoUsecase.Interface
+	Tags        tagUsecase.Interface
+	ShareLinks  shareLinkUsecase.Interface
+	Engagements engagementUsecase.Interface
+	Watermarks  watermarkUsecase.Interface
+	Moderation  moderationUsecase.Interface
 }
 
 type InitParam struct {
-	Repo         repository.Repository
-	ServerConf   config.Server
-	JwtLib       jwt.Interface
-	ValidatorLib validator.Interface
-	StorageLib   storage.Interface
+	Repo           repository.Repository
+	ServerConf     config.Server
+	StorageConf    config.Storage
+	ModerationConf config.Moderation
+	JwtLib         jwt.Interface
+	ValidatorLib   validator.Interface
+	StorageLib     storage.Interface
+	EncoderLibs    map[string]imaging.Encoder
+	ModeratorLib   moderation.Moderator
 }
 
 func Init(param InitParam) Usecase {
@@ -32,12 +48,63 @@ func Init(param InitParam) Usecase {
 		Validator:     param.ValidatorLib,
 	}
 	photoInitParam := photoUsecase.InitParam{
-		PhotoRepo: param.Repo.Photos,
-		Storage:   param.StorageLib,
+		PhotoRepo:           param.Repo.Photos,
+		PhotoVersionRepo:    param.Repo.PhotoVersions,
+		PhotoUploadRepo:     param.Repo.PhotoUploads,
+		PhotoObjectRepo:     param.Repo.PhotoObjects,
+		PhotoRenditionRepo:  param.Repo.PhotoRenditions,
+		WatermarkRepo:       param.Repo.Watermarks,
+		PhotoRenderRepo:     param.Repo.PhotoRenders,
+		PhotoJobRepo:        param.Repo.PhotoJobs,
+		PhotoModerationRepo: param.Repo.PhotoModerations,
+		TusUploadRepo:       param.Repo.TusUploads,
+		Config:              param.StorageConf,
+		Storage:             param.StorageLib,
+		Validator:           param.ValidatorLib,
+		Encoders:            param.EncoderLibs,
+		Moderator:           param.ModeratorLib,
+	}
+	photos := photoUsecase.Init(photoInitParam)
+
+	tagInitParam := tagUsecase.InitParam{
+		TagRepo: param.Repo.Tags,
+	}
+	shareLinkInitParam := shareLinkUsecase.InitParam{
+		ShareLinkRepo: param.Repo.ShareLink,
+		PhotoRepo:     param.Repo.Photos,
+		Config:        param.ServerConf,
+		PhotoUsecase:  photos,
+		Validator:     param.ValidatorLib,
+	}
+	engagementInitParam := engagementUsecase.InitParam{
+		PhotoRepo:    param.Repo.Photos,
+		LikeRepo:     param.Repo.Likes,
+		CommentRepo:  param.Repo.Comments,
+		PhotoUsecase: photos,
+		Validator:    param.ValidatorLib,
+	}
+	watermarkInitParam := watermarkUsecase.InitParam{
+		WatermarkRepo: param.Repo.Watermarks,
+		Storage:       param.StorageLib,
+		Validator:     param.ValidatorLib,
+	}
+	moderationInitParam := moderationUsecase.InitParam{
+		PhotoModerationRepo: param.Repo.PhotoModerations,
+		PhotoRepo:           param.Repo.Photos,
+		UserRepo:            param.Repo.Users,
+		ReportRepo:          param.Repo.Reports,
+		Config:              param.ModerationConf,
+		Storage:             param.StorageLib,
+		Validator:           param.ValidatorLib,
 	}
 
 	return Usecase{
-		Users: userUsecase.Init(userInitParam),
-		Photos: photoUsecase.Init(photoInitParam),
+		Users:       userUsecase.Init(userInitParam),
+		Photos:      photos,
+		Tags:        tagUsecase.Init(tagInitParam),
+		ShareLinks:  shareLinkUsecase.Init(shareLinkInitParam),
+		Engagements: engagementUsecase.Init(engagementInitParam),
+		Watermarks:  watermarkUsecase.Init(watermarkInitParam),
+		Moderation:  moderationUsecase.Init(moderationInitParam),
 	}
 }
@@ -22,6 +22,7 @@ type Interface interface {
 	UpdateUser(ctx context.Context, body models.UpdateUserParams, params models.UserParams) (models.Users, error)
 	GetUserProfile(ctx context.Context) (models.Users, error)
 	DeactivateUser(ctx context.Context, params models.UserParams) (models.Users, error)
+	CheckModerator(ctx context.Context) error
 }
 
 type users struct {
@@ -236,3 +237,21 @@ func (u *users) DeactivateUser(ctx context.Context, params models.UserParams) (m
 
 	return userRes, nil
 }
+
+// CheckModerator fails unless the user is a moderator
+func (u *users) CheckModerator(ctx context.Context) error {
+	userParam := models.UserParams{
+		ID: appcontext.GetUserID(ctx),
+	}
+
+	userRes, err := u.user.Get(ctx, userParam)
+	if err != nil {
+		return err
+	}
+
+	if userRes.IsModerator == nil || !*userRes.IsModerator {
+		return errors.Forbidden("Only moderators are allowed to do this")
+	}
+
+	return nil
+}
@@ -0,0 +1,177 @@
+package watermarks
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	watermarkRepo "rakamin-final-task/controllers/repository/watermarks"
+	"rakamin-final-task/helpers/appcontext"
+	"rakamin-final-task/helpers/errors"
+	"rakamin-final-task/helpers/files"
+	"rakamin-final-task/helpers/imaging"
+	"rakamin-final-task/helpers/storage"
+	"rakamin-final-task/helpers/validator"
+	"rakamin-final-task/models"
+)
+
+type Interface interface {
+	Get(ctx context.Context) (models.Watermarks, error)
+	Update(ctx context.Context, body models.UpdateWatermarkParams, logoFile *files.File) (models.Watermarks, error)
+}
+
+const (
+	logoPath = "watermarks"
+
+	defaultOpacity = 0.5
+)
+
+type watermarks struct {
+	watermark watermarkRepo.Interface
+	storage   storage.Interface
+	validator validator.Interface
+}
+
+type InitParam struct {
+	WatermarkRepo watermarkRepo.Interface
+	Storage       storage.Interface
+	Validator     validator.Interface
+}
+
+func Init(param InitParam) Interface {
+	return &watermarks{
+		watermark: param.WatermarkRepo,
+		storage:   param.Storage,
+		validator: param.Validator,
+	}
+}
+
+// Get returns the watermark settings of the user, a user who never changed them has a disabled text watermark
+func (w *watermarks) Get(ctx context.Context) (models.Watermarks, error) {
+	watermark, err := w.get(ctx, appcontext.GetUserID(ctx))
+	if err != nil {
+		return watermark, err
+	}
+
+	if err := w.signLogo(ctx, &watermark); err != nil {
+		return watermark, err
+	}
+
+	return watermark, nil
+}
+
+// Update changes the watermark settings of the user. Every change is a new version of the settings, the public
+// photos of the user are rendered again with it by the watermark job.
+func (w *watermarks) Update(ctx context.Context, body models.UpdateWatermarkParams, logoFile *files.File) (models.Watermarks, error) {
+	var watermark models.Watermarks
+
+	if err := w.validator.ValidateStruct(body); err != nil {
+		validationErr, _ := w.validator.GetValidationErrors(err)
+		return watermark, errors.ValidationError(validationErr)
+	}
+
+	userID := appcontext.GetUserID(ctx)
+
+	current, err := w.get(ctx, userID)
+	if err != nil {
+		return watermark, err
+	}
+
+	watermark = current
+	watermark.Version = current.Version + 1
+	if body.Enabled != nil {
+		watermark.Enabled = *body.Enabled
+	}
+	if body.Type != "" {
+		watermark.Type = body.Type
+	}
+	if body.Text != nil {
+		watermark.Text = strings.TrimSpace(*body.Text)
+	}
+	if body.Position != "" {
+		watermark.Position = body.Position
+	}
+	if body.Opacity != nil {
+		watermark.Opacity = *body.Opacity
+	}
+
+	if logoFile != nil {
+		watermark.LogoURL, err = w.uploadLogo(ctx, watermark, logoFile)
+		if err != nil {
+			return watermark, err
+		}
+	}
+
+	switch {
+	case watermark.Enabled && watermark.Type == models.WatermarkTypeText && watermark.Text == "":
+		err = errors.BadRequest("A text watermark needs a text")
+	case watermark.Enabled && watermark.Type == models.WatermarkTypeImage && watermark.LogoURL == "":
+		err = errors.BadRequest("An image watermark needs a logo")
+	default:
+		watermark, err = w.watermark.Save(ctx, watermark, current.Version)
+	}
+	if err != nil {
+		if watermark.LogoURL != current.LogoURL {
+			w.storage.Delete(ctx, files.GetFileNameFromURL(watermark.LogoURL), logoPath)
+		}
+		return watermark, err
+	}
+
+	if current.LogoURL != "" && watermark.LogoURL != current.LogoURL {
+		w.storage.Delete(ctx, files.GetFileNameFromURL(current.LogoURL), logoPath)
+	}
+
+	if err := w.signLogo(ctx, &watermark); err != nil {
+		return watermark, err
+	}
+
+	return watermark, nil
+}
+
+func (w *watermarks) get(ctx context.Context, userID int64) (models.Watermarks, error) {
+	watermark, err := w.watermark.Get(ctx, userID)
+	if errors.GetCode(err) == http.StatusNotFound {
+		return models.Watermarks{
+			UserID:   userID,
+			Type:     models.WatermarkTypeText,
+			Position: imaging.PositionBottomRight,
+			Opacity:  defaultOpacity,
+		}, nil
+	}
+
+	return watermark, err
+}
+
+// uploadLogo stores the logo under the new version of the settings, so the current logo is kept until they are saved
+func (w *watermarks) uploadLogo(ctx context.Context, watermark models.Watermarks, logoFile *files.File) (string, error) {
+	if _, err := imaging.Decode(logoFile.Content); err != nil {
+		return "", err
+	}
+
+	if _, err := logoFile.Content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	fileName := fmt.Sprintf("%d-%d%s", watermark.UserID, watermark.Version, strings.ToLower(filepath.Ext(logoFile.Meta.Filename)))
+
+	return w.storage.UploadFromReader(ctx, logoFile.Content, fileName, logoPath)
+}
+
+// signLogo replaces the stored URL of the logo with a signed URL that expires, like the URLs of the photos
+func (w *watermarks) signLogo(ctx context.Context, watermark *models.Watermarks) error {
+	if watermark.LogoURL == "" {
+		return nil
+	}
+
+	signedURL, err := w.storage.SignedURL(ctx, files.GetFileNameFromURL(watermark.LogoURL), logoPath)
+	if err != nil {
+		return err
+	}
+
+	watermark.LogoURL = signedURL
+
+	return nil
+}
@@ -64,5 +64,26 @@ func initPostgres(dbLogger log.LogInterface, config config.SQL) (*gorm.DB, error
 func (db *DB) Migrate() {
 	db.ORM.AutoMigrate(&models.Users{})
 	db.ORM.AutoMigrate(&models.UserToken{})
+	db.ORM.AutoMigrate(&models.Tags{})
+
+	// Join table has to be registered before the photos are migrated
+	db.ORM.SetupJoinTable(&models.Photos{}, "Tags", &models.PhotoTags{})
 	db.ORM.AutoMigrate(&models.Photos{})
+	db.ORM.AutoMigrate(&models.PhotoTags{})
+	db.migrateSearch()
+	db.ORM.AutoMigrate(&models.ShareLinks{})
+	db.ORM.AutoMigrate(&models.PhotoLikes{})
+	db.ORM.AutoMigrate(&models.Comments{})
+	db.ORM.AutoMigrate(&models.PhotoVersions{})
+	db.migrateSimilar()
+	db.ORM.AutoMigrate(&models.PhotoUploads{})
+	db.ORM.AutoMigrate(&models.TusUploads{})
+	db.ORM.AutoMigrate(&models.PhotoObjects{})
+	db.ORM.AutoMigrate(&models.PhotoRenditions{})
+	db.ORM.AutoMigrate(&models.Watermarks{})
+	db.ORM.AutoMigrate(&models.PhotoWatermarks{})
+	db.ORM.AutoMigrate(&models.PhotoRenders{})
+	db.ORM.AutoMigrate(&models.PhotoJobs{})
+	db.ORM.AutoMigrate(&models.PhotoModerations{})
+	db.ORM.AutoMigrate(&models.Reports{})
 }
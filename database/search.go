@@ -0,0 +1,63 @@
+package database
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	defaultSearchLanguages = []string{"indonesian", "english"}
+	searchLanguagePattern  = regexp.MustCompile(`^[a-z_]+$`)
+)
+
+// SearchLanguages returns the text search configurations used for the photo full-text search
+func (db *DB) SearchLanguages() []string {
+	languages := []string{}
+	for _, language := range db.Config.FullTextSearch.Languages {
+		language = strings.ToLower(strings.TrimSpace(language))
+		if searchLanguagePattern.MatchString(language) {
+			languages = append(languages, language)
+		}
+	}
+
+	if len(languages) == 0 {
+		return defaultSearchLanguages
+	}
+
+	return languages
+}
+
+// migrateSearch maintains the generated search vector of the photos and its GIN index.
+// The configured languages are kept as the column comment, so the column is only rebuilt when they change.
+func (db *DB) migrateSearch() {
+	languages := db.SearchLanguages()
+	signature := strings.Join(languages, ",")
+
+	var currentSignature string
+	db.ORM.Raw(`
+		SELECT COALESCE(col_description(attrelid, attnum), '')
+		FROM pg_attribute
+		WHERE attrelid = 'photos'::regclass AND attname = 'search_vector' AND NOT attisdropped
+	`).Scan(&currentSignature)
+
+	if currentSignature == signature {
+		return
+	}
+
+	vectors := []string{}
+	for _, language := range languages {
+		vectors = append(vectors,
+			fmt.Sprintf("setweight(to_tsvector('%s'::regconfig, coalesce(title, '')), 'A')", language),
+			fmt.Sprintf("setweight(to_tsvector('%s'::regconfig, coalesce(caption, '')), 'B')", language),
+		)
+	}
+
+	db.ORM.Exec("ALTER TABLE photos DROP COLUMN IF EXISTS search_vector")
+	db.ORM.Exec(fmt.Sprintf(
+		"ALTER TABLE photos ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (%s) STORED",
+		strings.Join(vectors, " || "),
+	))
+	db.ORM.Exec("CREATE INDEX IF NOT EXISTS idx_photos_search_vector ON photos USING GIN (search_vector)")
+	db.ORM.Exec(fmt.Sprintf("COMMENT ON COLUMN photos.search_vector IS '%s'", signature))
+}
@@ -0,0 +1,35 @@
+package database
+
+import (
+	"fmt"
+)
+
+const (
+	// PerceptualHashBands is the number of bands the perceptual hash of a photo is indexed by
+	PerceptualHashBands = 4
+
+	// PerceptualHashBandBits is the number of bits of a band, the bands cover the 64 bits of the hash
+	PerceptualHashBandBits = 64 / PerceptualHashBands
+)
+
+// PerceptualHashBand returns the SQL expression of a band of the perceptual hash, as indexed by migrateSimilar.
+// The expression has to be written the same way for the index to serve it.
+func PerceptualHashBand(band int) string {
+	return fmt.Sprintf("((perceptual_hash >> %d) & %d)", band*PerceptualHashBandBits, 1<<PerceptualHashBandBits-1)
+}
+
+// migrateSimilar indexes every band of the perceptual hash along with the owner of the photo. Two hashes within a
+// Hamming distance have at least one band within the distance divided by the number of bands, so the indexes find the
+// candidates of a similar photo search without comparing every hash.
+func (db *DB) migrateSimilar() {
+	// The former indexes of the whole hash could not serve a Hamming distance
+	db.ORM.Exec("DROP INDEX IF EXISTS idx_photos_perceptual_hash")
+	db.ORM.Exec("DROP INDEX IF EXISTS idx_photo_versions_perceptual_hash")
+
+	for band := 0; band < PerceptualHashBands; band++ {
+		db.ORM.Exec(fmt.Sprintf(
+			"CREATE INDEX IF NOT EXISTS idx_photos_perceptual_hash_band_%d ON photos (user_id, %s) WHERE perceptual_hash IS NOT NULL",
+			band, PerceptualHashBand(band),
+		))
+	}
+}
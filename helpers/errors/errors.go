@@ -20,6 +20,11 @@ const (
 	UnprocessableEntityType = "HTTPStatusUnprocessableEntity"
 	ConflictType            = "HTTPStatusConflict"
 	ForbiddenType           = "HTTPStatusForbidden"
+	GoneType                = "HTTPStatusGone"
+	PreconditionFailedType  = "HTTPStatusPreconditionFailed"
+	EntityTooLargeType      = "HTTPStatusRequestEntityTooLarge"
+	UnsupportedMediaType    = "HTTPStatusUnsupportedMediaType"
+	TooManyRequestsType     = "HTTPStatusTooManyRequests"
 )
 
 func (e *Errors) Error() string {
@@ -74,6 +79,26 @@ func Forbidden(message string) error {
 
 }
 
+func Gone(message string) error {
+	return NewWithCode(http.StatusGone, message, GoneType)
+}
+
+func PreconditionFailed(message string) error {
+	return NewWithCode(http.StatusPreconditionFailed, message, PreconditionFailedType)
+}
+
+func EntityTooLarge(message string) error {
+	return NewWithCode(http.StatusRequestEntityTooLarge, message, EntityTooLargeType)
+}
+
+func UnsupportedMedia(message string) error {
+	return NewWithCode(http.StatusUnsupportedMediaType, message, UnsupportedMediaType)
+}
+
+func TooManyRequests(message string) error {
+	return NewWithCode(http.StatusTooManyRequests, message, TooManyRequestsType)
+}
+
 func GetType(err error) string {
 	if err == nil {
 		return "HTTPStatusOK"
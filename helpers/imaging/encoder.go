@@ -0,0 +1,100 @@
+package imaging
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+)
+
+const (
+	FormatWebP = "webp"
+	FormatAVIF = "avif"
+)
+
+// Encoder writes images in a format the standard library can not encode
+type Encoder interface {
+	Format() string
+	ContentType() string
+	Extension() string
+	Encode(ctx context.Context, img image.Image, quality int) ([]byte, error)
+}
+
+// commandEncoder encodes through a command line tool, the image is handed over as a PNG file
+type commandEncoder struct {
+	format      string
+	contentType string
+	path        string
+	args        func(quality int, input string, output string) []string
+}
+
+// InitWebP encodes with cwebp from libwebp
+func InitWebP(path string) Encoder {
+	return &commandEncoder{
+		format:      FormatWebP,
+		contentType: "image/webp",
+		path:        path,
+		args: func(quality int, input string, output string) []string {
+			return []string{"-quiet", "-metadata", "none", "-q", strconv.Itoa(quality), input, "-o", output}
+		},
+	}
+}
+
+// InitAVIF encodes with avifenc from libavif
+func InitAVIF(path string) Encoder {
+	return &commandEncoder{
+		format:      FormatAVIF,
+		contentType: "image/avif",
+		path:        path,
+		args: func(quality int, input string, output string) []string {
+			return []string{"-q", strconv.Itoa(quality), input, output}
+		},
+	}
+}
+
+func (e *commandEncoder) Format() string {
+	return e.format
+}
+
+func (e *commandEncoder) ContentType() string {
+	return e.contentType
+}
+
+func (e *commandEncoder) Extension() string {
+	return "." + e.format
+}
+
+// Encode writes the image with a quality from 0 to 100
+func (e *commandEncoder) Encode(ctx context.Context, img image.Image, quality int) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "encode-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	input := filepath.Join(dir, "input.png")
+	output := filepath.Join(dir, "output"+e.Extension())
+
+	var source bytes.Buffer
+	if err := png.Encode(&source, img); err != nil {
+		return nil, err
+	}
+
+	if err := os.WriteFile(input, source.Bytes(), 0600); err != nil {
+		return nil, err
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, e.path, e.args(quality, input, output)...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s encoding failed: %w: %s", e.format, err, bytes.TrimSpace(stderr.Bytes()))
+	}
+
+	return os.ReadFile(output)
+}
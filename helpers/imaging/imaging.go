@@ -0,0 +1,64 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+	"rakamin-final-task/helpers/errors"
+)
+
+// Decode reads a JPEG, PNG, GIF or WebP image, other formats are not supported
+func Decode(r io.Reader) (image.Image, error) {
+	img, _, err := image.Decode(r)
+	if err == image.ErrFormat {
+		return nil, errors.BadRequest("Image format is not supported")
+	} else if err != nil {
+		return nil, err
+	}
+
+	return img, nil
+}
+
+// Encode writes the image as a JPEG of the given quality for the jpg and jpeg extensions and as a PNG otherwise
+func Encode(img image.Image, extension string, quality int) ([]byte, error) {
+	var content bytes.Buffer
+
+	switch strings.ToLower(extension) {
+	case ".jpg", ".jpeg":
+		if err := jpeg.Encode(&content, img, &jpeg.Options{Quality: quality}); err != nil {
+			return nil, err
+		}
+	default:
+		if err := png.Encode(&content, img); err != nil {
+			return nil, err
+		}
+	}
+
+	return content.Bytes(), nil
+}
+
+// DHash returns the difference hash of the image. The image is shrunk to 9x8 grayscale pixels and every bit tells
+// whether a pixel is brighter than its right neighbour, so resized or recompressed copies get close hashes.
+func DHash(img image.Image) uint64 {
+	gray := image.NewGray(image.Rect(0, 0, 9, 8))
+	draw.BiLinear.Scale(gray, gray.Bounds(), img, img.Bounds(), draw.Src, nil)
+
+	var hash uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			hash <<= 1
+			if gray.GrayAt(x, y).Y > gray.GrayAt(x+1, y).Y {
+				hash |= 1
+			}
+		}
+	}
+
+	return hash
+}
@@ -0,0 +1,174 @@
+package imaging
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strings"
+
+	"golang.org/x/image/draw"
+)
+
+const (
+	base83Chars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+
+	// The placeholders are computed from a thumbnail, they do not need more detail than that
+	blurHashSize      = 32
+	dominantColorSize = 64
+)
+
+// BlurHash encodes a blurred placeholder of the image with 4 components along its longer side and 3 along the other,
+// see https://blurha.sh for the format
+func BlurHash(img image.Image) string {
+	xComponents, yComponents := 4, 3
+	if img.Bounds().Dy() > img.Bounds().Dx() {
+		xComponents, yComponents = 3, 4
+	}
+
+	thumb := thumbnail(img, blurHashSize)
+	width, height := thumb.Bounds().Dx(), thumb.Bounds().Dy()
+
+	factors := make([][3]float64, 0, xComponents*yComponents)
+	for j := 0; j < yComponents; j++ {
+		for i := 0; i < xComponents; i++ {
+			normalisation := 2.0
+			if i == 0 && j == 0 {
+				normalisation = 1
+			}
+
+			var factor [3]float64
+			for y := 0; y < height; y++ {
+				for x := 0; x < width; x++ {
+					basis := normalisation *
+						math.Cos(math.Pi*float64(i)*float64(x)/float64(width)) *
+						math.Cos(math.Pi*float64(j)*float64(y)/float64(height))
+
+					pixel := thumb.Pix[thumb.PixOffset(x, y):]
+					for c := 0; c < 3; c++ {
+						factor[c] += basis * srgbToLinear(pixel[c])
+					}
+				}
+			}
+
+			for c := range factor {
+				factor[c] /= float64(width * height)
+			}
+			factors = append(factors, factor)
+		}
+	}
+
+	var hash strings.Builder
+	hash.WriteString(encode83((xComponents-1)+(yComponents-1)*9, 1))
+
+	maximum := 0.0
+	for _, factor := range factors[1:] {
+		for _, value := range factor {
+			maximum = math.Max(maximum, math.Abs(value))
+		}
+	}
+
+	quantisedMaximum := int(math.Max(0, math.Min(82, math.Floor(maximum*166-0.5))))
+	maximum = float64(quantisedMaximum+1) / 166
+	hash.WriteString(encode83(quantisedMaximum, 1))
+
+	dc := factors[0]
+	hash.WriteString(encode83(linearToSRGB(dc[0])<<16+linearToSRGB(dc[1])<<8+linearToSRGB(dc[2]), 4))
+
+	for _, factor := range factors[1:] {
+		var ac [3]int
+		for c, value := range factor {
+			ac[c] = int(math.Max(0, math.Min(18, math.Floor(signPow(value/maximum, 0.5)*9+9.5))))
+		}
+		hash.WriteString(encode83(ac[0]*19*19+ac[1]*19+ac[2], 2))
+	}
+
+	return hash.String()
+}
+
+// DominantColor returns the most common colour of the image as a #rrggbb hex string. The colours are grouped
+// by their 4 most significant bits per channel, and the colour of the largest group is the average of its pixels.
+func DominantColor(img image.Image) string {
+	thumb := thumbnail(img, dominantColorSize)
+
+	var counts [4096]int
+	var sums [4096][3]int
+
+	largest := 0
+	for y := 0; y < thumb.Bounds().Dy(); y++ {
+		for x := 0; x < thumb.Bounds().Dx(); x++ {
+			pixel := thumb.Pix[thumb.PixOffset(x, y):]
+
+			// Mostly transparent pixels are not seen, they do not count
+			if pixel[3] < 128 {
+				continue
+			}
+
+			group := int(pixel[0]>>4)<<8 | int(pixel[1]>>4)<<4 | int(pixel[2]>>4)
+			counts[group]++
+			for c := 0; c < 3; c++ {
+				sums[group][c] += int(pixel[c])
+			}
+
+			if counts[group] > counts[largest] {
+				largest = group
+			}
+		}
+	}
+
+	if counts[largest] == 0 {
+		return "#000000"
+	}
+
+	count := counts[largest]
+
+	return fmt.Sprintf("#%02x%02x%02x", sums[largest][0]/count, sums[largest][1]/count, sums[largest][2]/count)
+}
+
+// thumbnail shrinks the image so its longer side is at most size pixels, keeping its aspect ratio
+func thumbnail(img image.Image, size int) *image.RGBA {
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	if width > size || height > size {
+		if width >= height {
+			width, height = size, max(1, height*size/width)
+		} else {
+			width, height = max(1, width*size/height), size
+		}
+	}
+
+	thumb := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.BiLinear.Scale(thumb, thumb.Bounds(), img, img.Bounds(), draw.Src, nil)
+
+	return thumb
+}
+
+func encode83(value int, length int) string {
+	encoded := make([]byte, length)
+	for i := length - 1; i >= 0; i-- {
+		encoded[i] = base83Chars[value%83]
+		value /= 83
+	}
+
+	return string(encoded)
+}
+
+func srgbToLinear(value uint8) float64 {
+	v := float64(value) / 255
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+func linearToSRGB(value float64) int {
+	v := math.Max(0, math.Min(1, value))
+	if v <= 0.0031308 {
+		return int(v*12.92*255 + 0.5)
+	}
+
+	return int((1.055*math.Pow(v, 1/2.4)-0.055)*255 + 0.5)
+}
+
+func signPow(value float64, exp float64) float64 {
+	return math.Copysign(math.Pow(math.Abs(value), exp), value)
+}
@@ -0,0 +1,61 @@
+package imaging
+
+import (
+	"image"
+
+	"golang.org/x/image/draw"
+)
+
+const (
+	FitCover   = "cover"
+	FitContain = "contain"
+)
+
+// Resize scales the image to the width and height without enlarging it. Contain fits the whole image within the
+// size, cover fills the size and crops what overflows around the center. A zero width or height is left free and
+// follows the aspect ratio of the image.
+func Resize(img image.Image, width int, height int, fit string) image.Image {
+	bounds := img.Bounds()
+	sourceWidth, sourceHeight := bounds.Dx(), bounds.Dy()
+	if sourceWidth == 0 || sourceHeight == 0 {
+		return img
+	}
+
+	if width <= 0 && height <= 0 {
+		width, height = sourceWidth, sourceHeight
+	} else if width <= 0 {
+		width = max(sourceWidth*height/sourceHeight, 1)
+	} else if height <= 0 {
+		height = max(sourceHeight*width/sourceWidth, 1)
+	}
+
+	source := bounds
+	if fit == FitCover {
+		// Crop the source to the aspect ratio of the size first, the crop is then scaled to the size
+		if sourceWidth*height > sourceHeight*width {
+			cropWidth := sourceHeight * width / height
+			source.Min.X += (sourceWidth - cropWidth) / 2
+			source.Max.X = source.Min.X + cropWidth
+		} else {
+			cropHeight := sourceWidth * height / width
+			source.Min.Y += (sourceHeight - cropHeight) / 2
+			source.Max.Y = source.Min.Y + cropHeight
+		}
+	} else {
+		// Shrink the size to the aspect ratio of the image
+		if sourceWidth*height > sourceHeight*width {
+			height = max(sourceHeight*width/sourceWidth, 1)
+		} else {
+			width = max(sourceWidth*height/sourceHeight, 1)
+		}
+	}
+
+	if width > source.Dx() || height > source.Dy() {
+		width, height = source.Dx(), source.Dy()
+	}
+
+	resized := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(resized, resized.Bounds(), img, source, draw.Src, nil)
+
+	return resized
+}
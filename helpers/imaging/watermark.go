@@ -0,0 +1,142 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"sync"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	PositionTopLeft     = "top-left"
+	PositionTopRight    = "top-right"
+	PositionBottomLeft  = "bottom-left"
+	PositionBottomRight = "bottom-right"
+	PositionCenter      = "center"
+)
+
+// Mark is a watermark, a text or else a logo drawn at a position of the image with an opacity from 0 to 1
+type Mark struct {
+	Text     string
+	Logo     image.Image
+	Position string
+	Opacity  float64
+}
+
+var (
+	markFont     *opentype.Font
+	markFontErr  error
+	markFontOnce sync.Once
+)
+
+// Watermark returns a copy of the image with the mark drawn on it. The mark is sized after the image width,
+// a text is a twentieth of the width high and a logo is a fifth of the width wide.
+func Watermark(img image.Image, mark Mark) (image.Image, error) {
+	bounds := img.Bounds()
+
+	marked := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(marked, marked.Bounds(), img, bounds.Min, draw.Src)
+
+	opacity := uint8(min(max(mark.Opacity, 0), 1) * 255)
+	margin := max(marked.Bounds().Dx()/40, 1)
+
+	if mark.Logo != nil {
+		drawLogo(marked, mark.Logo, mark.Position, opacity, margin)
+		return marked, nil
+	}
+
+	if err := drawText(marked, mark.Text, mark.Position, opacity, margin); err != nil {
+		return nil, err
+	}
+
+	return marked, nil
+}
+
+func drawLogo(dst *image.RGBA, logo image.Image, position string, opacity uint8, margin int) {
+	logoBounds := logo.Bounds()
+	if logoBounds.Empty() {
+		return
+	}
+
+	width := max(dst.Bounds().Dx()/5, 1)
+	height := max(width*logoBounds.Dy()/logoBounds.Dx(), 1)
+
+	scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(scaled, scaled.Bounds(), logo, logoBounds, draw.Src, nil)
+
+	at := markPosition(dst.Bounds(), width, height, margin, position)
+	mask := image.NewUniform(color.Alpha{A: opacity})
+	draw.DrawMask(dst, image.Rect(at.X, at.Y, at.X+width, at.Y+height), scaled, image.Point{}, mask, image.Point{}, draw.Over)
+}
+
+// drawText draws the text in white over a dark shadow, so it can be read on light and dark images alike
+func drawText(dst *image.RGBA, text string, position string, opacity uint8, margin int) error {
+	if text == "" {
+		return nil
+	}
+
+	markFontOnce.Do(func() {
+		markFont, markFontErr = opentype.Parse(goregular.TTF)
+	})
+	if markFontErr != nil {
+		return markFontErr
+	}
+
+	face, err := opentype.NewFace(markFont, &opentype.FaceOptions{
+		Size:    float64(max(dst.Bounds().Dx()/20, 8)),
+		DPI:     72,
+		Hinting: font.HintingFull,
+	})
+	if err != nil {
+		return err
+	}
+	defer face.Close()
+
+	metrics := face.Metrics()
+	width := font.MeasureString(face, text).Ceil()
+	height := (metrics.Ascent + metrics.Descent).Ceil()
+
+	at := markPosition(dst.Bounds(), width, height, margin, position)
+	baseline := fixed.P(at.X, at.Y+metrics.Ascent.Ceil())
+	shadow := max(height/16, 1)
+
+	drawer := &font.Drawer{
+		Dst:  dst,
+		Src:  image.NewUniform(color.NRGBA{A: opacity / 2}),
+		Face: face,
+		Dot:  baseline.Add(fixed.P(shadow, shadow)),
+	}
+	drawer.DrawString(text)
+
+	drawer.Src = image.NewUniform(color.NRGBA{R: 255, G: 255, B: 255, A: opacity})
+	drawer.Dot = baseline
+	drawer.DrawString(text)
+
+	return nil
+}
+
+// markPosition returns where the top left corner of a mark of the given size goes within the bounds
+func markPosition(bounds image.Rectangle, width int, height int, margin int, position string) image.Point {
+	left := bounds.Min.X + margin
+	top := bounds.Min.Y + margin
+	right := bounds.Max.X - margin - width
+	bottom := bounds.Max.Y - margin - height
+
+	switch position {
+	case PositionTopLeft:
+		return image.Pt(left, top)
+	case PositionTopRight:
+		return image.Pt(right, top)
+	case PositionBottomLeft:
+		return image.Pt(left, bottom)
+	case PositionCenter:
+		return image.Pt(bounds.Min.X+(bounds.Dx()-width)/2, bounds.Min.Y+(bounds.Dy()-height)/2)
+	default:
+		return image.Pt(right, bottom)
+	}
+}
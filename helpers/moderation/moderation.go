@@ -0,0 +1,134 @@
+package moderation
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"math/bits"
+	"strings"
+	"unicode"
+
+	"rakamin-final-task/helpers/imaging"
+)
+
+const (
+	ActionAllow  = "allow"
+	ActionFlag   = "flag"
+	ActionReject = "reject"
+)
+
+// Verdict is the outcome of a moderation, the reasons explain why the content is flagged or rejected
+type Verdict struct {
+	Action  string
+	Reasons []string
+}
+
+// Moderator decides whether the uploaded content can be published. A flagged content waits for a moderator to
+// review it, a rejected one is never published.
+type Moderator interface {
+	ModerateText(ctx context.Context, text string) (Verdict, error)
+	ModerateImage(ctx context.Context, img image.Image) (Verdict, error)
+}
+
+// Rules configure the default moderator. The words and phrases are matched as whole words regardless of case,
+// the images smaller than the minimum size are flagged and the images within MaxHashDistance of a blocked
+// perceptual hash are rejected.
+type Rules struct {
+	RejectWords     []string
+	FlagWords       []string
+	MinWidth        int
+	MinHeight       int
+	BlockedHashes   []uint64
+	MaxHashDistance int
+}
+
+type rules struct {
+	rejectWords     []string
+	flagWords       []string
+	minWidth        int
+	minHeight       int
+	blockedHashes   []uint64
+	maxHashDistance int
+}
+
+// InitRules moderates with a local word list and image rules
+func InitRules(param Rules) Moderator {
+	return &rules{
+		rejectWords:     normalizeWords(param.RejectWords),
+		flagWords:       normalizeWords(param.FlagWords),
+		minWidth:        param.MinWidth,
+		minHeight:       param.MinHeight,
+		blockedHashes:   param.BlockedHashes,
+		maxHashDistance: param.MaxHashDistance,
+	}
+}
+
+func (r *rules) ModerateText(ctx context.Context, text string) (Verdict, error) {
+	verdict := Verdict{Action: ActionAllow}
+
+	// The words are padded with spaces so a listed word or phrase only matches whole words
+	normalized := " " + normalize(text) + " "
+	for _, word := range r.rejectWords {
+		if strings.Contains(normalized, " "+word+" ") {
+			verdict.add(ActionReject, fmt.Sprintf("Text contains the blocked word %q", word))
+		}
+	}
+
+	for _, word := range r.flagWords {
+		if strings.Contains(normalized, " "+word+" ") {
+			verdict.add(ActionFlag, fmt.Sprintf("Text contains the sensitive word %q", word))
+		}
+	}
+
+	return verdict, nil
+}
+
+func (r *rules) ModerateImage(ctx context.Context, img image.Image) (Verdict, error) {
+	verdict := Verdict{Action: ActionAllow}
+
+	if len(r.blockedHashes) > 0 {
+		hash := imaging.DHash(img)
+		for _, blocked := range r.blockedHashes {
+			if bits.OnesCount64(hash^blocked) <= r.maxHashDistance {
+				verdict.add(ActionReject, fmt.Sprintf("Image matches the blocked image %016x", blocked))
+				break
+			}
+		}
+	}
+
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	if width < r.minWidth || height < r.minHeight {
+		verdict.add(ActionFlag, fmt.Sprintf("Image of %dx%d is smaller than %dx%d", width, height, r.minWidth, r.minHeight))
+	}
+
+	return verdict, nil
+}
+
+// add records the reason, a rejection prevails over a flag
+func (v *Verdict) add(action string, reason string) {
+	if action == ActionReject || v.Action == ActionAllow {
+		v.Action = action
+	}
+
+	v.Reasons = append(v.Reasons, reason)
+}
+
+// normalize lowercases the text and keeps its letters and digits, the words are separated by a single space
+func normalize(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	return strings.Join(words, " ")
+}
+
+func normalizeWords(words []string) []string {
+	normalized := make([]string, 0, len(words))
+	for _, word := range words {
+		if word = normalize(word); word != "" {
+			normalized = append(normalized, word)
+		}
+	}
+
+	return normalized
+}
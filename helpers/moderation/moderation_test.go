@@ -0,0 +1,85 @@
+package moderation
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestModerateText(t *testing.T) {
+	moderator := InitRules(Rules{
+		RejectWords: []string{"Spam", "buy now", "  "},
+		FlagWords:   []string{"nsfw", "Gore!"},
+	})
+
+	tests := []struct {
+		name string
+		text string
+		want Verdict
+	}{
+		{
+			name: "empty text",
+			text: "",
+			want: Verdict{Action: ActionAllow},
+		},
+		{
+			name: "clean text",
+			text: "Sunset over the beach",
+			want: Verdict{Action: ActionAllow},
+		},
+		{
+			name: "rejected word regardless of case",
+			text: "Great SPAM here",
+			want: Verdict{Action: ActionReject, Reasons: []string{`Text contains the blocked word "spam"`}},
+		},
+		{
+			name: "rejected phrase across punctuation and spaces",
+			text: "Buy...   NOW!",
+			want: Verdict{Action: ActionReject, Reasons: []string{`Text contains the blocked word "buy now"`}},
+		},
+		{
+			name: "partial words do not match",
+			text: "spammer buying nowhere",
+			want: Verdict{Action: ActionAllow},
+		},
+		{
+			name: "flagged word normalized like the text",
+			text: "some gore",
+			want: Verdict{Action: ActionFlag, Reasons: []string{`Text contains the sensitive word "gore"`}},
+		},
+		{
+			name: "rejection prevails over a flag",
+			text: "nsfw spam",
+			want: Verdict{Action: ActionReject, Reasons: []string{
+				`Text contains the blocked word "spam"`,
+				`Text contains the sensitive word "nsfw"`,
+			}},
+		},
+		{
+			name: "several flags",
+			text: "#NSFW #gore",
+			want: Verdict{Action: ActionFlag, Reasons: []string{
+				`Text contains the sensitive word "nsfw"`,
+				`Text contains the sensitive word "gore"`,
+			}},
+		},
+		{
+			name: "words at the start and the end",
+			text: "spam",
+			want: Verdict{Action: ActionReject, Reasons: []string{`Text contains the blocked word "spam"`}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := moderator.ModerateText(context.Background(), tt.text)
+			if err != nil {
+				t.Fatalf("ModerateText() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ModerateText(%q) = %+v, want %+v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
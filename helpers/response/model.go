@@ -32,6 +32,12 @@ type PaginationParam struct {
 	TotalPage      int64  `json:"totalPage" gorm:"-"`
 	CurrentElement int64  `json:"currentElement" gorm:"-"`
 	TotalElement   int64  `json:"totalElement" gorm:"-"`
+
+	// Cursor switches to keyset pagination, the page is ignored when it is set
+	Cursor     string `form:"cursor" json:"-" gorm:"-"`
+	WithTotal  bool   `form:"with_total" json:"-" gorm:"-"`
+	NextCursor string `json:"nextCursor,omitempty" gorm:"-"`
+	PrevCursor string `json:"prevCursor,omitempty" gorm:"-"`
 }
 
 func (pg *PaginationParam) SetDefaultPagination() {
@@ -47,7 +53,10 @@ func (pg *PaginationParam) SetDefaultPagination() {
 }
 
 func (pg *PaginationParam) ProcessPagination(rowsAffected int64) {
-	pg.CurrentPage = pg.Page
+	// There is no page number when the page is reached by a cursor
+	if pg.Cursor == "" {
+		pg.CurrentPage = pg.Page
+	}
 	pg.TotalPage = int64(math.Ceil(float64(pg.TotalElement) / float64(pg.Limit)))
 	pg.CurrentElement = rowsAffected
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	goerr "errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -27,6 +28,10 @@ func Init(log log.LogInterface) Interface {
 }
 
 func (r *response) Success(c *gin.Context, message string, data interface{}, pg *PaginationParam) {
+	if pg != nil {
+		setLinkHeader(c, pg)
+	}
+
 	c.JSON(200, HTTPResponse{
 		Meta:       getRequestMetadata(c),
 		Message:    ResponseMessage{Title: "Success", Description: message},
@@ -81,3 +86,26 @@ func getRequestMetadata(c *gin.Context) Meta {
 
 	return meta
 }
+
+// setLinkHeader points to the next and previous pages by their cursors, as described in RFC 8288
+func setLinkHeader(c *gin.Context, pg *PaginationParam) {
+	links := []string{}
+
+	for _, page := range []struct{ rel, cursor string }{{"next", pg.NextCursor}, {"prev", pg.PrevCursor}} {
+		if page.cursor == "" {
+			continue
+		}
+
+		pageURL := *c.Request.URL
+		query := pageURL.Query()
+		query.Set("cursor", page.cursor)
+		query.Del("page")
+		pageURL.RawQuery = query.Encode()
+
+		links = append(links, fmt.Sprintf(`<%s>; rel="%s"`, pageURL.RequestURI(), page.rel))
+	}
+
+	if len(links) > 0 {
+		c.Header("Link", strings.Join(links, ", "))
+	}
+}
@@ -0,0 +1,82 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"rakamin-final-task/helpers/log"
+)
+
+type Job func(ctx context.Context) error
+
+type Interface interface {
+	Register(name string, interval time.Duration, job Job)
+	Start()
+	Stop()
+}
+
+type task struct {
+	name     string
+	interval time.Duration
+	job      Job
+}
+
+type scheduler struct {
+	log    log.LogInterface
+	tasks  []task
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func Init(log log.LogInterface) Interface {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &scheduler{
+		log:    log,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Register adds a job that runs every interval once the scheduler is started, a job with no interval never runs
+func (s *scheduler) Register(name string, interval time.Duration, job Job) {
+	if interval <= 0 {
+		return
+	}
+
+	s.tasks = append(s.tasks, task{name: name, interval: interval, job: job})
+}
+
+func (s *scheduler) Start() {
+	for _, t := range s.tasks {
+		s.wg.Add(1)
+		go s.run(t)
+	}
+}
+
+// Stop cancels the running jobs and waits for them to return
+func (s *scheduler) Stop() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+func (s *scheduler) run(t task) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := t.job(s.ctx); err != nil {
+				s.log.Error(s.ctx, fmt.Sprintf("Job %s failed: %s", t.name, err.Error()))
+			}
+		}
+	}
+}
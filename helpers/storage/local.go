@@ -0,0 +1,281 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	goerr "errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"rakamin-final-task/helpers/errors"
+	"rakamin-final-task/helpers/files"
+)
+
+type LocalConfig struct {
+	// Dir is the directory the objects are stored in
+	Dir string
+	// BaseURL is the URL the objects are served from by the API, e.g. http://127.0.0.1:8080/files
+	BaseURL string
+	// Secret signs the URLs of the objects
+	Secret string
+}
+
+type localStorage struct {
+	Config       LocalConfig
+	SignedURLTTL time.Duration
+}
+
+// InitLocal stores the objects on the filesystem, their signed URLs are signed with an HMAC of the config secret
+func InitLocal(config LocalConfig, signedURLTTL time.Duration) Interface {
+	if config.Secret == "" {
+		panic("local storage secret is required to sign URLs")
+	}
+
+	if err := os.MkdirAll(config.Dir, 0o755); err != nil {
+		panic(err)
+	}
+
+	if signedURLTTL <= 0 {
+		signedURLTTL = defaultSignedURLTTL
+	}
+
+	return &localStorage{
+		Config:       config,
+		SignedURLTTL: signedURLTTL,
+	}
+}
+
+// objectPath returns the path of the object on the filesystem, names that could escape the directory are rejected
+func (l *localStorage) objectPath(filename string, path string) (string, error) {
+	if filename == "" || filename != filepath.Base(filename) || path != filepath.Clean(path) || !filepath.IsLocal(path) {
+		return "", errors.NotFound("File not found")
+	}
+
+	return filepath.Join(l.Config.Dir, path, filename), nil
+}
+
+// ObjectURL returns the permanent URL of the object, it can only be read through a signed URL
+func (l *localStorage) ObjectURL(filename string, path string) (string, error) {
+	return url.JoinPath(l.Config.BaseURL, path, filename)
+}
+
+func (l *localStorage) Upload(ctx context.Context, file *files.File, path string) (string, error) {
+	return l.write(file.Content, file.Meta.Filename, path)
+}
+
+func (l *localStorage) UploadFromBytes(ctx context.Context, file *bytes.Reader, fileName string, path string) (string, error) {
+	return l.write(file, fileName, path)
+}
+
+func (l *localStorage) UploadFromReader(ctx context.Context, file io.Reader, fileName string, path string) (string, error) {
+	return l.write(file, fileName, path)
+}
+
+// Copy copies the object to another name under the same path
+func (l *localStorage) Copy(ctx context.Context, srcFileName string, dstFileName string, path string) error {
+	src, err := l.Download(ctx, srcFileName, path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	_, err = l.write(src, dstFileName, path)
+
+	return err
+}
+
+// write stores the object through a temporary file, so a failed upload never leaves a partial object
+func (l *localStorage) write(content io.Reader, filename string, path string) (string, error) {
+	objectPath, err := l.objectPath(filename, path)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(objectPath), 0o755); err != nil {
+		return "", err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(objectPath), "."+filename+".*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, content); err != nil {
+		tmp.Close()
+		return "", err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+
+	if err := os.Rename(tmp.Name(), objectPath); err != nil {
+		return "", err
+	}
+
+	return l.ObjectURL(filename, path)
+}
+
+// Delete removes the object, an object that does not exist anymore is not an error
+func (l *localStorage) Delete(ctx context.Context, filename string, path string) error {
+	objectPath, err := l.objectPath(filename, path)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(objectPath)
+	if err != nil && !goerr.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+func (l *localStorage) Download(ctx context.Context, filename string, path string) (io.ReadCloser, error) {
+	return l.DownloadRange(ctx, filename, path, 0, -1)
+}
+
+// DownloadRange opens length bytes of the object from offset for reading, a negative length reads to the end
+func (l *localStorage) DownloadRange(ctx context.Context, filename string, path string, offset int64, length int64) (io.ReadCloser, error) {
+	objectPath, err := l.objectPath(filename, path)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(objectPath)
+	if goerr.Is(err, fs.ErrNotExist) {
+		return nil, errors.NotFound("File not found")
+	} else if err != nil {
+		return nil, err
+	}
+
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	if length < 0 {
+		return file, nil
+	}
+
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(file, length), file}, nil
+}
+
+func (l *localStorage) Attributes(ctx context.Context, filename string, path string) (ObjectAttrs, error) {
+	var objectAttrs ObjectAttrs
+
+	objectPath, err := l.objectPath(filename, path)
+	if err != nil {
+		return objectAttrs, err
+	}
+
+	info, err := os.Stat(objectPath)
+	if goerr.Is(err, fs.ErrNotExist) {
+		return objectAttrs, errors.NotFound("File not found")
+	} else if err != nil {
+		return objectAttrs, err
+	}
+
+	objectAttrs = ObjectAttrs{
+		ContentType: mime.TypeByExtension(filepath.Ext(filename)),
+		Size:        info.Size(),
+		ETag:        fmt.Sprintf("%x-%x", info.ModTime().UnixNano(), info.Size()),
+		Updated:     info.ModTime(),
+	}
+
+	return objectAttrs, nil
+}
+
+// SignedURL returns the URL of the object with its expiry and the HMAC of both, see VerifySignedURL
+func (l *localStorage) SignedURL(ctx context.Context, filename string, path string) (string, error) {
+	return l.signedURL(http.MethodGet, filename, path, time.Now().Add(l.SignedURLTTL).Unix())
+}
+
+// SignedUploadURL returns a signed URL the object is uploaded to with a PUT request to the API, the content type
+// and checksum are only verified once the upload is completed
+func (l *localStorage) SignedUploadURL(ctx context.Context, filename string, path string, contentType string, checksum string) (SignedUpload, error) {
+	expires := time.Now().Add(l.SignedURLTTL).Unix()
+
+	signedURL, err := l.signedURL(http.MethodPut, filename, path, expires)
+	if err != nil {
+		return SignedUpload{}, err
+	}
+
+	signedUpload := SignedUpload{
+		URL:    signedURL,
+		Method: http.MethodPut,
+		Headers: map[string]string{
+			"Content-Type": contentType,
+		},
+		ExpiresAt: expires,
+	}
+
+	return signedUpload, nil
+}
+
+func (l *localStorage) signedURL(method string, filename string, path string, expires int64) (string, error) {
+	objectURL, err := l.ObjectURL(filename, path)
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	query.Set("signature", l.sign(method, filename, path, expires))
+
+	return objectURL + "?" + query.Encode(), nil
+}
+
+// VerifySignedURL checks the signature of a URL for the request method, a URL signed to read an object
+// can not be used to upload it
+func (l *localStorage) VerifySignedURL(method string, filename string, path string, expires int64, signature string) error {
+	if !hmac.Equal([]byte(signature), []byte(l.sign(method, filename, path, expires))) {
+		return errors.Forbidden("Invalid signature")
+	}
+
+	if expires < time.Now().Unix() {
+		return errors.Forbidden("Signed URL has expired")
+	}
+
+	return nil
+}
+
+func (l *localStorage) sign(method string, filename string, path string, expires int64) string {
+	mac := hmac.New(sha256.New, []byte(l.Config.Secret))
+	fmt.Fprintf(mac, "%s\n%s/%s\n%d", method, path, filename, expires)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Checksum returns the base64 encoded MD5 checksum of the object
+func (l *localStorage) Checksum(ctx context.Context, filename string, path string) (string, error) {
+	file, err := l.Download(ctx, filename, path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := md5.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(hash.Sum(nil)), nil
+}
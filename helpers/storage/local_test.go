@@ -0,0 +1,181 @@
+package storage
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"rakamin-final-task/helpers/errors"
+)
+
+func TestLocalSignedURL(t *testing.T) {
+	l := &localStorage{Config: LocalConfig{BaseURL: "http://127.0.0.1:8080/files", Secret: "secret"}}
+	other := &localStorage{Config: LocalConfig{BaseURL: "http://127.0.0.1:8080/files", Secret: "other secret"}}
+
+	expires := time.Now().Add(time.Hour).Unix()
+
+	signedURL, err := l.signedURL(http.MethodGet, "photo.jpg", "photos", expires)
+	if err != nil {
+		t.Fatalf("signedURL() error = %v", err)
+	}
+
+	parsed, err := url.Parse(signedURL)
+	if err != nil {
+		t.Fatalf("signedURL() = %q is not a URL: %v", signedURL, err)
+	}
+
+	if parsed.Path != "/files/photos/photo.jpg" {
+		t.Errorf("signedURL() path = %q, want /files/photos/photo.jpg", parsed.Path)
+	}
+
+	if got := parsed.Query().Get("expires"); got != strconv.FormatInt(expires, 10) {
+		t.Errorf("signedURL() expires = %q, want %d", got, expires)
+	}
+
+	signature := parsed.Query().Get("signature")
+	if signature != l.sign(http.MethodGet, "photo.jpg", "photos", expires) {
+		t.Errorf("signedURL() signature = %q, want the signature of the object", signature)
+	}
+
+	tests := []struct {
+		name      string
+		storage   *localStorage
+		method    string
+		filename  string
+		path      string
+		expires   int64
+		signature string
+		wantErr   bool
+	}{
+		{
+			name:      "signed URL",
+			storage:   l,
+			method:    http.MethodGet,
+			filename:  "photo.jpg",
+			path:      "photos",
+			expires:   expires,
+			signature: signature,
+		},
+		{
+			name:      "other method",
+			storage:   l,
+			method:    http.MethodPut,
+			filename:  "photo.jpg",
+			path:      "photos",
+			expires:   expires,
+			signature: signature,
+			wantErr:   true,
+		},
+		{
+			name:      "other file",
+			storage:   l,
+			method:    http.MethodGet,
+			filename:  "other.jpg",
+			path:      "photos",
+			expires:   expires,
+			signature: signature,
+			wantErr:   true,
+		},
+		{
+			name:      "other path",
+			storage:   l,
+			method:    http.MethodGet,
+			filename:  "photo.jpg",
+			path:      "renditions",
+			expires:   expires,
+			signature: signature,
+			wantErr:   true,
+		},
+		{
+			name:      "extended expiry",
+			storage:   l,
+			method:    http.MethodGet,
+			filename:  "photo.jpg",
+			path:      "photos",
+			expires:   expires + 1,
+			signature: signature,
+			wantErr:   true,
+		},
+		{
+			name:      "other secret",
+			storage:   other,
+			method:    http.MethodGet,
+			filename:  "photo.jpg",
+			path:      "photos",
+			expires:   expires,
+			signature: signature,
+			wantErr:   true,
+		},
+		{
+			name:      "no signature",
+			storage:   l,
+			method:    http.MethodGet,
+			filename:  "photo.jpg",
+			path:      "photos",
+			expires:   expires,
+			signature: "",
+			wantErr:   true,
+		},
+		{
+			name:      "expired",
+			storage:   l,
+			method:    http.MethodGet,
+			filename:  "photo.jpg",
+			path:      "photos",
+			expires:   time.Now().Add(-time.Minute).Unix(),
+			signature: l.sign(http.MethodGet, "photo.jpg", "photos", time.Now().Add(-time.Minute).Unix()),
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.storage.VerifySignedURL(tt.method, tt.filename, tt.path, tt.expires, tt.signature)
+			if !tt.wantErr {
+				if err != nil {
+					t.Errorf("VerifySignedURL() error = %v", err)
+				}
+				return
+			}
+
+			if errors.GetCode(err) != http.StatusForbidden {
+				t.Errorf("VerifySignedURL() error = %v, want a forbidden", err)
+			}
+		})
+	}
+}
+
+func TestLocalSign(t *testing.T) {
+	l := &localStorage{Config: LocalConfig{Secret: "secret"}}
+	base := l.sign(http.MethodGet, "photo.jpg", "photos", 100)
+
+	tests := []struct {
+		name     string
+		method   string
+		filename string
+		path     string
+		expires  int64
+		wantSame bool
+	}{
+		{name: "same input", method: http.MethodGet, filename: "photo.jpg", path: "photos", expires: 100, wantSame: true},
+		{name: "other method", method: http.MethodPut, filename: "photo.jpg", path: "photos", expires: 100},
+		{name: "other file", method: http.MethodGet, filename: "photo.png", path: "photos", expires: 100},
+		{name: "other path", method: http.MethodGet, filename: "photo.jpg", path: "photo", expires: 100},
+		{name: "other expiry", method: http.MethodGet, filename: "photo.jpg", path: "photos", expires: 1000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := l.sign(tt.method, tt.filename, tt.path, tt.expires)
+			if len(got) != 64 {
+				t.Errorf("sign() = %q, want a hex encoded SHA-256 HMAC", got)
+			}
+
+			if (got == base) != tt.wantSame {
+				t.Errorf("sign() = %q, same as %q is %v, want %v", got, base, got == base, tt.wantSame)
+			}
+		})
+	}
+}
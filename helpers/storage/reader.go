@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"context"
+	goerr "errors"
+	"io"
+)
+
+// ObjectReader reads an object as an io.ReadSeeker, as needed by http.ServeContent. The first read opens a download
+// from the current offset to the end of the object, since the length a caller reads is not known, and a seek or
+// Close stops it. A range request is therefore cut short once it is served, but the storage may have sent more of
+// the object than was read by then.
+type ObjectReader struct {
+	Attrs ObjectAttrs
+
+	ctx      context.Context
+	storage  Interface
+	fileName string
+	path     string
+	offset   int64
+	body     io.ReadCloser
+}
+
+func NewObjectReader(ctx context.Context, storage Interface, fileName string, path string, attrs ObjectAttrs) *ObjectReader {
+	return &ObjectReader{
+		Attrs:    attrs,
+		ctx:      ctx,
+		storage:  storage,
+		fileName: fileName,
+		path:     path,
+	}
+}
+
+func (r *ObjectReader) Read(p []byte) (int, error) {
+	if r.offset >= r.Attrs.Size {
+		return 0, io.EOF
+	}
+
+	if r.body == nil {
+		body, err := r.storage.DownloadRange(r.ctx, r.fileName, r.path, r.offset, -1)
+		if err != nil {
+			return 0, err
+		}
+		r.body = body
+	}
+
+	n, err := r.body.Read(p)
+	r.offset += int64(n)
+
+	return n, err
+}
+
+func (r *ObjectReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += r.Attrs.Size
+	default:
+		return r.offset, goerr.New("invalid whence")
+	}
+
+	if offset < 0 {
+		return r.offset, goerr.New("negative position")
+	}
+
+	if offset != r.offset {
+		if err := r.Close(); err != nil {
+			return r.offset, err
+		}
+		r.offset = offset
+	}
+
+	return r.offset, nil
+}
+
+func (r *ObjectReader) Close() error {
+	if r.body == nil {
+		return nil
+	}
+
+	err := r.body.Close()
+	r.body = nil
+
+	return err
+}
@@ -3,14 +3,19 @@ package storage
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	goerr "errors"
 	"fmt"
 	"io"
+	"net/http"
 	"net/url"
 	"regexp"
+	"time"
 
 	"cloud.google.com/go/storage"
 	"google.golang.org/api/option"
+	"rakamin-final-task/helpers/errors"
 	"rakamin-final-task/helpers/files"
 )
 
@@ -22,19 +27,50 @@ type GCPConfig struct {
 }
 
 type storageLib struct {
-	Config     GCPConfig
-	BucketName string
-	client     *storage.Client
+	Config       GCPConfig
+	BucketName   string
+	SignedURLTTL time.Duration
+	client       *storage.Client
+}
+
+type ObjectAttrs struct {
+	ContentType string
+	Size        int64
+	ETag        string
+	Updated     time.Time
+}
+
+// SignedUpload is a URL the client uploads an object to without going through the API,
+// the request must be sent with the given method and headers before ExpiresAt
+type SignedUpload struct {
+	URL       string
+	Method    string
+	Headers   map[string]string
+	ExpiresAt int64
 }
 
 type Interface interface {
 	Upload(ctx context.Context, file *files.File, path string) (string, error)
 	UploadFromBytes(ctx context.Context, file *bytes.Reader, fileName string, path string) (string, error)
-	Delete(ctx context.Context, path string, fileName string) error
-	getObjectPlace(objectPath string) *storage.ObjectHandle
+	UploadFromReader(ctx context.Context, file io.Reader, fileName string, path string) (string, error)
+	Copy(ctx context.Context, srcFileName string, dstFileName string, path string) error
+	Delete(ctx context.Context, fileName string, path string) error
+	Download(ctx context.Context, fileName string, path string) (io.ReadCloser, error)
+	DownloadRange(ctx context.Context, fileName string, path string, offset int64, length int64) (io.ReadCloser, error)
+	Attributes(ctx context.Context, fileName string, path string) (ObjectAttrs, error)
+	SignedURL(ctx context.Context, fileName string, path string) (string, error)
+	SignedUploadURL(ctx context.Context, fileName string, path string, contentType string, checksum string) (SignedUpload, error)
+	VerifySignedURL(method string, fileName string, path string, expires int64, signature string) error
+	ObjectURL(fileName string, path string) (string, error)
+	Checksum(ctx context.Context, fileName string, path string) (string, error)
 }
 
-func Init(config GCPConfig, bucketName string) Interface {
+const (
+	defaultSignedURLTTL = 15 * time.Minute
+)
+
+// Init connects to the Google Cloud Storage bucket, the signed URLs of its objects are valid for signedURLTTL
+func Init(config GCPConfig, bucketName string, signedURLTTL time.Duration) Interface {
 	config.PrivateKey = regexp.MustCompile(`\\n`).ReplaceAllString(config.PrivateKey, "\n")
 	config.Type = "service_account"
 
@@ -49,10 +85,15 @@ func Init(config GCPConfig, bucketName string) Interface {
 		panic(err)
 	}
 
+	if signedURLTTL <= 0 {
+		signedURLTTL = defaultSignedURLTTL
+	}
+
 	return &storageLib{
-		Config:     config,
-		BucketName: bucketName,
-		client:     client,
+		Config:       config,
+		BucketName:   bucketName,
+		SignedURLTTL: signedURLTTL,
+		client:       client,
 	}
 }
 
@@ -61,10 +102,18 @@ func (s *storageLib) getObjectPlace(objectPath string) *storage.ObjectHandle {
 }
 
 func (s *storageLib) Upload(ctx context.Context, file *files.File, path string) (string, error) {
+	return s.UploadFromReader(ctx, file.Content, file.Meta.Filename, path)
+}
+
+func (s *storageLib) UploadFromBytes(ctx context.Context, file *bytes.Reader, fileName string, path string) (string, error) {
+	return s.UploadFromReader(ctx, file, fileName, path)
+}
+
+func (s *storageLib) UploadFromReader(ctx context.Context, file io.Reader, fileName string, path string) (string, error) {
 	var imageURL string
-	writer := s.getObjectPlace(fmt.Sprintf("%s/%s", path, file.Meta.Filename)).NewWriter(ctx)
+	writer := s.getObjectPlace(path + "/" + fileName).NewWriter(ctx)
 
-	if _, err := io.Copy(writer, file.Content); err != nil {
+	if _, err := io.Copy(writer, file); err != nil {
 		return imageURL, err
 	}
 
@@ -72,38 +121,129 @@ func (s *storageLib) Upload(ctx context.Context, file *files.File, path string)
 		return imageURL, err
 	}
 
-	parsedURL, err := url.Parse(fmt.Sprintf("https://storage.googleapis.com/%s/%s/%s", s.BucketName, path, file.Meta.Filename))
+	return s.ObjectURL(fileName, path)
+}
+
+// Copy copies the object to another name within the bucket, the content never leaves the bucket
+func (s *storageLib) Copy(ctx context.Context, srcFileName string, dstFileName string, path string) error {
+	src := s.getObjectPlace(path + "/" + srcFileName)
+
+	_, err := s.getObjectPlace(path + "/" + dstFileName).CopierFrom(src).Run(ctx)
+	if goerr.Is(err, storage.ErrObjectNotExist) {
+		return errors.NotFound("File not found")
+	}
+
+	return err
+}
+
+// ObjectURL returns the permanent URL of the object, it can only be read through a signed URL unless it is public
+func (s *storageLib) ObjectURL(fileName string, path string) (string, error) {
+	parsedURL, err := url.Parse(fmt.Sprintf("https://storage.googleapis.com/%s/%s/%s", s.BucketName, path, fileName))
 	if err != nil {
-		return imageURL, err
+		return "", err
 	}
 
-	imageURL = parsedURL.String()
+	return parsedURL.String(), nil
+}
+
+// Delete removes the object, an object that does not exist anymore is not an error
+func (s *storageLib) Delete(ctx context.Context, filename string, path string) error {
+	err := s.getObjectPlace(path + "/" + filename).Delete(ctx)
+	if err != nil && !goerr.Is(err, storage.ErrObjectNotExist) {
+		return err
+	}
 
-	return imageURL, nil
+	return nil
 }
 
-func (s *storageLib) UploadFromBytes(ctx context.Context, file *bytes.Reader, fileName string, path string) (string, error) {
-	var imageURL string
-	writer := s.getObjectPlace(path + "/" + fileName).NewWriter(ctx)
+// Download opens the object for reading, the caller must close the reader
+func (s *storageLib) Download(ctx context.Context, filename string, path string) (io.ReadCloser, error) {
+	return s.getObjectPlace(path + "/" + filename).NewReader(ctx)
+}
 
-	if _, err := io.Copy(writer, file); err != nil {
-		return imageURL, err
+// DownloadRange opens length bytes of the object from offset for reading, a negative length reads to the end
+func (s *storageLib) DownloadRange(ctx context.Context, filename string, path string, offset int64, length int64) (io.ReadCloser, error) {
+	return s.getObjectPlace(path+"/"+filename).NewRangeReader(ctx, offset, length)
+}
+
+func (s *storageLib) Attributes(ctx context.Context, filename string, path string) (ObjectAttrs, error) {
+	var objectAttrs ObjectAttrs
+
+	attrs, err := s.getObjectPlace(path + "/" + filename).Attrs(ctx)
+	if goerr.Is(err, storage.ErrObjectNotExist) {
+		return objectAttrs, errors.NotFound("File not found")
+	} else if err != nil {
+		return objectAttrs, err
 	}
 
-	if err := writer.Close(); err != nil {
-		return imageURL, err
+	objectAttrs = ObjectAttrs{
+		ContentType: attrs.ContentType,
+		Size:        attrs.Size,
+		ETag:        attrs.Etag,
+		Updated:     attrs.Updated,
 	}
 
-	parsedURL, err := url.Parse(fmt.Sprintf("https://storage.googleapis.com/%s/%s/%s", s.BucketName, path, fileName))
+	return objectAttrs, nil
+}
+
+// SignedURL returns a V4 signed URL that grants read access to the object until it expires
+func (s *storageLib) SignedURL(ctx context.Context, filename string, path string) (string, error) {
+	options := &storage.SignedURLOptions{
+		GoogleAccessID: s.Config.ClientEmail,
+		PrivateKey:     []byte(s.Config.PrivateKey),
+		Method:         http.MethodGet,
+		Expires:        time.Now().Add(s.SignedURLTTL),
+		Scheme:         storage.SigningSchemeV4,
+	}
+
+	return s.client.Bucket(s.BucketName).SignedURL(path+"/"+filename, options)
+}
+
+// SignedUploadURL returns a V4 signed URL to upload the object, the bucket rejects an upload whose content type
+// or MD5 checksum, base64 encoded, differ from the signed ones
+func (s *storageLib) SignedUploadURL(ctx context.Context, filename string, path string, contentType string, checksum string) (SignedUpload, error) {
+	expires := time.Now().Add(s.SignedURLTTL)
+	options := &storage.SignedURLOptions{
+		GoogleAccessID: s.Config.ClientEmail,
+		PrivateKey:     []byte(s.Config.PrivateKey),
+		Method:         http.MethodPut,
+		ContentType:    contentType,
+		MD5:            checksum,
+		Expires:        expires,
+		Scheme:         storage.SigningSchemeV4,
+	}
+
+	signedURL, err := s.client.Bucket(s.BucketName).SignedURL(path+"/"+filename, options)
 	if err != nil {
-		return imageURL, err
+		return SignedUpload{}, err
 	}
 
-	imageURL = parsedURL.String()
+	signedUpload := SignedUpload{
+		URL:    signedURL,
+		Method: http.MethodPut,
+		Headers: map[string]string{
+			"Content-Type": contentType,
+			"Content-MD5":  checksum,
+		},
+		ExpiresAt: expires.Unix(),
+	}
 
-	return imageURL, nil
+	return signedUpload, nil
 }
 
-func (s *storageLib) Delete(ctx context.Context, filename string, path string) error {
-	return s.getObjectPlace(path + "/" + filename).Delete(ctx)
+// VerifySignedURL is not supported, the URLs signed for a bucket are verified by the bucket itself
+func (s *storageLib) VerifySignedURL(method string, filename string, path string, expires int64, signature string) error {
+	return errors.NotFound("File not found")
+}
+
+// Checksum returns the base64 encoded MD5 checksum of the object, as computed by the bucket
+func (s *storageLib) Checksum(ctx context.Context, filename string, path string) (string, error) {
+	attrs, err := s.getObjectPlace(path + "/" + filename).Attrs(ctx)
+	if goerr.Is(err, storage.ErrObjectNotExist) {
+		return "", errors.NotFound("File not found")
+	} else if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(attrs.MD5), nil
 }
@@ -50,6 +50,8 @@ func (v *validator) messageForTag(err validatorLib.FieldError) string {
 		return fmt.Sprintf("The %s field is required.", err.Field())
 	case "min":
 		return fmt.Sprintf("The %s field must be at least %s characters.", err.Field(), err.Param())
+	case "max":
+		return fmt.Sprintf("The %s field must not exceed %s.", err.Field(), err.Param())
 	}
 	return fmt.Sprintf("The %s field is invalid.", err.Field())
 }
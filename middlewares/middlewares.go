@@ -18,11 +18,17 @@ const (
 	HeaderRequestId = "x-request-id"
 )
 
+// untimedRoutes stream their response for as long as the client reads it, a deadline would cut it short
+var untimedRoutes = map[string]bool{
+	"GET /photos/archive": true,
+}
+
 type Interface interface {
 	SetTimeout(c *gin.Context)
 	AddFieldsToCtx(c *gin.Context)
 	SetCors() gin.HandlerFunc
 	CheckJWT() gin.HandlerFunc
+	CheckModerator() gin.HandlerFunc
 }
 
 type middleware struct {
@@ -52,12 +58,16 @@ func Init(param InitParam) Interface {
 	}
 }
 
-// Timeout middleware wraps the request context with a timeout.
+// Timeout middleware wraps the request context with a timeout, except for the untimed routes.
 func (m *middleware) SetTimeout(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(c.Request.Context(), time.Duration(m.config.Server.RequestTimeoutSec) * time.Second)
+	ctx := c.Request.Context()
+	if !untimedRoutes[c.Request.Method+" "+c.FullPath()] {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(m.config.Server.RequestTimeoutSec) * time.Second)
 
-	// Cancel to clean up resources
-	defer cancel()
+		// Cancel to clean up resources
+		defer cancel()
+	}
 
 	// Set the new context and replace the request context
 	ctx = appcontext.SetRequestStartTime(ctx, time.Now())
@@ -81,12 +91,13 @@ func (m *middleware) AddFieldsToCtx(c *gin.Context) {
 func (m *middleware) SetCors() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-		c.Writer.Header().Set("Access-Control-Expose-Headers", "Content-Length, Content-Range")
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, HEAD, POST, PUT, PATCH, DELETE, OPTIONS")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Share-Password, Range, If-None-Match, Tus-Resumable, Upload-Length, Upload-Metadata, Upload-Offset")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "Content-Length, Content-Range, Accept-Ranges, ETag, Link, Location, Tus-Resumable, Tus-Version, Tus-Extension, Tus-Max-Size, Upload-Offset, Upload-Length, Upload-Expires, X-Photo-ID")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
 
-		if c.Request.Method == "OPTIONS" {
+		// Only preflight requests are answered here, other OPTIONS requests such as the tus discovery are routed
+		if c.Request.Method == "OPTIONS" && c.GetHeader("Access-Control-Request-Method") != "" {
 			c.AbortWithStatus(204)
 			return
 		}
@@ -129,3 +140,18 @@ func (m *middleware) checkJWT(c *gin.Context) {
 	c.Request = c.Request.WithContext(ctx)
 	c.Next()
 }
+
+// CheckModerator only lets the moderators through, it comes after CheckJWT
+func (m *middleware) CheckModerator() gin.HandlerFunc {
+	return m.checkModerator
+}
+
+func (m *middleware) checkModerator(c *gin.Context) {
+	if err := m.usecase.Users.CheckModerator(c.Request.Context()); err != nil {
+		m.response.Error(c, err)
+		c.Abort()
+		return
+	}
+
+	c.Next()
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"gorm.io/gorm"
+	"rakamin-final-task/helpers/response"
+)
+
+type PhotoLikes struct {
+	ID        int64 `gorm:"primaryKey" json:"id"`
+	CreatedAt int64 `json:"createdAt"`
+
+	PhotoID int64 `gorm:"not null;index:photo_id_user_id_idx,unique" json:"photoID"`
+	UserID  int64 `gorm:"not null;index:photo_id_user_id_idx,unique;index" json:"userID"`
+}
+
+type PhotoLikeParams struct {
+	PhotoID int64 `json:"photoID" uri:"photo_id"`
+	UserID  int64 `json:"userID"`
+}
+
+type Comments struct {
+	ID        int64          `gorm:"primaryKey" json:"id"`
+	CreatedAt int64          `json:"createdAt"`
+	UpdatedAt int64          `json:"updatedAt"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	CreatedBy *int64         `json:"createdBy"`
+	UpdatedBy *int64         `json:"updatedBy"`
+	DeletedBy *int64         `json:"deletedBy"`
+
+	PhotoID    int64   `gorm:"not null;index" json:"photoID"`
+	UserID     int64   `gorm:"not null;index" json:"userID"`
+	ParentID   *int64  `gorm:"index" json:"parentID"`
+	Content    string  `gorm:"not null;type:text" json:"content"`
+	ReplyCount int64   `gorm:"->;-:migration" json:"replyCount"`
+	Photo      *Photos `gorm:"foreignKey:PhotoID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+type CommentParams struct {
+	ID      int64 `json:"id" uri:"comment_id"`
+	PhotoID int64 `json:"photoID" uri:"photo_id"`
+	UserID  int64 `json:"userID"`
+
+	// ParentID lists the replies of a comment, top level comments are listed when it is empty
+	ParentID int64 `json:"-" form:"parent_id" gorm:"-"`
+	ListFilter
+	response.PaginationParam
+}
+
+type CreateCommentParams struct {
+	Content  string `json:"content" validate:"required,max=2000"`
+	ParentID int64  `json:"parentID"`
+}
+
+type UpdateCommentParams struct {
+	Content string `json:"content" validate:"required,max=2000"`
+}
@@ -0,0 +1,9 @@
+package models
+
+// SignedFileParams is a request for a file of the local storage through its signed URL
+type SignedFileParams struct {
+	Path      string `uri:"path"`
+	FileName  string `uri:"file_name"`
+	Expires   int64  `form:"expires"`
+	Signature string `form:"signature"`
+}
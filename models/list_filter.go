@@ -0,0 +1,11 @@
+package models
+
+// ListFilter is the filter grammar shared by the list endpoints
+type ListFilter struct {
+	// Sort is a comma separated list of field:direction pairs, e.g. "title:asc,created:desc"
+	Sort string `json:"-" form:"sort" gorm:"-"`
+
+	// CreatedFrom and CreatedTo accept a unix timestamp, an RFC3339 time or a YYYY-MM-DD date, both ends are inclusive
+	CreatedFrom string `json:"-" form:"created_from" gorm:"-"`
+	CreatedTo   string `json:"-" form:"created_to" gorm:"-"`
+}
@@ -0,0 +1,59 @@
+package models
+
+import "rakamin-final-task/helpers/response"
+
+const (
+	// A photo is pending until its image is moderated, allowed until it is flagged, and flagged until a moderator
+	// approves or rejects it. Pending, flagged and rejected photos are hidden from everyone but their owner.
+	PhotoModerationPending  = "pending"
+	PhotoModerationAllowed  = "allowed"
+	PhotoModerationFlagged  = "flagged"
+	PhotoModerationApproved = "approved"
+	PhotoModerationRejected = "rejected"
+
+	ModerationSourceText  = "text"
+	ModerationSourceImage = "image"
+
+	// ModerationSourceReport flags the photos reported by enough users, the flag is reviewed along with the reports
+	ModerationSourceReport = "report"
+
+	ModerationStatusPending  = "pending"
+	ModerationStatusApproved = "approved"
+	ModerationStatusRejected = "rejected"
+)
+
+// HiddenPhotoModerations are the moderation statuses of the photos hidden from everyone but their owner
+var HiddenPhotoModerations = []string{PhotoModerationPending, PhotoModerationFlagged, PhotoModerationRejected}
+
+// PhotoModerations are the flags raised by the moderator on the photos, a flag waits in the moderation queue until
+// a moderator approves or rejects it. A rejection by the moderator itself is recorded as rejected at once.
+type PhotoModerations struct {
+	ID        int64 `gorm:"primaryKey" json:"id"`
+	CreatedAt int64 `json:"createdAt"`
+	UpdatedAt int64 `json:"updatedAt"`
+
+	PhotoID int64  `gorm:"not null;index" json:"photoID"`
+	Source  string `gorm:"not null;type:varchar(20)" json:"source"`
+	Action  string `gorm:"not null;type:varchar(20)" json:"action"`
+	Reasons string `gorm:"not null;type:text;default:''" json:"reasons"`
+	Status  string `gorm:"not null;type:varchar(20);default:pending;index" json:"status"`
+
+	ReviewedBy *int64 `json:"reviewedBy"`
+	ReviewedAt *int64 `json:"reviewedAt"`
+	Note       string `gorm:"not null;type:text;default:''" json:"note"`
+
+	Photo *Photos `gorm:"foreignKey:PhotoID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"photo,omitempty"`
+}
+
+type ModerationParams struct {
+	ID int64 `json:"id" uri:"moderation_id"`
+
+	// Status lists the pending flags by default, the reviewed ones are kept for audit
+	Status string `json:"-" form:"status" validate:"omitempty,oneof=pending approved rejected"`
+	ListFilter
+	response.PaginationParam
+}
+
+type ReviewModerationParams struct {
+	Note string `json:"note" validate:"max=1000"`
+}
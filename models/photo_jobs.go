@@ -0,0 +1,57 @@
+package models
+
+const (
+	PhotoStatusPending = "pending"
+	PhotoStatusReady   = "ready"
+	PhotoStatusFailed  = "failed"
+
+	// PhotoJobImage describes the image of the photo file and renders it in the rendition formats
+	PhotoJobImage = "image"
+
+	// PhotoJobModerate moderates the image of the photo file
+	PhotoJobModerate = "moderate"
+
+	// PhotoJobStore names a file uploaded straight to the storage after its content, the file then goes through
+	// the jobs of a new photo file
+	PhotoJobStore = "store"
+
+	PhotoJobStatusPending = "pending"
+	PhotoJobStatusDone    = "done"
+	PhotoJobStatusFailed  = "failed"
+)
+
+// PhotoJobKinds are the jobs every new photo file goes through
+var PhotoJobKinds = []string{PhotoJobImage, PhotoJobModerate}
+
+// PhotoJobs are the processing steps of a photo file, run in the background once the file is stored. A photo is
+// pending until all of its jobs are done, and failed once one of them has failed for good.
+type PhotoJobs struct {
+	ID        int64 `gorm:"primaryKey" json:"id"`
+	CreatedAt int64 `json:"createdAt"`
+	UpdatedAt int64 `json:"updatedAt"`
+
+	PhotoID  int64  `gorm:"not null;uniqueIndex:idx_photo_jobs_photo_kind" json:"photoID"`
+	Kind     string `gorm:"not null;type:varchar(20);uniqueIndex:idx_photo_jobs_photo_kind" json:"kind"`
+	Status   string `gorm:"not null;type:varchar(20);default:pending;index:idx_photo_jobs_due,priority:1" json:"status"`
+	RunAt    int64  `gorm:"not null;default:0;index:idx_photo_jobs_due,priority:2" json:"runAt"`
+	Attempts int    `gorm:"not null;default:0" json:"attempts"`
+	Error    string `gorm:"not null;type:text;default:''" json:"error"`
+
+	// Revision changes whenever the job is claimed or enqueued again, an attempt only ends the revision it claimed
+	Revision int64 `gorm:"not null;default:0" json:"-"`
+
+	Photo *Photos `gorm:"foreignKey:PhotoID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+// PhotoStatus is the processing status of a photo along with its jobs
+type PhotoStatus struct {
+	ID               int64       `json:"id"`
+	ProcessingStatus string      `json:"processingStatus"`
+	Jobs             []PhotoJobs `json:"jobs"`
+}
+
+type PhotoStatusParams struct {
+	ID int64 `uri:"photo_id"`
+	// Wait is how many seconds the request waits for a pending photo to be processed before returning
+	Wait int `form:"wait" validate:"min=0,max=60"`
+}
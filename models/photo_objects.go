@@ -0,0 +1,33 @@
+package models
+
+const (
+	DuplicatePolicyAllow  = "allow"
+	DuplicatePolicyWarn   = "warn"
+	DuplicatePolicyReject = "reject"
+)
+
+// PhotoObjects counts the photos and versions that refer to a stored file. Files are named after the SHA-256 of
+// their content, so the same content is stored once, and a file is only deleted once nothing refers to it anymore.
+type PhotoObjects struct {
+	FileName  string `gorm:"primaryKey;type:varchar(255)" json:"fileName"`
+	CreatedAt int64  `json:"createdAt"`
+	UpdatedAt int64  `json:"updatedAt"`
+
+	Hash     string `gorm:"not null;type:varchar(64);default:''" json:"hash"`
+	Size     int64  `gorm:"not null;default:0" json:"size"`
+	RefCount int64  `gorm:"not null;default:0;index" json:"refCount"`
+}
+
+// PhotoRenditions are copies of a stored file in another format, a rendition is served instead of the file to the
+// clients that accept its format and is deleted along with the file
+type PhotoRenditions struct {
+	ObjectName string `gorm:"primaryKey;type:varchar(255)" json:"objectName"`
+	Format     string `gorm:"primaryKey;type:varchar(10)" json:"format"`
+	CreatedAt  int64  `json:"createdAt"`
+
+	FileName    string `gorm:"not null;type:varchar(255)" json:"fileName"`
+	ContentType string `gorm:"not null;type:varchar(50)" json:"contentType"`
+	Size        int64  `gorm:"not null;default:0" json:"size"`
+
+	Object *PhotoObjects `gorm:"foreignKey:ObjectName;references:FileName;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
@@ -0,0 +1,26 @@
+package models
+
+// PhotoRenders are the transformed photos cached by GET /photos/:photo_id/render. A render is named after the file
+// it was rendered from and the parameters of its preset, and is deleted along with that file.
+type PhotoRenders struct {
+	FileName  string `gorm:"primaryKey;type:varchar(255)" json:"fileName"`
+	CreatedAt int64  `json:"createdAt"`
+
+	SourceName  string `gorm:"not null;type:varchar(255);index:idx_photo_renders_source" json:"sourceName"`
+	SourcePath  string `gorm:"not null;type:varchar(20);index:idx_photo_renders_source" json:"sourcePath"`
+	ContentType string `gorm:"not null;type:varchar(50)" json:"contentType"`
+	Size        int64  `gorm:"not null;default:0" json:"size"`
+}
+
+// RenderPhotoParams is a request for a transformed photo through its signed URL, the parameters must match a preset
+type RenderPhotoParams struct {
+	ID        int64  `uri:"photo_id"`
+	Width     int    `form:"w"`
+	Height    int    `form:"h"`
+	Fit       string `form:"fit"`
+	Format    string `form:"fmt"`
+	Quality   int    `form:"q"`
+	Watermark bool   `form:"wm"`
+	Expires   int64  `form:"expires"`
+	Signature string `form:"signature"`
+}
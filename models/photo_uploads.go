@@ -0,0 +1,55 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+const (
+	PhotoUploadStatusPending   = "pending"
+	PhotoUploadStatusCompleted = "completed"
+)
+
+// PhotoUploads is a file uploaded by the client straight to the storage, the photo is only created once the
+// upload is completed and the file matches what was declared
+type PhotoUploads struct {
+	ID        int64          `gorm:"primaryKey" json:"id"`
+	CreatedAt int64          `json:"createdAt"`
+	UpdatedAt int64          `json:"updatedAt"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	CreatedBy *int64         `json:"createdBy"`
+	UpdatedBy *int64         `json:"updatedBy"`
+	DeletedBy *int64         `json:"deletedBy"`
+
+	UserID      int64   `gorm:"not null;index" json:"userID"`
+	FileName    string  `gorm:"not null;type:varchar(255);uniqueIndex" json:"fileName"`
+	ContentType string  `gorm:"not null;type:varchar(100)" json:"contentType"`
+	Size        int64   `gorm:"not null" json:"size"`
+	Checksum    string  `gorm:"not null;type:varchar(50)" json:"checksum"`
+	ContentHash string  `gorm:"not null;type:varchar(64);default:''" json:"contentHash"`
+	Status      string  `gorm:"not null;type:varchar(20);default:pending;index" json:"status"`
+	ExpiresAt   int64   `gorm:"not null;index" json:"expiresAt"`
+	PhotoID     *int64  `json:"photoID"`
+	Photo       *Photos `gorm:"foreignKey:PhotoID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"-"`
+
+	// Where and how the client uploads the file, only filled when the upload is created
+	UploadURL     string            `gorm:"-" json:"uploadURL,omitempty"`
+	UploadMethod  string            `gorm:"-" json:"uploadMethod,omitempty"`
+	UploadHeaders map[string]string `gorm:"-" json:"uploadHeaders,omitempty"`
+}
+
+type PhotoUploadParams struct {
+	ID       int64  `json:"id" uri:"upload_id"`
+	UserID   int64  `json:"userID"`
+	FileName string `json:"fileName"`
+	Status   string `json:"status"`
+}
+
+type CreatePhotoUploadParams struct {
+	ContentType string `json:"contentType" validate:"required,oneof=image/jpeg image/png image/gif image/webp image/avif"`
+	Size        int64  `json:"size" validate:"required,min=1"`
+	// Checksum is the base64 encoded MD5 checksum of the file, as sent in a Content-MD5 header
+	Checksum string `json:"checksum" validate:"required,base64,len=24"`
+	// ContentHash is the hex encoded SHA-256 of the file, the duplicates are found by it when the upload is
+	// completed and it is verified once the file is stored
+	ContentHash string `json:"contentHash" validate:"required,hexadecimal,len=64"`
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// PhotoVersions keeps a previous file of a photo until it expires, so a replaced file can be rolled back
+type PhotoVersions struct {
+	ID        int64          `gorm:"primaryKey" json:"id"`
+	CreatedAt int64          `json:"createdAt"`
+	UpdatedAt int64          `json:"updatedAt"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	CreatedBy *int64         `json:"createdBy"`
+	UpdatedBy *int64         `json:"updatedBy"`
+	DeletedBy *int64         `json:"deletedBy"`
+
+	PhotoID     int64  `gorm:"not null;index" json:"photoID"`
+	UserID      int64  `gorm:"not null;index" json:"userID"`
+	PhotoURL    string `gorm:"not null;type:text" json:"photoURL"`
+	ContentHash string `gorm:"not null;type:varchar(64);default:''" json:"contentHash"`
+	PhotoImage
+	ExpiresAt int64   `gorm:"not null;index" json:"expiresAt"`
+	Photo     *Photos `gorm:"foreignKey:PhotoID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+type PhotoVersionParams struct {
+	ID      int64 `json:"id" uri:"version_id"`
+	PhotoID int64 `json:"photoID" uri:"photo_id"`
+	UserID  int64 `json:"userID"`
+}
+
+// SwitchPhotoFileParams points a photo to a new file. The current file is kept as a version until RetainUntil,
+// or released when RetainUntil is zero, and the restored version is removed since its file becomes the current one.
+// Process enqueues the jobs of the new file, its image is then described in the background instead of by NewImage.
+type SwitchPhotoFileParams struct {
+	PhotoID           int64
+	UserID            int64
+	CurrentURL        string
+	CurrentHash       string
+	CurrentImage      PhotoImage
+	NewURL            string
+	NewHash           string
+	NewImage          PhotoImage
+	Process           bool
+	RetainUntil       int64
+	RestoredVersionID int64
+}
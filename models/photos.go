@@ -1,10 +1,19 @@
 package models
 
 import (
+	"slices"
+
 	"gorm.io/gorm"
 	"rakamin-final-task/helpers/response"
 )
 
+const (
+	VisibilityPrivate   = "private"
+	VisibilityUnlisted  = "unlisted"
+	VisibilityPublic    = "public"
+	VisibilityFollowers = "followers"
+)
+
 type Photos struct {
 	ID        int64          `gorm:"primaryKey" json:"id"`
 	CreatedAt int64          `json:"createdAt"`
@@ -14,24 +23,184 @@ type Photos struct {
 	UpdatedBy *int64         `json:"updatedBy"`
 	DeletedBy *int64         `json:"deletedBy"`
 
-	Title    string `gorm:"not null;type:varchar(255)" json:"title"`
-	Caption  string `gorm:"not null;type:text" json:"caption"`
-	PhotoURL string `gorm:"not null;type:text" json:"photoURL"`
-	UserID   int64  `gorm:"not null" json:"userID"`
+	Title      string `gorm:"not null;type:varchar(255)" json:"title"`
+	Caption    string `gorm:"not null;type:text" json:"caption"`
+	PhotoURL   string `gorm:"not null;type:text" json:"photoURL"`
+	UserID     int64  `gorm:"not null;index" json:"userID"`
+	Visibility string `gorm:"not null;type:varchar(20);default:private;index" json:"visibility"`
+
+	// ContentHash is the SHA-256 of the file, it is empty for the files stored before the content was hashed
+	ContentHash string `gorm:"not null;type:varchar(64);default:'';index" json:"contentHash"`
+
+	PhotoImage
+
+	// ProcessingStatus is pending until the background jobs of the photo file complete, it is ready or failed then
+	ProcessingStatus string `gorm:"not null;type:varchar(20);default:ready;index" json:"processingStatus"`
+
+	// ModerationStatus is allowed until the photo is flagged by the moderator, see the photo moderation statuses
+	ModerationStatus string `gorm:"not null;type:varchar(20);default:allowed;index" json:"moderationStatus"`
+
+	// OwnerHidden is whether the owner of the photo is hidden by the reports, it is read along with the photo
+	OwnerHidden bool `gorm:"->;-:migration" json:"-"`
+
+	IsCommentDisabled *bool `gorm:"default:false" json:"isCommentDisabled"`
+	LikeCount         int64 `gorm:"not null;default:0" json:"likeCount"`
+	CommentCount      int64 `gorm:"not null;default:0" json:"commentCount"`
+
+	Tags []Tags `gorm:"many2many:photo_tags;joinForeignKey:PhotoID;joinReferences:TagID" json:"tags"`
+
+	// Full-text search result, only filled when the photos are searched by keyword. The highlights are HTML escaped
+	// text where the matches are wrapped in <mark>.
+	SearchRank       float64 `gorm:"->;-:migration" json:"searchRank,omitempty"`
+	TitleHighlight   string  `gorm:"->;-:migration" json:"titleHighlight,omitempty"`
+	CaptionHighlight string  `gorm:"->;-:migration" json:"captionHighlight,omitempty"`
+
+	// PurgeAt is when a trashed photo is permanently deleted, only filled in the trash
+	PurgeAt int64 `gorm:"-" json:"purgeAt,omitempty"`
+
+	// Distance is the Hamming distance to the compared photo, only filled when the similar photos are listed
+	Distance *int `gorm:"->;-:migration" json:"distance,omitempty"`
+
+	// DuplicateOf is the existing photo of the user with the same content, only filled when a duplicate is created
+	DuplicateOf *int64 `gorm:"-" json:"duplicateOf,omitempty"`
+
+	// RenderURLs are the signed URLs of the photo transformed by every render preset, by preset name
+	RenderURLs map[string]string `gorm:"-" json:"renderURLs,omitempty"`
+}
+
+// PhotoImage describes the image of a photo file, it is empty when the image can not be decoded. The dimensions,
+// blurred placeholder and dominant colour let a client lay out and show the photo before its file is loaded.
+type PhotoImage struct {
+	// PerceptualHash is the difference hash of the image, close hashes are images that look alike. The hashes of
+	// the photos are indexed by bands, see database.PerceptualHashBand.
+	PerceptualHash *int64 `json:"-"`
+
+	Width         *int    `json:"width"`
+	Height        *int    `json:"height"`
+	BlurHash      *string `gorm:"type:varchar(64)" json:"blurHash"`
+	DominantColor *string `gorm:"type:varchar(7)" json:"dominantColor"`
+}
+
+// IsVisibleTo reports whether the user can see the photo. Followers only photos are visible to the owner only,
+// since there is no follower relation yet, and so are the photos hidden by moderation.
+func (p Photos) IsVisibleTo(userID int64) bool {
+	if p.UserID == userID {
+		return true
+	}
+
+	if p.IsHidden() {
+		return false
+	}
+
+	return p.Visibility == VisibilityPublic || p.Visibility == VisibilityUnlisted
+}
+
+// IsHidden reports whether the photo is hidden by moderation, until its image is moderated, until a moderator
+// approves it or for good, or whether its owner is hidden by the reports
+func (p Photos) IsHidden() bool {
+	return slices.Contains(HiddenPhotoModerations, p.ModerationStatus) || p.OwnerHidden
 }
 
 type PhotoParams struct {
 	ID     int64 `json:"id" uri:"photo_id"`
 	UserID int64 `json:"userID" uri:"user_id"`
+
+	// Tag filter, "and" requires every tag while "or" requires at least one of them
+	Tags    []string `json:"-" form:"tag" gorm:"-"`
+	TagMode string   `json:"-" form:"tagMode" gorm:"-"`
+
+	// HasCaption keeps only the photos with a caption when it is true, and only the ones without when it is false
+	HasCaption *bool `json:"-" form:"has_caption" gorm:"-"`
+
+	// Language restricts the keyword search to a single configured text search language
+	Language string `json:"-" form:"language" gorm:"-"`
+
+	// Visibilities restricts the result to the given visibility levels, it is never bound from the request
+	Visibilities []string `json:"-" form:"-" gorm:"-"`
+
+	// ExcludeHidden leaves out the photos hidden by moderation and the photos of the hidden users, it is never bound
+	// from the request
+	ExcludeHidden bool `json:"-" form:"-" gorm:"-"`
+
+	// ContentHash finds the photos with the given content, it is never bound from the request
+	ContentHash string `json:"-" form:"-"`
+	ListFilter
 	response.PaginationParam
 }
 
 type CreatePhotoParams struct {
-	Title   string `json:"title" form:"title" validate:"required"`
-	Caption string `json:"caption" form:"caption" validate:"required"`
+	Title      string   `json:"title" form:"title" validate:"required"`
+	Caption    string   `json:"caption" form:"caption" validate:"required"`
+	Tags       []string `json:"tags" form:"tags" validate:"max=20,dive,max=50"`
+	Visibility string   `json:"visibility" form:"visibility" validate:"omitempty,oneof=private unlisted public followers"`
+
+	// OnDuplicate overrides the configured duplicate policy for this upload
+	OnDuplicate string `json:"onDuplicate" form:"onDuplicate" validate:"omitempty,oneof=allow warn reject"`
+}
+
+// CreateBatchPhotoParams holds the title and caption of every uploaded file by its position,
+// the tags and visibility are shared by the whole batch
+type CreateBatchPhotoParams struct {
+	Titles      []string `json:"titles" form:"titles"`
+	Captions    []string `json:"captions" form:"captions"`
+	Tags        []string `json:"tags" form:"tags"`
+	Visibility  string   `json:"visibility" form:"visibility"`
+	OnDuplicate string   `json:"onDuplicate" form:"onDuplicate"`
+}
+
+// BatchPhotoResult is the outcome of a single file of a batch upload, the batch succeeds partially
+type BatchPhotoResult struct {
+	Index     int         `json:"index"`
+	FileName  string      `json:"fileName"`
+	IsSuccess bool        `json:"isSuccess"`
+	Code      int64       `json:"code"`
+	Message   interface{} `json:"message,omitempty"`
+	Photo     *Photos     `json:"photo,omitempty"`
+}
+
+const (
+	BulkActionDelete     = "delete"
+	BulkActionVisibility = "visibility"
+	BulkActionAddTags    = "add_tags"
+	BulkActionRemoveTags = "remove_tags"
+	// BulkActionMoveToAlbum is refused since the photos are not grouped in albums
+	BulkActionMoveToAlbum = "move_to_album"
+)
+
+type BulkPhotoParams struct {
+	Action     string   `json:"action" validate:"required,oneof=delete visibility add_tags remove_tags move_to_album"`
+	IDs        []int64  `json:"ids" validate:"required,min=1,max=500"`
+	Visibility string   `json:"visibility" validate:"omitempty,oneof=private unlisted public followers"`
+	Tags       []string `json:"tags" validate:"max=20,dive,max=50"`
+}
+
+// BulkPhotoResult is the outcome of a bulk operation for a single photo
+type BulkPhotoResult struct {
+	ID        int64  `json:"id"`
+	IsSuccess bool   `json:"isSuccess"`
+	Code      int64  `json:"code"`
+	Message   string `json:"message,omitempty"`
+}
+
+// SimilarPhotoParams finds the photos of the user that look like the given photo, the closest first
+type SimilarPhotoParams struct {
+	ID          int64 `uri:"photo_id"`
+	MaxDistance *int  `form:"maxDistance" validate:"omitempty,min=0,max=64"`
+	Limit       int   `form:"limit" validate:"omitempty,min=1,max=100"`
+}
+
+// PhotoArchiveParams selects the photos to download, ids is a comma separated list or a repeated query
+type PhotoArchiveParams struct {
+	IDs []string `form:"ids"`
 }
 
 type UpdatePhotoParams struct {
-	Title   string `json:"title"`
-	Caption string `json:"caption"`
+	Title      string `json:"title"`
+	Caption    string `json:"caption"`
+	Visibility string `json:"visibility" validate:"omitempty,oneof=private unlisted public followers"`
+
+	IsCommentDisabled *bool `json:"isCommentDisabled"`
+
+	// Tags replaces the photo tags when it is present, an empty array removes all tags
+	Tags []string `json:"tags" validate:"max=20,dive,max=50"`
 }
@@ -0,0 +1,70 @@
+package models
+
+import "rakamin-final-task/helpers/response"
+
+const (
+	ReportReasonSpam       = "spam"
+	ReportReasonHarassment = "harassment"
+	ReportReasonNudity     = "nudity"
+	ReportReasonViolence   = "violence"
+	ReportReasonCopyright  = "copyright"
+	ReportReasonOther      = "other"
+
+	ReportTargetPhoto = "photo"
+	ReportTargetUser  = "user"
+
+	ReportStatusPending  = "pending"
+	ReportStatusResolved = "resolved"
+
+	// A dismissed report shows the target again, an upheld one rejects the photo or keeps the user hidden
+	ReportOutcomeDismissed = "dismissed"
+	ReportOutcomeUpheld    = "upheld"
+)
+
+// Reports are the abuse reports of the users on a photo or on another user, either PhotoID or ReportedUserID is
+// set. A user has at most one pending report per target, and the target is hidden once enough distinct users have
+// reported it. A resolved report keeps its outcome, who resolved it and when for audit.
+type Reports struct {
+	ID        int64 `gorm:"primaryKey" json:"id"`
+	CreatedAt int64 `json:"createdAt"`
+	UpdatedAt int64 `json:"updatedAt"`
+
+	ReporterID     int64  `gorm:"not null;uniqueIndex:idx_reports_pending_photo,where:status = 'pending';uniqueIndex:idx_reports_pending_user,where:status = 'pending'" json:"reporterID"`
+	PhotoID        *int64 `gorm:"index;uniqueIndex:idx_reports_pending_photo,where:status = 'pending'" json:"photoID"`
+	ReportedUserID *int64 `gorm:"index;uniqueIndex:idx_reports_pending_user,where:status = 'pending'" json:"reportedUserID"`
+	Reason         string `gorm:"not null;type:varchar(20)" json:"reason"`
+	Details        string `gorm:"not null;type:text;default:''" json:"details"`
+	Status         string `gorm:"not null;type:varchar(20);default:pending;index" json:"status"`
+
+	Outcome    string `gorm:"not null;type:varchar(20);default:''" json:"outcome"`
+	ResolvedBy *int64 `json:"resolvedBy"`
+	ResolvedAt *int64 `json:"resolvedAt"`
+	Note       string `gorm:"not null;type:text;default:''" json:"note"`
+
+	Reporter     *Users  `gorm:"foreignKey:ReporterID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Photo        *Photos `gorm:"foreignKey:PhotoID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"photo,omitempty"`
+	ReportedUser *Users  `gorm:"foreignKey:ReportedUserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"reportedUser,omitempty"`
+}
+
+type CreateReportParams struct {
+	Reason  string `json:"reason" validate:"required,oneof=spam harassment nudity violence copyright other"`
+	Details string `json:"details" validate:"max=1000"`
+}
+
+type ReportParams struct {
+	ID int64 `json:"id" uri:"report_id"`
+
+	// Status lists the pending reports by default, the resolved ones are kept for audit
+	Status string `json:"-" form:"status" validate:"omitempty,oneof=pending resolved"`
+
+	// Target lists the reports on photos or on users only
+	Target string `json:"-" form:"target" gorm:"-" validate:"omitempty,oneof=photo user"`
+	ListFilter
+	response.PaginationParam
+}
+
+// ResolveReportParams resolves a pending report along with the other pending reports on the same target
+type ResolveReportParams struct {
+	Outcome string `json:"outcome" validate:"required,oneof=dismissed upheld"`
+	Note    string `json:"note" validate:"max=1000"`
+}
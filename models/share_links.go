@@ -0,0 +1,51 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+type ShareLinks struct {
+	ID        int64          `gorm:"primaryKey" json:"id"`
+	CreatedAt int64          `json:"createdAt"`
+	UpdatedAt int64          `json:"updatedAt"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	CreatedBy *int64         `json:"createdBy"`
+	UpdatedBy *int64         `json:"updatedBy"`
+	DeletedBy *int64         `json:"deletedBy"`
+
+	Token       string  `gorm:"not null;unique;type:varchar(64)" json:"token"`
+	PhotoID     int64   `gorm:"not null;index" json:"photoID"`
+	UserID      int64   `gorm:"not null;index" json:"userID"`
+	Password    string  `gorm:"type:text" json:"-"`
+	HasPassword bool    `gorm:"not null;default:false" json:"hasPassword"`
+	ExpiresAt   *int64  `json:"expiresAt"`
+	MaxViews    *int64  `json:"maxViews"`
+	ViewCount   int64   `gorm:"not null;default:0" json:"viewCount"`
+	IsRevoked   *bool   `gorm:"default:false" json:"isRevoked"`
+	Photo       *Photos `gorm:"foreignKey:PhotoID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+
+	// PasswordAttempts counts the passwords tried since the last right one, the link is locked until LockedUntil
+	// once there are too many of them
+	PasswordAttempts int64  `gorm:"not null;default:0" json:"-"`
+	LockedUntil      *int64 `json:"-"`
+}
+
+type ShareLinkParams struct {
+	ID        int64  `json:"id" uri:"share_link_id"`
+	Token     string `json:"token" uri:"token"`
+	PhotoID   int64  `json:"photoID" uri:"photo_id"`
+	UserID    int64  `json:"userID"`
+	IsRevoked *bool  `json:"isRevoked"`
+}
+
+type CreateShareLinkParams struct {
+	// ExpiresInSec is the lifetime of the link in seconds, the link never expires when it is empty
+	ExpiresInSec int64  `json:"expiresInSec" validate:"omitempty,min=60"`
+	MaxViews     int64  `json:"maxViews" validate:"omitempty,min=1"`
+	Password     string `json:"password" validate:"omitempty,min=6"`
+}
+
+type ResolveShareLinkParams struct {
+	Token    string `uri:"token"`
+	Password string `header:"x-share-password"`
+}
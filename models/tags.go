@@ -0,0 +1,31 @@
+package models
+
+const (
+	TagModeAnd = "and"
+	TagModeOr  = "or"
+)
+
+type Tags struct {
+	ID        int64  `gorm:"primaryKey" json:"id"`
+	CreatedAt int64  `json:"createdAt"`
+	UpdatedAt int64  `json:"updatedAt"`
+	CreatedBy *int64 `json:"createdBy"`
+	UpdatedBy *int64 `json:"updatedBy"`
+
+	Name       string `gorm:"not null;type:varchar(50);index:user_id_name_idx,unique" json:"name"`
+	UserID     int64  `gorm:"not null;index:user_id_name_idx,unique" json:"userID"`
+	UsageCount int64  `gorm:"->;-:migration" json:"usageCount,omitempty"`
+}
+
+type PhotoTags struct {
+	PhotoID   int64 `gorm:"primaryKey;autoIncrement:false"`
+	TagID     int64 `gorm:"primaryKey;autoIncrement:false;index"`
+	CreatedAt int64
+}
+
+type TagParams struct {
+	UserID int64    `json:"userID"`
+	Names  []string `json:"names" gorm:"-"`
+	Prefix string   `json:"prefix" form:"prefix" gorm:"-"`
+	Limit  int64    `json:"limit" form:"limit" gorm:"-"`
+}
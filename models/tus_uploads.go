@@ -0,0 +1,49 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// TusUploads is a resumable upload of the tus protocol, its data is kept on the local disk until it is complete
+// and the photo is created from it
+type TusUploads struct {
+	ID        int64          `gorm:"primaryKey" json:"id"`
+	CreatedAt int64          `json:"createdAt"`
+	UpdatedAt int64          `json:"updatedAt"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	CreatedBy *int64         `json:"createdBy"`
+	UpdatedBy *int64         `json:"updatedBy"`
+	DeletedBy *int64         `json:"deletedBy"`
+
+	UserID      int64   `gorm:"not null;index" json:"userID"`
+	Length      int64   `gorm:"not null" json:"length"`
+	Offset      int64   `gorm:"not null;default:0" json:"offset"`
+	FileName    string  `gorm:"not null;type:varchar(255)" json:"fileName"`
+	ContentType string  `gorm:"not null;type:varchar(100)" json:"contentType"`
+	ExpiresAt   int64   `gorm:"not null;index" json:"expiresAt"`
+	PhotoID     *int64  `json:"photoID"`
+	Photo       *Photos `gorm:"foreignKey:PhotoID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"-"`
+
+	// The photo created once the upload is complete, tags are comma separated
+	Title      string `gorm:"not null;type:varchar(255)" json:"title"`
+	Caption    string `gorm:"not null;type:text" json:"caption"`
+	Visibility string `gorm:"type:varchar(20)" json:"visibility"`
+	Tags       string `gorm:"type:text" json:"tags"`
+}
+
+type TusUploadParams struct {
+	ID     int64 `json:"id" uri:"upload_id"`
+	UserID int64 `json:"userID"`
+}
+
+// CreateTusUploadParams is read from the Upload-Length and Upload-Metadata headers, the metadata keys are
+// filename, filetype, title, caption, visibility and tags
+type CreateTusUploadParams struct {
+	Length   int64
+	Metadata map[string]string
+}
+
+// PatchTusUploadParams is read from the Upload-Offset header
+type PatchTusUploadParams struct {
+	Offset int64
+}
@@ -14,11 +14,13 @@ type Users struct {
 	UpdatedBy *int64         `json:"updatedBy"`
 	DeletedBy *int64         `json:"deletedBy"`
 
-	Username  string   `gorm:"not null;unique;type:varchar(255)" json:"username"`
-	Email     string   `gorm:"not null;unique;type:varchar(255)" json:"email"`
-	Password  string   `gorm:"not null;type:text" json:"-"`
-	IsActived *bool    `gorm:"default:true" json:"isActived"`
-	Photos    []Photos `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Username    string   `gorm:"not null;unique;type:varchar(255)" json:"username"`
+	Email       string   `gorm:"not null;unique;type:varchar(255)" json:"email"`
+	Password    string   `gorm:"not null;type:text" json:"-"`
+	IsActived   *bool    `gorm:"default:true" json:"isActived"`
+	IsModerator *bool    `gorm:"default:false" json:"isModerator"`
+	IsHidden    *bool    `gorm:"default:false" json:"isHidden"`
+	Photos      []Photos `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
 }
 
 type UserParams struct {
@@ -0,0 +1,49 @@
+package models
+
+const (
+	WatermarkTypeText  = "text"
+	WatermarkTypeImage = "image"
+)
+
+// Watermarks are the watermark settings of a user. Once enabled, the public and unlisted photos of the user are
+// served to everyone else as watermarked copies, the original files are left untouched.
+type Watermarks struct {
+	UserID    int64 `gorm:"primaryKey" json:"userID"`
+	CreatedAt int64 `json:"createdAt"`
+	UpdatedAt int64 `json:"updatedAt"`
+
+	Enabled  bool    `gorm:"not null;default:false" json:"enabled"`
+	Type     string  `gorm:"not null;type:varchar(10);default:text" json:"type"`
+	Text     string  `gorm:"not null;type:varchar(100);default:''" json:"text"`
+	LogoURL  string  `gorm:"not null;type:text;default:''" json:"logoURL"`
+	Position string  `gorm:"not null;type:varchar(20);default:bottom-right" json:"position"`
+	Opacity  float64 `gorm:"not null;default:0.5" json:"opacity"`
+
+	// Version goes up with every change of the settings, the copies rendered with an older version are rendered again
+	Version int64 `gorm:"not null;default:0" json:"version"`
+
+	User *Users `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+// UpdateWatermarkParams changes the given settings only, a logo uploaded along with them replaces the current one
+type UpdateWatermarkParams struct {
+	Enabled  *bool    `json:"enabled" form:"enabled"`
+	Type     string   `json:"type" form:"type" validate:"omitempty,oneof=text image"`
+	Text     *string  `json:"text" form:"text" validate:"omitempty,max=100"`
+	Position string   `json:"position" form:"position" validate:"omitempty,oneof=top-left top-right bottom-left bottom-right center"`
+	Opacity  *float64 `json:"opacity" form:"opacity" validate:"omitempty,gt=0,lte=1"`
+}
+
+// PhotoWatermarks are the watermarked copies of the photo files, a copy is rendered from the file at SourceURL
+// with the given version of the settings of the owner
+type PhotoWatermarks struct {
+	PhotoID   int64 `gorm:"primaryKey" json:"photoID"`
+	CreatedAt int64 `json:"createdAt"`
+	UpdatedAt int64 `json:"updatedAt"`
+
+	FileName  string `gorm:"not null;type:varchar(255)" json:"fileName"`
+	SourceURL string `gorm:"not null;type:text" json:"sourceURL"`
+	Version   int64  `gorm:"not null" json:"version"`
+
+	Photo *Photos `gorm:"foreignKey:PhotoID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
@@ -0,0 +1,195 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+	"rakamin-final-task/models"
+)
+
+// @Summary Like Photo
+// @Description Like a photo that is visible to the current user
+// @Tags Engagements
+// @Produce json
+// @Param photo_id path int true "Photo ID"
+// @Security BearerAuth
+// @Success 200 {object} response.HTTPResponse{data=models.Photos}
+// @Failure 400 {object} response.HTTPResponse{}
+// @Failure 404 {object} response.HTTPResponse{}
+// @Failure 500 {object} response.HTTPResponse{}
+// @Router /photos/{photo_id}/like [POST]
+func (r *router) LikePhoto(c *gin.Context) {
+	var likeParam models.PhotoLikeParams
+	if err := r.BindParam(c, &likeParam); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	photo, err := r.usecase.Engagements.Like(c.Request.Context(), likeParam)
+	if err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	r.response.Success(c, "Like photo successfull", photo, nil)
+}
+
+// @Summary Unlike Photo
+// @Description Remove the like of the current user from a photo
+// @Tags Engagements
+// @Produce json
+// @Param photo_id path int true "Photo ID"
+// @Security BearerAuth
+// @Success 200 {object} response.HTTPResponse{data=models.Photos}
+// @Failure 400 {object} response.HTTPResponse{}
+// @Failure 404 {object} response.HTTPResponse{}
+// @Failure 500 {object} response.HTTPResponse{}
+// @Router /photos/{photo_id}/like [DELETE]
+func (r *router) UnlikePhoto(c *gin.Context) {
+	var likeParam models.PhotoLikeParams
+	if err := r.BindParam(c, &likeParam); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	photo, err := r.usecase.Engagements.Unlike(c.Request.Context(), likeParam)
+	if err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	r.response.Success(c, "Unlike photo successfull", photo, nil)
+}
+
+// @Summary Create Comment
+// @Description Comment on a photo, or reply to a comment when the parent ID is set
+// @Tags Engagements
+// @Produce json
+// @Param photo_id path int true "Photo ID"
+// @Param commentBody body models.CreateCommentParams true "Comment Body"
+// @Security BearerAuth
+// @Success 201 {object} response.HTTPResponse{data=models.Comments}
+// @Failure 400 {object} response.HTTPResponse{}
+// @Failure 403 {object} response.HTTPResponse{}
+// @Failure 404 {object} response.HTTPResponse{}
+// @Failure 422 {object} response.HTTPResponse{}
+// @Failure 500 {object} response.HTTPResponse{}
+// @Router /photos/{photo_id}/comments [POST]
+func (r *router) CreateComment(c *gin.Context) {
+	var body models.CreateCommentParams
+	if err := r.BindBody(c, &body); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	var commentParam models.CommentParams
+	if err := r.BindParam(c, &commentParam); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	comment, err := r.usecase.Engagements.CreateComment(c.Request.Context(), commentParam, body)
+	if err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	r.response.Created(c, "Comment created", comment)
+}
+
+// @Summary Get List Comment
+// @Description Get the top level comments of a photo, or the replies of a comment when the parent ID is set
+// @Tags Engagements
+// @Produce json
+// @Param photo_id path int true "Photo ID"
+// @Param parent_id query int false "Parent Comment ID"
+// @Param sort query string false "Comma separated field:direction pairs, fields are created and updated" example(created:asc)
+// @Param created_from query string false "Created from, unix timestamp, RFC3339 time or YYYY-MM-DD date"
+// @Param created_to query string false "Created to, unix timestamp, RFC3339 time or YYYY-MM-DD date"
+// @Param page query int false "Page"
+// @Param limit query int false "Limit"
+// @Param cursor query string false "Cursor of the next or previous page, the page is ignored when it is set"
+// @Param with_total query bool false "Count the total elements"
+// @Security BearerAuth
+// @Success 200 {object} response.HTTPResponse{data=[]models.Comments,meta=response.PaginationParam}
+// @Failure 400 {object} response.HTTPResponse{}
+// @Failure 404 {object} response.HTTPResponse{}
+// @Failure 500 {object} response.HTTPResponse{}
+// @Router /photos/{photo_id}/comments [GET]
+func (r *router) GetListComment(c *gin.Context) {
+	var commentParam models.CommentParams
+	if err := r.BindParam(c, &commentParam); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	comments, pg, err := r.usecase.Engagements.GetListComment(c.Request.Context(), commentParam)
+	if err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	r.response.Success(c, "Get list comment successfull", comments, pg)
+}
+
+// @Summary Update Comment
+// @Description Edit a comment of the current user
+// @Tags Engagements
+// @Produce json
+// @Param photo_id path int true "Photo ID"
+// @Param comment_id path int true "Comment ID"
+// @Param commentBody body models.UpdateCommentParams true "Comment Body"
+// @Security BearerAuth
+// @Success 200 {object} response.HTTPResponse{data=models.Comments}
+// @Failure 400 {object} response.HTTPResponse{}
+// @Failure 404 {object} response.HTTPResponse{}
+// @Failure 422 {object} response.HTTPResponse{}
+// @Failure 500 {object} response.HTTPResponse{}
+// @Router /photos/{photo_id}/comments/{comment_id} [PUT]
+func (r *router) UpdateComment(c *gin.Context) {
+	var body models.UpdateCommentParams
+	if err := r.BindBody(c, &body); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	var commentParam models.CommentParams
+	if err := r.BindParam(c, &commentParam); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	comment, err := r.usecase.Engagements.UpdateComment(c.Request.Context(), commentParam, body)
+	if err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	r.response.Success(c, "Update comment successfull", comment, nil)
+}
+
+// @Summary Delete Comment
+// @Description Delete a comment along with its replies, allowed for the author and the photo owner
+// @Tags Engagements
+// @Produce json
+// @Param photo_id path int true "Photo ID"
+// @Param comment_id path int true "Comment ID"
+// @Security BearerAuth
+// @Success 200 {object} response.HTTPResponse{}
+// @Failure 400 {object} response.HTTPResponse{}
+// @Failure 403 {object} response.HTTPResponse{}
+// @Failure 404 {object} response.HTTPResponse{}
+// @Failure 500 {object} response.HTTPResponse{}
+// @Router /photos/{photo_id}/comments/{comment_id} [DELETE]
+func (r *router) DeleteComment(c *gin.Context) {
+	var commentParam models.CommentParams
+	if err := r.BindParam(c, &commentParam); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	if err := r.usecase.Engagements.DeleteComment(c.Request.Context(), commentParam); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	r.response.Success(c, "Delete comment successfull", nil, nil)
+}
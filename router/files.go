@@ -0,0 +1,73 @@
+package router
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"rakamin-final-task/models"
+)
+
+// @Summary Get Signed File
+// @Description Get a file of the local storage through its signed URL, as returned in the photo URLs
+// @Tags Files
+// @Produce image/*
+// @Param path path string true "Path"
+// @Param file_name path string true "File name"
+// @Param expires query int true "Expiry, unix timestamp"
+// @Param signature query string true "Signature"
+// @Param Range header string false "Byte range, e.g. bytes=0-1023"
+// @Param If-None-Match header string false "ETag of the cached file"
+// @Param Accept header string false "Accepted image types, e.g. image/avif,image/webp,*/*"
+// @Success 200 {file} file
+// @Success 206 {file} file
+// @Success 304 "Not Modified"
+// @Failure 403 {object} response.HTTPResponse{}
+// @Failure 404 {object} response.HTTPResponse{}
+// @Failure 500 {object} response.HTTPResponse{}
+// @Router /files/{path}/{file_name} [GET]
+func (r *router) GetSignedFile(c *gin.Context) {
+	var fileParam models.SignedFileParams
+	if err := r.BindParam(c, &fileParam); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	content, err := r.usecase.Photos.GetSignedContent(c.Request.Context(), fileParam, c.GetHeader("Accept"))
+	if err != nil {
+		r.response.Error(c, err)
+		return
+	}
+	defer content.Close()
+
+	// The URL stops working when it expires, a cache must not keep the file for longer than the URL lives
+	r.serveContent(c, content, "private", max(fileParam.Expires-time.Now().Unix(), 0))
+}
+
+// @Summary Put Signed File
+// @Description Upload a file to the local storage through its signed upload URL, as returned by a photo upload
+// @Tags Files
+// @Accept image/*
+// @Produce json
+// @Param path path string true "Path"
+// @Param file_name path string true "File name"
+// @Param expires query int true "Expiry, unix timestamp"
+// @Param signature query string true "Signature"
+// @Success 200 {object} response.HTTPResponse{}
+// @Failure 403 {object} response.HTTPResponse{}
+// @Failure 404 {object} response.HTTPResponse{}
+// @Failure 500 {object} response.HTTPResponse{}
+// @Router /files/{path}/{file_name} [PUT]
+func (r *router) PutSignedFile(c *gin.Context) {
+	var fileParam models.SignedFileParams
+	if err := r.BindParam(c, &fileParam); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	if err := r.usecase.Photos.PutSignedContent(c.Request.Context(), fileParam, c.Request.Body); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	r.response.Success(c, "Upload file successfull", nil, nil)
+}
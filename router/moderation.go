@@ -0,0 +1,116 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+	"rakamin-final-task/models"
+)
+
+// @Summary Get Moderation Queue
+// @Description Get the flags raised by the moderator on the photos, the pending ones by default and oldest first.
+// @Description A flagged photo is hidden from everyone but its owner until a moderator approves or rejects it.
+// @Tags Moderation
+// @Produce json
+// @Param status query string false "Status" Enums(pending, approved, rejected)
+// @Param sort query string false "Comma separated field:direction pairs, fields are created and updated" example(created:asc)
+// @Param created_from query string false "Created from, unix timestamp, RFC3339 time or YYYY-MM-DD date"
+// @Param created_to query string false "Created to, unix timestamp, RFC3339 time or YYYY-MM-DD date"
+// @Param page query int false "Page"
+// @Param limit query int false "Limit"
+// @Param cursor query string false "Cursor of the next or previous page, the page is ignored when it is set"
+// @Param with_total query bool false "Count the total elements"
+// @Security BearerAuth
+// @Success 200 {object} response.HTTPResponse{data=[]models.PhotoModerations,meta=response.PaginationParam}
+// @Failure 400 {object} response.HTTPResponse{}
+// @Failure 403 {object} response.HTTPResponse{}
+// @Failure 422 {object} response.HTTPResponse{}
+// @Failure 500 {object} response.HTTPResponse{}
+// @Router /moderation/queue [GET]
+func (r *router) GetModerationQueue(c *gin.Context) {
+	var moderationParam models.ModerationParams
+	if err := r.BindParam(c, &moderationParam); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	moderations, pg, err := r.usecase.Moderation.GetListQueue(c.Request.Context(), moderationParam)
+	if err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	r.response.Success(c, "Get moderation queue successfull", moderations, pg)
+}
+
+// @Summary Approve Moderation
+// @Description Approve a pending flag, the photo is shown again once none of its flags is pending
+// @Tags Moderation
+// @Produce json
+// @Param moderation_id path int true "Moderation ID"
+// @Param reviewBody body models.ReviewModerationParams true "Review Body"
+// @Security BearerAuth
+// @Success 200 {object} response.HTTPResponse{data=models.PhotoModerations}
+// @Failure 400 {object} response.HTTPResponse{}
+// @Failure 403 {object} response.HTTPResponse{}
+// @Failure 404 {object} response.HTTPResponse{}
+// @Failure 409 {object} response.HTTPResponse{}
+// @Failure 422 {object} response.HTTPResponse{}
+// @Failure 500 {object} response.HTTPResponse{}
+// @Router /moderation/queue/{moderation_id}/approve [POST]
+func (r *router) ApproveModeration(c *gin.Context) {
+	var body models.ReviewModerationParams
+	if err := r.BindBody(c, &body); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	var moderationParam models.ModerationParams
+	if err := r.BindParam(c, &moderationParam); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	moderation, err := r.usecase.Moderation.Approve(c.Request.Context(), moderationParam, body)
+	if err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	r.response.Success(c, "Approve moderation successfull", moderation, nil)
+}
+
+// @Summary Reject Moderation
+// @Description Reject a pending flag, the photo stays hidden from everyone but its owner for good
+// @Tags Moderation
+// @Produce json
+// @Param moderation_id path int true "Moderation ID"
+// @Param reviewBody body models.ReviewModerationParams true "Review Body"
+// @Security BearerAuth
+// @Success 200 {object} response.HTTPResponse{data=models.PhotoModerations}
+// @Failure 400 {object} response.HTTPResponse{}
+// @Failure 403 {object} response.HTTPResponse{}
+// @Failure 404 {object} response.HTTPResponse{}
+// @Failure 409 {object} response.HTTPResponse{}
+// @Failure 422 {object} response.HTTPResponse{}
+// @Failure 500 {object} response.HTTPResponse{}
+// @Router /moderation/queue/{moderation_id}/reject [POST]
+func (r *router) RejectModeration(c *gin.Context) {
+	var body models.ReviewModerationParams
+	if err := r.BindBody(c, &body); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	var moderationParam models.ModerationParams
+	if err := r.BindParam(c, &moderationParam); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	moderation, err := r.usecase.Moderation.Reject(c.Request.Context(), moderationParam, body)
+	if err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	r.response.Success(c, "Reject moderation successfull", moderation, nil)
+}
@@ -0,0 +1,72 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+	"rakamin-final-task/models"
+)
+
+// @Summary Create Photo Upload
+// @Description Reserve a file and get the signed URL to upload it to the storage directly.
+// @Description The file must be sent with the returned method and headers, then the upload must be completed.
+// @Tags Photos
+// @Produce json
+// @Param uploadBody body models.CreatePhotoUploadParams true "Upload Body"
+// @Security BearerAuth
+// @Success 201 {object} response.HTTPResponse{data=models.PhotoUploads}
+// @Failure 400 {object} response.HTTPResponse{}
+// @Failure 422 {object} response.HTTPResponse{}
+// @Failure 500 {object} response.HTTPResponse{}
+// @Router /photos/uploads [POST]
+func (r *router) CreatePhotoUpload(c *gin.Context) {
+	var body models.CreatePhotoUploadParams
+	if err := r.BindBody(c, &body); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	photoUpload, err := r.usecase.Photos.CreateUpload(c.Request.Context(), body)
+	if err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	r.response.Created(c, "Photo upload created", photoUpload)
+}
+
+// @Summary Complete Photo Upload
+// @Description Verify the uploaded file against its declared size, type and checksum, and create its photo.
+// @Description The file is stored under its content hash in the background, the photo is pending until then.
+// @Tags Photos
+// @Produce json
+// @Param upload_id path int true "Upload ID"
+// @Param photoBody body models.CreatePhotoParams true "Photo Body"
+// @Security BearerAuth
+// @Success 201 {object} response.HTTPResponse{data=models.Photos}
+// @Failure 400 {object} response.HTTPResponse{}
+// @Failure 404 {object} response.HTTPResponse{}
+// @Failure 409 {object} response.HTTPResponse{}
+// @Failure 410 {object} response.HTTPResponse{}
+// @Failure 422 {object} response.HTTPResponse{}
+// @Failure 500 {object} response.HTTPResponse{}
+// @Router /photos/uploads/{upload_id}/complete [POST]
+func (r *router) CompletePhotoUpload(c *gin.Context) {
+	var uploadParam models.PhotoUploadParams
+	if err := r.BindParam(c, &uploadParam); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	var body models.CreatePhotoParams
+	if err := r.BindBody(c, &body); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	photo, err := r.usecase.Photos.CompleteUpload(c.Request.Context(), uploadParam, body)
+	if err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	r.response.Created(c, "Photo created", photo)
+}
@@ -0,0 +1,106 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+	"rakamin-final-task/helpers/errors"
+	"rakamin-final-task/models"
+)
+
+// @Summary Replace Photo File
+// @Description Replace the image file of a photo, the previous file is kept as a version for rollback.
+// @Description A file with the same content as the current one leaves the photo unchanged.
+// @Tags Photos
+// @Produce json
+// @Param photo_id path int true "Photo ID"
+// @Param photo formData file true "Photo"
+// @Accept multipart/form-data
+// @Security BearerAuth
+// @Success 200 {object} response.HTTPResponse{data=models.Photos}
+// @Failure 400 {object} response.HTTPResponse{}
+// @Failure 404 {object} response.HTTPResponse{}
+// @Failure 409 {object} response.HTTPResponse{}
+// @Failure 500 {object} response.HTTPResponse{}
+// @Router /photos/{photo_id}/file [PUT]
+func (r *router) ReplacePhotoFile(c *gin.Context) {
+	var photoParam models.PhotoParams
+	if err := r.BindParam(c, &photoParam); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	photoFile, meta, err := c.Request.FormFile("photo")
+	if err != nil {
+		r.response.Error(c, errors.BadRequest("File not found"))
+		return
+	}
+
+	image, err := r.getPhotos(photoFile, meta)
+	if err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	photo, err := r.usecase.Photos.ReplaceFile(c.Request.Context(), photoParam, image)
+	if err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	r.response.Success(c, "Replace photo file successfull", photo, nil)
+}
+
+// @Summary Get List Photo Version
+// @Description Get list of retained previous files of a photo
+// @Tags Photos
+// @Produce json
+// @Param photo_id path int true "Photo ID"
+// @Security BearerAuth
+// @Success 200 {object} response.HTTPResponse{data=[]models.PhotoVersions}
+// @Failure 400 {object} response.HTTPResponse{}
+// @Failure 500 {object} response.HTTPResponse{}
+// @Router /photos/{photo_id}/versions [GET]
+func (r *router) GetListPhotoVersion(c *gin.Context) {
+	var versionParam models.PhotoVersionParams
+	if err := r.BindParam(c, &versionParam); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	versions, err := r.usecase.Photos.GetListVersion(c.Request.Context(), versionParam)
+	if err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	r.response.Success(c, "Get list photo version successfull", versions, nil)
+}
+
+// @Summary Restore Photo Version
+// @Description Roll the photo back to a retained previous file
+// @Tags Photos
+// @Produce json
+// @Param photo_id path int true "Photo ID"
+// @Param version_id path int true "Version ID"
+// @Security BearerAuth
+// @Success 200 {object} response.HTTPResponse{data=models.Photos}
+// @Failure 400 {object} response.HTTPResponse{}
+// @Failure 404 {object} response.HTTPResponse{}
+// @Failure 409 {object} response.HTTPResponse{}
+// @Failure 410 {object} response.HTTPResponse{}
+// @Failure 500 {object} response.HTTPResponse{}
+// @Router /photos/{photo_id}/versions/{version_id}/restore [POST]
+func (r *router) RestorePhotoVersion(c *gin.Context) {
+	var versionParam models.PhotoVersionParams
+	if err := r.BindParam(c, &versionParam); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	photo, err := r.usecase.Photos.RestoreVersion(c.Request.Context(), versionParam)
+	if err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	r.response.Success(c, "Restore photo version successfull", photo, nil)
+}
@@ -1,26 +1,35 @@
 package router
 
 import (
+	"fmt"
 	"mime/multipart"
+	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"rakamin-final-task/helpers/errors"
 	"rakamin-final-task/helpers/files"
+	"rakamin-final-task/helpers/storage"
 	"rakamin-final-task/models"
 )
 
 // @Summary Create Photo
-// @Description Create photo
+// @Description Create photo, an exact duplicate of a photo of the user is allowed, returned with the ID of the
+// @Description duplicated photo on warn, or rejected, according to onDuplicate or the configured policy
 // @Tags Photos
 // @Produce json
 // @Param title formData string true "Title"
 // @Param caption formData string true "Caption"
+// @Param tags formData []string false "Tags" collectionFormat(multi)
+// @Param visibility formData string false "Visibility" Enums(private, unlisted, public, followers)
+// @Param onDuplicate formData string false "Duplicate policy" Enums(allow, warn, reject)
 // @Param photo formData file true "Photo"
 // @Accept multipart/form-data
 // @Security BearerAuth
 // @Success 201 {object} response.HTTPResponse{data=models.Photos}
 // @Failure 400 {object} response.HTTPResponse{}
 // @Failure 404 {object} response.HTTPResponse{}
+// @Failure 409 {object} response.HTTPResponse{}
 // @Failure 500 {object} response.HTTPResponse{}
 // @Router /photos [POST]
 func (r *router) CreatePhoto(c *gin.Context) {
@@ -52,6 +61,296 @@ func (r *router) CreatePhoto(c *gin.Context) {
 	r.response.Created(c, "Photo created", photo)
 }
 
+// @Summary Create Batch Photo
+// @Description Create a photo for every uploaded file, the titles and captions are matched to the files by position.
+// @Description Every file has its own result, a failing file does not fail the others.
+// @Tags Photos
+// @Produce json
+// @Param titles formData []string true "Titles" collectionFormat(multi)
+// @Param captions formData []string true "Captions" collectionFormat(multi)
+// @Param tags formData []string false "Tags of every photo" collectionFormat(multi)
+// @Param visibility formData string false "Visibility of every photo" Enums(private, unlisted, public, followers)
+// @Param onDuplicate formData string false "Duplicate policy of every photo" Enums(allow, warn, reject)
+// @Param photos formData []file true "Photos" collectionFormat(multi)
+// @Accept multipart/form-data
+// @Security BearerAuth
+// @Success 200 {object} response.HTTPResponse{data=[]models.BatchPhotoResult}
+// @Failure 400 {object} response.HTTPResponse{}
+// @Failure 500 {object} response.HTTPResponse{}
+// @Router /photos/batch [POST]
+func (r *router) CreateBatchPhoto(c *gin.Context) {
+	var body models.CreateBatchPhotoParams
+
+	if err := r.BindBody(c, &body); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		r.response.Error(c, errors.BadRequest("File not found"))
+		return
+	}
+
+	images := []*files.File{}
+	for _, meta := range form.File["photos"] {
+		photoFile, err := meta.Open()
+		if err != nil {
+			r.response.Error(c, errors.BadRequest("Failed to read file "+meta.Filename))
+			return
+		}
+		defer photoFile.Close()
+
+		images = append(images, files.Init(photoFile, meta))
+	}
+
+	results, err := r.usecase.Photos.CreateBatch(c.Request.Context(), body, images)
+	if err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	r.response.Success(c, "Create batch photo successfull", results, nil)
+}
+
+// @Summary Bulk Photo
+// @Description Apply an action to many photos in a single transaction: delete, visibility, add_tags or remove_tags.
+// @Description The move_to_album action is refused with a 400 since the photos are not grouped in albums.
+// @Description Every photo has its own result, the photos that are not found are reported without failing the others.
+// @Tags Photos
+// @Produce json
+// @Param bulkBody body models.BulkPhotoParams true "Bulk Body"
+// @Security BearerAuth
+// @Success 200 {object} response.HTTPResponse{data=[]models.BulkPhotoResult}
+// @Failure 400 {object} response.HTTPResponse{}
+// @Failure 422 {object} response.HTTPResponse{}
+// @Failure 500 {object} response.HTTPResponse{}
+// @Router /photos/bulk [POST]
+func (r *router) BulkPhoto(c *gin.Context) {
+	var body models.BulkPhotoParams
+	if err := r.BindBody(c, &body); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	results, err := r.usecase.Photos.Bulk(c.Request.Context(), body)
+	if err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	r.response.Success(c, "Bulk photo successfull", results, nil)
+}
+
+// @Summary Download Photo Archive
+// @Description Download photos as a ZIP archive streamed as it is built, the entries are named after the photo titles.
+// @Description The photos that are not found are left out of the archive.
+// @Description The photos are selected by ID, they are not grouped in albums that could be downloaded whole.
+// @Description The photos whose file can not be read are left out or cut short and listed in an errors.txt entry.
+// @Description The download is not bound by the request timeout.
+// @Tags Photos
+// @Produce application/zip
+// @Param ids query []string true "Comma separated photo IDs" collectionFormat(csv)
+// @Security BearerAuth
+// @Success 200 {file} file
+// @Failure 400 {object} response.HTTPResponse{}
+// @Failure 404 {object} response.HTTPResponse{}
+// @Failure 500 {object} response.HTTPResponse{}
+// @Router /photos/archive [GET]
+func (r *router) DownloadPhotoArchive(c *gin.Context) {
+	var archiveParam models.PhotoArchiveParams
+	if err := r.BindParam(c, &archiveParam); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	photos, err := r.usecase.Photos.GetListArchive(c.Request.Context(), archiveParam)
+	if err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="photos-%d.zip"`, time.Now().Unix()))
+	c.Header("Content-Type", "application/zip")
+	c.Status(http.StatusOK)
+
+	err = r.usecase.Photos.WriteArchive(c.Request.Context(), photos, c.Writer)
+	if err == nil {
+		return
+	}
+
+	// Once the first bytes are sent the failure can only be logged, and the client gets a truncated archive
+	if !c.Writer.Written() {
+		c.Writer.Header().Del("Content-Disposition")
+		c.Writer.Header().Del("Content-Type")
+		r.response.Error(c, err)
+		return
+	}
+	r.log.Error(c.Request.Context(), err.Error())
+}
+
+// @Summary Get Photo Content
+// @Description Get the file of a photo the user can see, with support for range and conditional requests.
+// @Description A WebP or AVIF rendition is returned instead when the Accept header lists its type
+// @Tags Photos
+// @Produce image/*
+// @Param photo_id path int true "Photo ID"
+// @Param Range header string false "Byte range, e.g. bytes=0-1023"
+// @Param If-None-Match header string false "ETag of the cached file"
+// @Param Accept header string false "Accepted image types, e.g. image/avif,image/webp,*/*"
+// @Security BearerAuth
+// @Success 200 {file} file
+// @Success 206 {file} file
+// @Success 304 "Not Modified"
+// @Failure 400 {object} response.HTTPResponse{}
+// @Failure 404 {object} response.HTTPResponse{}
+// @Failure 416 "Range Not Satisfiable"
+// @Failure 500 {object} response.HTTPResponse{}
+// @Router /photos/{photo_id}/content [GET]
+func (r *router) GetPhotoContent(c *gin.Context) {
+	var photoParam models.PhotoParams
+	if err := r.BindParam(c, &photoParam); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	photo, content, err := r.usecase.Photos.GetContent(c.Request.Context(), photoParam, c.GetHeader("Accept"))
+	if err != nil {
+		r.response.Error(c, err)
+		return
+	}
+	defer content.Close()
+
+	// Only public photos may be kept by shared caches
+	cacheScope := "private"
+	if photo.Visibility == models.VisibilityPublic {
+		cacheScope = "public"
+	}
+
+	r.serveContent(c, content, cacheScope, r.config.Storage.ContentMaxAgeSec)
+}
+
+// @Summary Render Photo
+// @Description Get the photo resized, cropped and converted by a render preset, through one of the render URLs
+// @Description returned along with the photo. The parameters must match a preset and the URL must be signed.
+// @Tags Photos
+// @Produce image/*
+// @Param photo_id path int true "Photo ID"
+// @Param w query int false "Width"
+// @Param h query int false "Height"
+// @Param fit query string false "Fit" Enums(cover, contain)
+// @Param fmt query string true "Format" Enums(jpeg, png, webp, avif)
+// @Param q query int false "Quality"
+// @Param wm query bool false "Watermarked"
+// @Param expires query int true "Expiry, unix timestamp"
+// @Param signature query string true "Signature"
+// @Param Range header string false "Byte range, e.g. bytes=0-1023"
+// @Param If-None-Match header string false "ETag of the cached file"
+// @Success 200 {file} file
+// @Success 206 {file} file
+// @Success 304 "Not Modified"
+// @Failure 400 {object} response.HTTPResponse{}
+// @Failure 403 {object} response.HTTPResponse{}
+// @Failure 404 {object} response.HTTPResponse{}
+// @Failure 500 {object} response.HTTPResponse{}
+// @Router /photos/{photo_id}/render [GET]
+func (r *router) RenderPhoto(c *gin.Context) {
+	var renderParam models.RenderPhotoParams
+	if err := r.BindParam(c, &renderParam); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	photo, content, err := r.usecase.Photos.Render(c.Request.Context(), renderParam)
+	if err != nil {
+		r.response.Error(c, err)
+		return
+	}
+	defer content.Close()
+
+	cacheScope := "private"
+	if photo.Visibility == models.VisibilityPublic {
+		cacheScope = "public"
+	}
+
+	r.serveContent(c, content, cacheScope, r.config.Storage.ContentMaxAgeSec)
+}
+
+// @Summary Get List Similar Photo
+// @Description Get list of the photos of the user that look like the photo, the closest first. The distance is the
+// @Description number of differing bits of the perceptual hashes, from 0 for the same image to 64.
+// @Description A maximum distance up to 15 is searched through the indexes, a wider one compares every photo of the user.
+// @Tags Photos
+// @Produce json
+// @Param photo_id path int true "Photo ID"
+// @Param maxDistance query int false "Maximum distance" default(10)
+// @Param limit query int false "Limit" default(20)
+// @Security BearerAuth
+// @Success 200 {object} response.HTTPResponse{data=[]models.Photos}
+// @Failure 400 {object} response.HTTPResponse{}
+// @Failure 404 {object} response.HTTPResponse{}
+// @Failure 409 {object} response.HTTPResponse{}
+// @Failure 500 {object} response.HTTPResponse{}
+// @Router /photos/{photo_id}/similar [GET]
+func (r *router) GetListSimilarPhoto(c *gin.Context) {
+	var similarParam models.SimilarPhotoParams
+	if err := r.BindParam(c, &similarParam); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	photos, err := r.usecase.Photos.GetListSimilar(c.Request.Context(), similarParam)
+	if err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	r.response.Success(c, "Get list similar photo successfull", photos, nil)
+}
+
+// @Summary Get Photo Status
+// @Description Get the processing status of the photo and its jobs, a new photo file is pending until it has been
+// @Description processed in the background. A pending photo is waited for up to wait seconds, so a client can poll
+// @Description again at once and learns as soon as the processing completes.
+// @Tags Photos
+// @Produce json
+// @Param photo_id path int true "Photo ID"
+// @Param wait query int false "Seconds to wait for a pending photo" minimum(0) maximum(60)
+// @Security BearerAuth
+// @Success 200 {object} response.HTTPResponse{data=models.PhotoStatus}
+// @Failure 400 {object} response.HTTPResponse{}
+// @Failure 404 {object} response.HTTPResponse{}
+// @Failure 500 {object} response.HTTPResponse{}
+// @Router /photos/{photo_id}/status [GET]
+func (r *router) GetPhotoStatus(c *gin.Context) {
+	var statusParam models.PhotoStatusParams
+	if err := r.BindParam(c, &statusParam); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	status, err := r.usecase.Photos.GetStatus(c.Request.Context(), statusParam)
+	if err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	r.response.Success(c, "Get photo status successfull", status, nil)
+}
+
+// serveContent streams the file and answers the range and conditional requests from its attributes,
+// the file may be cached for the given seconds
+func (r *router) serveContent(c *gin.Context, content *storage.ObjectReader, cacheScope string, maxAgeSec int64) {
+	c.Header("Cache-Control", fmt.Sprintf("%s, max-age=%d", cacheScope, maxAgeSec))
+	c.Header("ETag", fmt.Sprintf(`"%s"`, content.Attrs.ETag))
+	c.Header("Vary", "Accept")
+	if content.Attrs.ContentType != "" {
+		c.Header("Content-Type", content.Attrs.ContentType)
+	}
+
+	http.ServeContent(c.Writer, c.Request, "", content.Attrs.Updated, content)
+}
+
 func (r *router) getPhotos(file multipart.File, meta *multipart.FileHeader) (*files.File, error) {
 	image := files.Init(file, meta)
 
@@ -95,6 +394,16 @@ func (r *router) GetPhoto(c *gin.Context) {
 // @Produce json
 // @Param page query int false "Page"
 // @Param limit query int false "Limit"
+// @Param cursor query string false "Cursor of the next or previous page, the page is ignored when it is set"
+// @Param with_total query bool false "Count the total elements"
+// @Param tag query []string false "Tag" collectionFormat(multi)
+// @Param tagMode query string false "Tag mode" Enums(and, or)
+// @Param keyword query string false "Full-text search keyword"
+// @Param language query string false "Text search language, all configured languages are used when empty"
+// @Param sort query string false "Comma separated field:direction pairs, fields are created, updated and title" example(created:desc)
+// @Param created_from query string false "Created from, unix timestamp, RFC3339 time or YYYY-MM-DD date"
+// @Param created_to query string false "Created to, unix timestamp, RFC3339 time or YYYY-MM-DD date"
+// @Param has_caption query bool false "Has caption"
 // @Security BearerAuth
 // @Success 200 {object} response.HTTPResponse{data=[]models.Photos,meta=response.PaginationParam}
 // @Failure 400 {object} response.HTTPResponse{}
@@ -152,7 +461,7 @@ func (r *router) UpdatePhoto(c *gin.Context) {
 }
 
 // @Summary Delete Photo
-// @Description Delete photo
+// @Description Move photo to the trash, it can be restored until it is purged
 // @Tags Photos
 // @Produce json
 // @Param photo_id path int true "Photo ID"
@@ -177,3 +486,124 @@ func (r *router) DeletePhoto(c *gin.Context) {
 
 	r.response.Success(c, "Delete photo successfull", nil, nil)
 }
+
+// @Summary Get List Trash Photo
+// @Description Get list of deleted photos that have not been purged yet
+// @Tags Photos
+// @Produce json
+// @Param page query int false "Page"
+// @Param limit query int false "Limit"
+// @Param cursor query string false "Cursor of the next or previous page, the page is ignored when it is set"
+// @Param with_total query bool false "Count the total elements"
+// @Param sort query string false "Comma separated field:direction pairs, fields are created, updated, deleted and title" example(deleted:desc)
+// @Param created_from query string false "Created from, unix timestamp, RFC3339 time or YYYY-MM-DD date"
+// @Param created_to query string false "Created to, unix timestamp, RFC3339 time or YYYY-MM-DD date"
+// @Security BearerAuth
+// @Success 200 {object} response.HTTPResponse{data=[]models.Photos}
+// @Failure 400 {object} response.HTTPResponse{}
+// @Failure 500 {object} response.HTTPResponse{}
+// @Router /photos/trash [GET]
+func (r *router) GetListTrashPhoto(c *gin.Context) {
+	var photoParam models.PhotoParams
+	if err := r.BindParam(c, &photoParam); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	photos, pg, err := r.usecase.Photos.GetListTrash(c.Request.Context(), photoParam)
+	if err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	r.response.Success(c, "Get list trash photo successfull", photos, pg)
+}
+
+// @Summary Restore Photo
+// @Description Restore a deleted photo from the trash
+// @Tags Photos
+// @Produce json
+// @Param photo_id path int true "Photo ID"
+// @Security BearerAuth
+// @Success 200 {object} response.HTTPResponse{data=models.Photos}
+// @Failure 400 {object} response.HTTPResponse{}
+// @Failure 404 {object} response.HTTPResponse{}
+// @Failure 500 {object} response.HTTPResponse{}
+// @Router /photos/{photo_id}/restore [POST]
+func (r *router) RestorePhoto(c *gin.Context) {
+	var photoParam models.PhotoParams
+	if err := r.BindParam(c, &photoParam); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	photo, err := r.usecase.Photos.Restore(c.Request.Context(), photoParam)
+	if err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	r.response.Success(c, "Restore photo successfull", photo, nil)
+}
+
+// @Summary Get Public Photo
+// @Description Get a public or unlisted photo without authentication
+// @Tags Public
+// @Produce json
+// @Param photo_id path int true "Photo ID"
+// @Success 200 {object} response.HTTPResponse{data=models.Photos}
+// @Failure 400 {object} response.HTTPResponse{}
+// @Failure 404 {object} response.HTTPResponse{}
+// @Failure 500 {object} response.HTTPResponse{}
+// @Router /public/photos/{photo_id} [GET]
+func (r *router) GetPublicPhoto(c *gin.Context) {
+	var photoParam models.PhotoParams
+	if err := r.BindParam(c, &photoParam); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	photo, err := r.usecase.Photos.GetPublic(c.Request.Context(), photoParam)
+	if err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	r.response.Success(c, "Get public photo successfull", photo, nil)
+}
+
+// @Summary Get Public Photo Feed
+// @Description Get the public photo feed, newest first, without authentication
+// @Tags Public
+// @Produce json
+// @Param page query int false "Page"
+// @Param limit query int false "Limit"
+// @Param cursor query string false "Cursor of the next or previous page, the page is ignored when it is set"
+// @Param with_total query bool false "Count the total elements"
+// @Param tag query []string false "Tag" collectionFormat(multi)
+// @Param tagMode query string false "Tag mode" Enums(and, or)
+// @Param keyword query string false "Full-text search keyword"
+// @Param language query string false "Text search language, all configured languages are used when empty"
+// @Param sort query string false "Comma separated field:direction pairs, fields are created, updated and title" example(created:desc)
+// @Param created_from query string false "Created from, unix timestamp, RFC3339 time or YYYY-MM-DD date"
+// @Param created_to query string false "Created to, unix timestamp, RFC3339 time or YYYY-MM-DD date"
+// @Param has_caption query bool false "Has caption"
+// @Success 200 {object} response.HTTPResponse{data=[]models.Photos,meta=response.PaginationParam}
+// @Failure 400 {object} response.HTTPResponse{}
+// @Failure 500 {object} response.HTTPResponse{}
+// @Router /public/photos [GET]
+func (r *router) GetListPublicPhoto(c *gin.Context) {
+	var photoParam models.PhotoParams
+	if err := r.BindParam(c, &photoParam); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	photos, pg, err := r.usecase.Photos.GetPublicList(c.Request.Context(), photoParam)
+	if err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	r.response.Success(c, "Get public photo feed successfull", photos, pg)
+}
@@ -0,0 +1,154 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+	"rakamin-final-task/models"
+)
+
+// @Summary Report Photo
+// @Description Report a photo for abuse, a user can only have one pending report per photo.
+// @Description The photo is hidden until a moderator resolves the reports once enough users have reported it.
+// @Tags Reports
+// @Produce json
+// @Param photo_id path int true "Photo ID"
+// @Param reportBody body models.CreateReportParams true "Report Body"
+// @Security BearerAuth
+// @Success 201 {object} response.HTTPResponse{data=models.Reports}
+// @Failure 400 {object} response.HTTPResponse{}
+// @Failure 404 {object} response.HTTPResponse{}
+// @Failure 409 {object} response.HTTPResponse{}
+// @Failure 422 {object} response.HTTPResponse{}
+// @Failure 500 {object} response.HTTPResponse{}
+// @Router /photos/{photo_id}/report [POST]
+func (r *router) ReportPhoto(c *gin.Context) {
+	var body models.CreateReportParams
+	if err := r.BindBody(c, &body); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	var photoParam models.PhotoParams
+	if err := r.BindParam(c, &photoParam); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	report, err := r.usecase.Moderation.ReportPhoto(c.Request.Context(), photoParam, body)
+	if err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	r.response.Created(c, "Report created", report)
+}
+
+// @Summary Report User
+// @Description Report a user for abuse, a user can only have one pending report per user.
+// @Description The photos of the user are hidden until a moderator resolves the reports once enough users have reported them.
+// @Tags Reports
+// @Produce json
+// @Param user_id path int true "User ID"
+// @Param reportBody body models.CreateReportParams true "Report Body"
+// @Security BearerAuth
+// @Success 201 {object} response.HTTPResponse{data=models.Reports}
+// @Failure 400 {object} response.HTTPResponse{}
+// @Failure 404 {object} response.HTTPResponse{}
+// @Failure 409 {object} response.HTTPResponse{}
+// @Failure 422 {object} response.HTTPResponse{}
+// @Failure 500 {object} response.HTTPResponse{}
+// @Router /users/{user_id}/report [POST]
+func (r *router) ReportUser(c *gin.Context) {
+	var body models.CreateReportParams
+	if err := r.BindBody(c, &body); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	var userParam models.UserParams
+	if err := r.BindParam(c, &userParam); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	report, err := r.usecase.Moderation.ReportUser(c.Request.Context(), userParam, body)
+	if err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	r.response.Created(c, "Report created", report)
+}
+
+// @Summary Get List Report
+// @Description Get the abuse reports on the photos and the users, the pending ones by default and oldest first
+// @Tags Moderation
+// @Produce json
+// @Param status query string false "Status" Enums(pending, resolved)
+// @Param target query string false "Target" Enums(photo, user)
+// @Param sort query string false "Comma separated field:direction pairs, fields are created and updated" example(created:asc)
+// @Param created_from query string false "Created from, unix timestamp, RFC3339 time or YYYY-MM-DD date"
+// @Param created_to query string false "Created to, unix timestamp, RFC3339 time or YYYY-MM-DD date"
+// @Param page query int false "Page"
+// @Param limit query int false "Limit"
+// @Param cursor query string false "Cursor of the next or previous page, the page is ignored when it is set"
+// @Param with_total query bool false "Count the total elements"
+// @Security BearerAuth
+// @Success 200 {object} response.HTTPResponse{data=[]models.Reports,meta=response.PaginationParam}
+// @Failure 400 {object} response.HTTPResponse{}
+// @Failure 403 {object} response.HTTPResponse{}
+// @Failure 422 {object} response.HTTPResponse{}
+// @Failure 500 {object} response.HTTPResponse{}
+// @Router /moderation/reports [GET]
+func (r *router) GetListReport(c *gin.Context) {
+	var reportParam models.ReportParams
+	if err := r.BindParam(c, &reportParam); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	reports, pg, err := r.usecase.Moderation.GetListReport(c.Request.Context(), reportParam)
+	if err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	r.response.Success(c, "Get list report successfull", reports, pg)
+}
+
+// @Summary Resolve Report
+// @Description Resolve a pending report along with the other pending reports on the same target.
+// @Description A dismissal shows the target again, an upheld report rejects the photo or keeps the user hidden.
+// @Tags Moderation
+// @Produce json
+// @Param report_id path int true "Report ID"
+// @Param resolveBody body models.ResolveReportParams true "Resolve Body"
+// @Security BearerAuth
+// @Success 200 {object} response.HTTPResponse{data=models.Reports}
+// @Failure 400 {object} response.HTTPResponse{}
+// @Failure 403 {object} response.HTTPResponse{}
+// @Failure 404 {object} response.HTTPResponse{}
+// @Failure 409 {object} response.HTTPResponse{}
+// @Failure 422 {object} response.HTTPResponse{}
+// @Failure 500 {object} response.HTTPResponse{}
+// @Router /moderation/reports/{report_id}/resolve [POST]
+func (r *router) ResolveReport(c *gin.Context) {
+	var body models.ResolveReportParams
+	if err := r.BindBody(c, &body); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	var reportParam models.ReportParams
+	if err := r.BindParam(c, &reportParam); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	report, err := r.usecase.Moderation.ResolveReport(c.Request.Context(), reportParam, body)
+	if err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	r.response.Success(c, "Resolve report successfull", report, nil)
+}
@@ -86,18 +86,82 @@ func (r router) RegisterMiddlewaresAndRoutes() {
 	userRoutes := r.http.Group("users", r.middlewares.CheckJWT())
 	{
 		userRoutes.GET("/profile", r.GetUserProfile)
+		userRoutes.GET("/watermark", r.GetWatermark)
+		userRoutes.PUT("/watermark", r.UpdateWatermark)
 		userRoutes.PUT("/:user_id", r.UpdateUser)
 		userRoutes.DELETE("/:user_id", r.DeactivateUser)
+		userRoutes.POST("/:user_id/report", r.ReportUser)
 	}
 
 	// Photo routes
 	photoRoutes := r.http.Group("photos", r.middlewares.CheckJWT())
 	{
 		photoRoutes.POST("", r.CreatePhoto)
+		photoRoutes.POST("/batch", r.CreateBatchPhoto)
+		photoRoutes.POST("/bulk", r.BulkPhoto)
+		photoRoutes.POST("/uploads", r.CreatePhotoUpload)
+		photoRoutes.POST("/uploads/:upload_id/complete", r.CompletePhotoUpload)
+
+		photoRoutes.OPTIONS("/tus", r.GetTusOptions)
+		photoRoutes.POST("/tus", r.CreateTusUpload)
+		photoRoutes.HEAD("/tus/:upload_id", r.GetTusUploadOffset)
+		photoRoutes.PATCH("/tus/:upload_id", r.PatchTusUpload)
+		photoRoutes.DELETE("/tus/:upload_id", r.DeleteTusUpload)
 		photoRoutes.GET("", r.GetListPhoto)
+		photoRoutes.GET("/trash", r.GetListTrashPhoto)
+		photoRoutes.GET("/archive", r.DownloadPhotoArchive)
 		photoRoutes.GET("/:photo_id", r.GetPhoto)
 		photoRoutes.PUT("/:photo_id", r.UpdatePhoto)
 		photoRoutes.DELETE("/:photo_id", r.DeletePhoto)
+		photoRoutes.GET("/:photo_id/content", r.GetPhotoContent)
+		photoRoutes.GET("/:photo_id/similar", r.GetListSimilarPhoto)
+		photoRoutes.GET("/:photo_id/status", r.GetPhotoStatus)
+		photoRoutes.POST("/:photo_id/restore", r.RestorePhoto)
+		photoRoutes.POST("/:photo_id/report", r.ReportPhoto)
+		photoRoutes.PUT("/:photo_id/file", r.ReplacePhotoFile)
+		photoRoutes.GET("/:photo_id/versions", r.GetListPhotoVersion)
+		photoRoutes.POST("/:photo_id/versions/:version_id/restore", r.RestorePhotoVersion)
+
+		photoRoutes.POST("/:photo_id/share-links", r.CreateShareLink)
+		photoRoutes.GET("/:photo_id/share-links", r.GetListShareLink)
+		photoRoutes.DELETE("/:photo_id/share-links/:share_link_id", r.RevokeShareLink)
+
+		photoRoutes.POST("/:photo_id/like", r.LikePhoto)
+		photoRoutes.DELETE("/:photo_id/like", r.UnlikePhoto)
+		photoRoutes.GET("/:photo_id/comments", r.GetListComment)
+		photoRoutes.POST("/:photo_id/comments", r.CreateComment)
+		photoRoutes.PUT("/:photo_id/comments/:comment_id", r.UpdateComment)
+		photoRoutes.DELETE("/:photo_id/comments/:comment_id", r.DeleteComment)
+	}
+
+	// Public routes, accessible without authentication
+	publicRoutes := r.http.Group("public")
+	{
+		publicRoutes.GET("/photos", r.GetListPublicPhoto)
+		publicRoutes.GET("/photos/:photo_id", r.GetPublicPhoto)
+	}
+	r.http.GET("/s/:token", r.ResolveShareLink)
+	r.http.GET("/files/:path/:file_name", r.GetSignedFile)
+	r.http.PUT("/files/:path/:file_name", r.PutSignedFile)
+
+	// Render route, authorized by the signature of its URL instead of a token
+	r.http.GET("/photos/:photo_id/render", r.RenderPhoto)
+
+	// Tag routes
+	tagRoutes := r.http.Group("tags", r.middlewares.CheckJWT())
+	{
+		tagRoutes.GET("", r.GetListTag)
+		tagRoutes.GET("/suggest", r.SuggestTag)
+	}
+
+	// Moderation routes, only for the moderators
+	moderationRoutes := r.http.Group("moderation", r.middlewares.CheckJWT(), r.middlewares.CheckModerator())
+	{
+		moderationRoutes.GET("/queue", r.GetModerationQueue)
+		moderationRoutes.POST("/queue/:moderation_id/approve", r.ApproveModeration)
+		moderationRoutes.POST("/queue/:moderation_id/reject", r.RejectModeration)
+		moderationRoutes.GET("/reports", r.GetListReport)
+		moderationRoutes.POST("/reports/:report_id/resolve", r.ResolveReport)
 	}
 
 	// 404 handler
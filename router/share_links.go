@@ -0,0 +1,131 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+	"rakamin-final-task/models"
+)
+
+// @Summary Create Share Link
+// @Description Create a share link of a photo for people without an account.
+// @Description A link shares a single photo, the photos are not grouped in albums that could be shared instead.
+// @Tags Share Links
+// @Produce json
+// @Param photo_id path int true "Photo ID"
+// @Param shareLinkBody body models.CreateShareLinkParams true "Share Link Body"
+// @Security BearerAuth
+// @Success 201 {object} response.HTTPResponse{data=models.ShareLinks}
+// @Failure 400 {object} response.HTTPResponse{}
+// @Failure 404 {object} response.HTTPResponse{}
+// @Failure 422 {object} response.HTTPResponse{}
+// @Failure 500 {object} response.HTTPResponse{}
+// @Router /photos/{photo_id}/share-links [POST]
+func (r *router) CreateShareLink(c *gin.Context) {
+	var body models.CreateShareLinkParams
+	if err := r.BindBody(c, &body); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	var shareLinkParam models.ShareLinkParams
+	if err := r.BindParam(c, &shareLinkParam); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	shareLink, err := r.usecase.ShareLinks.Create(c.Request.Context(), shareLinkParam, body)
+	if err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	r.response.Created(c, "Share link created", shareLink)
+}
+
+// @Summary Get List Share Link
+// @Description Get the share links of a photo
+// @Tags Share Links
+// @Produce json
+// @Param photo_id path int true "Photo ID"
+// @Security BearerAuth
+// @Success 200 {object} response.HTTPResponse{data=[]models.ShareLinks}
+// @Failure 400 {object} response.HTTPResponse{}
+// @Failure 500 {object} response.HTTPResponse{}
+// @Router /photos/{photo_id}/share-links [GET]
+func (r *router) GetListShareLink(c *gin.Context) {
+	var shareLinkParam models.ShareLinkParams
+	if err := r.BindParam(c, &shareLinkParam); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	shareLinks, err := r.usecase.ShareLinks.GetList(c.Request.Context(), shareLinkParam)
+	if err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	r.response.Success(c, "Get list share link successfull", shareLinks, nil)
+}
+
+// @Summary Revoke Share Link
+// @Description Revoke a share link so it can not be used anymore
+// @Tags Share Links
+// @Produce json
+// @Param photo_id path int true "Photo ID"
+// @Param share_link_id path int true "Share Link ID"
+// @Security BearerAuth
+// @Success 200 {object} response.HTTPResponse{data=models.ShareLinks}
+// @Failure 400 {object} response.HTTPResponse{}
+// @Failure 404 {object} response.HTTPResponse{}
+// @Failure 500 {object} response.HTTPResponse{}
+// @Router /photos/{photo_id}/share-links/{share_link_id} [DELETE]
+func (r *router) RevokeShareLink(c *gin.Context) {
+	var shareLinkParam models.ShareLinkParams
+	if err := r.BindParam(c, &shareLinkParam); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	shareLink, err := r.usecase.ShareLinks.Revoke(c.Request.Context(), shareLinkParam)
+	if err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	r.response.Success(c, "Revoke share link successfull", shareLink, nil)
+}
+
+// @Summary Resolve Share Link
+// @Description Get the photo of a share link without authentication.
+// @Description The link is locked for 15 minutes after 5 wrong passwords in a row.
+// @Tags Public
+// @Produce json
+// @Param token path string true "Share Link Token"
+// @Param x-share-password header string false "Share Link Password"
+// @Success 200 {object} response.HTTPResponse{data=models.Photos}
+// @Failure 401 {object} response.HTTPResponse{}
+// @Failure 404 {object} response.HTTPResponse{}
+// @Failure 410 {object} response.HTTPResponse{}
+// @Failure 429 {object} response.HTTPResponse{}
+// @Failure 500 {object} response.HTTPResponse{}
+// @Router /s/{token} [GET]
+func (r *router) ResolveShareLink(c *gin.Context) {
+	var shareLinkParam models.ResolveShareLinkParams
+	if err := c.ShouldBindUri(&shareLinkParam); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	if err := c.ShouldBindHeader(&shareLinkParam); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	photo, err := r.usecase.ShareLinks.Resolve(c.Request.Context(), shareLinkParam)
+	if err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	r.response.Success(c, "Resolve share link successfull", photo, nil)
+}
@@ -0,0 +1,53 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+	"rakamin-final-task/models"
+)
+
+// @Summary Get List Tag
+// @Description Get the tags of the current user with their usage counts
+// @Tags Tags
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.HTTPResponse{data=[]models.Tags}
+// @Failure 401 {object} response.HTTPResponse{}
+// @Failure 500 {object} response.HTTPResponse{}
+// @Router /tags [GET]
+func (r *router) GetListTag(c *gin.Context) {
+	tags, err := r.usecase.Tags.GetList(c.Request.Context())
+	if err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	r.response.Success(c, "Get list tag successfull", tags, nil)
+}
+
+// @Summary Suggest Tag
+// @Description Autocomplete the tags of the current user by prefix, ordered by usage count
+// @Tags Tags
+// @Produce json
+// @Param prefix query string false "Prefix"
+// @Param limit query int false "Limit"
+// @Security BearerAuth
+// @Success 200 {object} response.HTTPResponse{data=[]models.Tags}
+// @Failure 400 {object} response.HTTPResponse{}
+// @Failure 401 {object} response.HTTPResponse{}
+// @Failure 500 {object} response.HTTPResponse{}
+// @Router /tags/suggest [GET]
+func (r *router) SuggestTag(c *gin.Context) {
+	var tagParam models.TagParams
+	if err := r.BindParam(c, &tagParam); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	tags, err := r.usecase.Tags.Suggest(c.Request.Context(), tagParam)
+	if err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	r.response.Success(c, "Suggest tag successfull", tags, nil)
+}
@@ -0,0 +1,259 @@
+package router
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"rakamin-final-task/helpers/errors"
+	"rakamin-final-task/models"
+)
+
+const (
+	tusVersion     = "1.0.0"
+	tusExtensions  = "creation,termination,expiration"
+	tusContentType = "application/offset+octet-stream"
+)
+
+// @Summary Get Tus Options
+// @Description Get the tus protocol version, extensions and maximum upload size supported by the server
+// @Tags Photos
+// @Security BearerAuth
+// @Success 204 "No Content"
+// @Router /photos/tus [OPTIONS]
+func (r *router) GetTusOptions(c *gin.Context) {
+	r.setTusOptionHeaders(c)
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Create Tus Upload
+// @Description Create a resumable upload with the tus creation extension, the photo is described by the metadata
+// @Description keys filename, filetype, title, caption, visibility and tags, tags being comma separated
+// @Tags Photos
+// @Param Tus-Resumable header string true "Tus version" default(1.0.0)
+// @Param Upload-Length header int true "Upload size in bytes"
+// @Param Upload-Metadata header string true "Comma separated key and base64 value pairs"
+// @Security BearerAuth
+// @Success 201 "Created, the upload URL is in the Location header"
+// @Failure 400 {object} response.HTTPResponse{}
+// @Failure 412 {object} response.HTTPResponse{}
+// @Failure 413 {object} response.HTTPResponse{}
+// @Failure 422 {object} response.HTTPResponse{}
+// @Failure 500 {object} response.HTTPResponse{}
+// @Router /photos/tus [POST]
+func (r *router) CreateTusUpload(c *gin.Context) {
+	if err := r.checkTusResumable(c); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	if c.GetHeader("Upload-Defer-Length") != "" {
+		r.response.Error(c, errors.BadRequest("Upload-Defer-Length is not supported"))
+		return
+	}
+
+	length, err := strconv.ParseInt(c.GetHeader("Upload-Length"), 10, 64)
+	if err != nil {
+		r.response.Error(c, errors.BadRequest("Invalid Upload-Length"))
+		return
+	}
+
+	metadata, err := parseTusMetadata(c.GetHeader("Upload-Metadata"))
+	if err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	body := models.CreateTusUploadParams{
+		Length:   length,
+		Metadata: metadata,
+	}
+
+	tusUpload, err := r.usecase.Photos.CreateTusUpload(c.Request.Context(), body)
+	if err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("%s/%d", strings.TrimSuffix(c.Request.URL.Path, "/"), tusUpload.ID))
+	c.Header("Upload-Expires", time.Unix(tusUpload.ExpiresAt, 0).UTC().Format(http.TimeFormat))
+	c.Status(http.StatusCreated)
+}
+
+// @Summary Get Tus Upload Offset
+// @Description Get the offset of a resumable upload, the client resumes the upload from it
+// @Tags Photos
+// @Param upload_id path int true "Upload ID"
+// @Param Tus-Resumable header string true "Tus version" default(1.0.0)
+// @Security BearerAuth
+// @Success 200 "The offset is in the Upload-Offset header"
+// @Failure 404 {object} response.HTTPResponse{}
+// @Failure 410 {object} response.HTTPResponse{}
+// @Failure 412 {object} response.HTTPResponse{}
+// @Router /photos/tus/{upload_id} [HEAD]
+func (r *router) GetTusUploadOffset(c *gin.Context) {
+	var tusUploadParam models.TusUploadParams
+	if err := r.BindParam(c, &tusUploadParam); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	if err := r.checkTusResumable(c); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	tusUpload, err := r.usecase.Photos.GetTusUpload(c.Request.Context(), tusUploadParam)
+	if err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	r.setTusUploadHeaders(c, tusUpload)
+	c.Header("Upload-Length", strconv.FormatInt(tusUpload.Length, 10))
+	c.Status(http.StatusOK)
+}
+
+// @Summary Patch Tus Upload
+// @Description Append a chunk to a resumable upload at its offset, the photo is created with the last chunk
+// @Description and its ID is returned in the X-Photo-ID header
+// @Tags Photos
+// @Accept application/offset+octet-stream
+// @Param upload_id path int true "Upload ID"
+// @Param Tus-Resumable header string true "Tus version" default(1.0.0)
+// @Param Upload-Offset header int true "Offset of the chunk"
+// @Security BearerAuth
+// @Success 204 "The new offset is in the Upload-Offset header"
+// @Failure 400 {object} response.HTTPResponse{}
+// @Failure 404 {object} response.HTTPResponse{}
+// @Failure 409 {object} response.HTTPResponse{}
+// @Failure 410 {object} response.HTTPResponse{}
+// @Failure 412 {object} response.HTTPResponse{}
+// @Failure 415 {object} response.HTTPResponse{}
+// @Failure 500 {object} response.HTTPResponse{}
+// @Router /photos/tus/{upload_id} [PATCH]
+func (r *router) PatchTusUpload(c *gin.Context) {
+	var tusUploadParam models.TusUploadParams
+	if err := r.BindParam(c, &tusUploadParam); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	if err := r.checkTusResumable(c); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	if c.ContentType() != tusContentType {
+		r.response.Error(c, errors.UnsupportedMedia("Content-Type must be "+tusContentType))
+		return
+	}
+
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil {
+		r.response.Error(c, errors.BadRequest("Invalid Upload-Offset"))
+		return
+	}
+
+	body := models.PatchTusUploadParams{
+		Offset: offset,
+	}
+
+	tusUpload, err := r.usecase.Photos.PatchTusUpload(c.Request.Context(), tusUploadParam, body, c.Request.Body)
+	if err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	r.setTusUploadHeaders(c, tusUpload)
+	if tusUpload.PhotoID != nil {
+		c.Header("X-Photo-ID", strconv.FormatInt(*tusUpload.PhotoID, 10))
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Delete Tus Upload
+// @Description Terminate a resumable upload that is not complete yet, its data is deleted
+// @Tags Photos
+// @Param upload_id path int true "Upload ID"
+// @Param Tus-Resumable header string true "Tus version" default(1.0.0)
+// @Security BearerAuth
+// @Success 204 "No Content"
+// @Failure 404 {object} response.HTTPResponse{}
+// @Failure 409 {object} response.HTTPResponse{}
+// @Failure 410 {object} response.HTTPResponse{}
+// @Failure 412 {object} response.HTTPResponse{}
+// @Failure 500 {object} response.HTTPResponse{}
+// @Router /photos/tus/{upload_id} [DELETE]
+func (r *router) DeleteTusUpload(c *gin.Context) {
+	var tusUploadParam models.TusUploadParams
+	if err := r.BindParam(c, &tusUploadParam); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	if err := r.checkTusResumable(c); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	if err := r.usecase.Photos.DeleteTusUpload(c.Request.Context(), tusUploadParam); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// checkTusResumable sets the Tus-Resumable header, which every tus response has, and rejects other versions
+func (r *router) checkTusResumable(c *gin.Context) error {
+	c.Header("Tus-Resumable", tusVersion)
+
+	if c.GetHeader("Tus-Resumable") != tusVersion {
+		c.Header("Tus-Version", tusVersion)
+		return errors.PreconditionFailed("Unsupported Tus-Resumable version, use " + tusVersion)
+	}
+
+	return nil
+}
+
+func (r *router) setTusOptionHeaders(c *gin.Context) {
+	c.Header("Tus-Resumable", tusVersion)
+	c.Header("Tus-Version", tusVersion)
+	c.Header("Tus-Extension", tusExtensions)
+	c.Header("Tus-Max-Size", strconv.FormatInt(r.usecase.Photos.TusMaxSize(), 10))
+}
+
+func (r *router) setTusUploadHeaders(c *gin.Context, tusUpload models.TusUploads) {
+	c.Header("Upload-Offset", strconv.FormatInt(tusUpload.Offset, 10))
+	if tusUpload.PhotoID == nil {
+		c.Header("Upload-Expires", time.Unix(tusUpload.ExpiresAt, 0).UTC().Format(http.TimeFormat))
+	}
+}
+
+// parseTusMetadata reads the Upload-Metadata header, a comma separated list of keys and base64 encoded values
+func parseTusMetadata(header string) (map[string]string, error) {
+	metadata := map[string]string{}
+
+	for _, pair := range strings.Split(header, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		key, encoded, _ := strings.Cut(pair, " ")
+		value, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return metadata, errors.BadRequest("Invalid Upload-Metadata value of " + key)
+		}
+
+		metadata[key] = string(value)
+	}
+
+	return metadata, nil
+}
@@ -0,0 +1,74 @@
+package router
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"rakamin-final-task/helpers/errors"
+)
+
+func TestParseTusMetadata(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name:   "no header",
+			header: "",
+			want:   map[string]string{},
+		},
+		{
+			name:   "several pairs",
+			header: "filename cGhvdG8uanBn,filetype aW1hZ2UvanBlZw==",
+			want:   map[string]string{"filename": "photo.jpg", "filetype": "image/jpeg"},
+		},
+		{
+			name:   "spaces around the pairs",
+			header: " title U3Vuc2V0 , , tags c2VhLHNreQ== ",
+			want:   map[string]string{"title": "Sunset", "tags": "sea,sky"},
+		},
+		{
+			name:   "key without a value",
+			header: "is_confidential,title U3Vuc2V0",
+			want:   map[string]string{"is_confidential": "", "title": "Sunset"},
+		},
+		{
+			name:   "last value of a repeated key",
+			header: "title YQ==,title Yg==",
+			want:   map[string]string{"title": "b"},
+		},
+		{
+			name:    "value not base64",
+			header:  "filename photo.jpg",
+			wantErr: true,
+		},
+		{
+			name:    "value not padded",
+			header:  "title U3Vuc2V0IQ",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTusMetadata(tt.header)
+			if tt.wantErr {
+				if errors.GetCode(err) != http.StatusBadRequest {
+					t.Errorf("parseTusMetadata() error = %v, want a bad request", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("parseTusMetadata() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseTusMetadata(%q) = %v, want %v", tt.header, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"rakamin-final-task/helpers/files"
+	"rakamin-final-task/models"
+)
+
+// @Summary Get Watermark
+// @Description Get the watermark settings of the user, a user who never changed them has a disabled text watermark
+// @Tags Watermarks
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.HTTPResponse{data=models.Watermarks}
+// @Failure 500 {object} response.HTTPResponse{}
+// @Router /users/watermark [GET]
+func (r *router) GetWatermark(c *gin.Context) {
+	watermark, err := r.usecase.Watermarks.Get(c.Request.Context())
+	if err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	r.response.Success(c, "Get watermark successfull", watermark, nil)
+}
+
+// @Summary Update Watermark
+// @Description Update the watermark settings of the user, only the given settings are changed. Once enabled, the
+// @Description public and unlisted photos of the user are served to everyone else with the watermark drawn on them,
+// @Description the original files are left untouched and the watermarked copies are rendered again in the background.
+// @Description A photo is served without URL to everyone else until its first watermarked copy is rendered.
+// @Tags Watermarks
+// @Produce json
+// @Param enabled formData bool false "Enabled"
+// @Param type formData string false "Type" Enums(text, image)
+// @Param text formData string false "Text"
+// @Param position formData string false "Position" Enums(top-left, top-right, bottom-left, bottom-right, center)
+// @Param opacity formData number false "Opacity, from 0 to 1"
+// @Param logo formData file false "Logo"
+// @Accept multipart/form-data
+// @Security BearerAuth
+// @Success 200 {object} response.HTTPResponse{data=models.Watermarks}
+// @Failure 400 {object} response.HTTPResponse{}
+// @Failure 409 {object} response.HTTPResponse{}
+// @Failure 422 {object} response.HTTPResponse{}
+// @Failure 500 {object} response.HTTPResponse{}
+// @Router /users/watermark [PUT]
+func (r *router) UpdateWatermark(c *gin.Context) {
+	var body models.UpdateWatermarkParams
+
+	if err := r.BindBody(c, &body); err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	var logo *files.File
+	logoFile, meta, err := c.Request.FormFile("logo")
+	if err == nil {
+		logo, err = r.getPhotos(logoFile, meta)
+		if err != nil {
+			r.response.Error(c, err)
+			return
+		}
+	} else if err != http.ErrMissingFile && err != http.ErrNotMultipart {
+		r.response.Error(c, err)
+		return
+	}
+
+	watermark, err := r.usecase.Watermarks.Update(c.Request.Context(), body, logo)
+	if err != nil {
+		r.response.Error(c, err)
+		return
+	}
+
+	r.response.Success(c, "Update watermark successfull", watermark, nil)
+}